@@ -12,11 +12,7 @@ import (
 
 var configFile = "./config.json"
 
-type serverconfig struct {
-	Listen string `json:""`
-}
-
-func loadConfig(config *serverconfig) {
+func loadConfig(config *mud.ServerConfig) {
 	data, err := ioutil.ReadFile(configFile)
 
 	if err == nil {
@@ -46,10 +42,10 @@ func main() {
 		os.Chdir(executablePath)
 	}
 
-	var config serverconfig
+	var config mud.ServerConfig
 
 	loadConfig(&config)
 
 	mud.LoadResources()
-	mud.ServeSSH(config.Listen)
+	mud.ServeSSH(config)
 }
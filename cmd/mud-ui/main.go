@@ -15,11 +15,7 @@ import (
 
 var configFile = "./config.json"
 
-type serverconfig struct {
-	Listen string `json:""`
-}
-
-func loadConfig(config *serverconfig) {
+func loadConfig(config *mud.ServerConfig) {
 	data, err := ioutil.ReadFile(configFile)
 
 	if err == nil {
@@ -49,10 +45,10 @@ func main() {
 		os.Chdir(executablePath)
 	}
 
-	var config serverconfig
+	var config mud.ServerConfig
 	loadConfig(&config)
 	mud.LoadResources()
-	go mud.ServeSSH(config.Listen)
+	go mud.ServeSSH(config)
 
 	uierr := ui.Main(func() {
 		box := ui.NewVerticalBox()
@@ -0,0 +1,230 @@
+package mud
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCharacters is how many characters one account can have
+const maxCharacters = 5
+
+// maxCharacterName is the longest a character's name can be
+const maxCharacterName = 20
+
+// Account is who signs in over SSH: the keys they sign in with and the characters they play.
+// Characters are users like any other, so their names are unique across every account.
+type Account struct {
+	Name       string          `json:""` // The SSH username
+	PublicKeys map[string]bool `json:""`
+	Characters []string        `json:",omitempty"` // Usernames of the account's characters, oldest first
+	LastPlayed string          `json:",omitempty"` // Character that commands run without logging in act as
+}
+
+// AccountInfo handles SSH accounts and the characters they own
+type AccountInfo interface {
+	Account(string) (Account, bool)
+	SignIn(name, publicKey string) (Account, error)
+	CreateCharacter(account, name string) error
+	DeleteCharacter(account, name string) error
+	PlayCharacter(account, name string) (User, error)
+}
+
+// Owns checks whether a character belongs to the account
+func (account *Account) Owns(name string) bool {
+	for _, character := range account.Characters {
+		if character == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validCharacterName says what's wrong with a name for a new character, if anything. Names
+// are one word so commands can take them as an argument.
+func validCharacterName(name string) error {
+	length := utf8.RuneCountInString(name)
+	if length < 2 || length > maxCharacterName {
+		return fmt.Errorf("Names are 2 to %v characters long", maxCharacterName)
+	}
+
+	for _, r := range name {
+		if !characterNameRune(r) {
+			return fmt.Errorf("Names can only have letters, numbers, hyphens and underscores")
+		}
+	}
+
+	return nil
+}
+
+// characterNameRune checks whether a character can go in a character's name
+func characterNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_'
+}
+
+func (w *dbWorld) Account(name string) (Account, bool) {
+	var account Account
+	found := false
+
+	w.store.View(func(tx Tx) error {
+		account, found = accountRepo(tx).Get(name)
+
+		return nil
+	})
+
+	return account, found
+}
+
+// SignIn loads the account for an SSH username and checks the key. The first key seen claims a
+// new account, and a user from before there were accounts becomes its first character, keys and all.
+// Those users all signed in with a key, so a user record without one isn't taken for one of them.
+func (w *dbWorld) SignIn(name, publicKey string) (Account, error) {
+	var account Account
+
+	err := w.store.Update(func(tx Tx) error {
+		accounts, users := accountRepo(tx), userRepo(tx)
+
+		var found bool
+		if account, found = accounts.Get(name); !found {
+			account = Account{Name: name, PublicKeys: make(map[string]bool)}
+
+			if userData, ok := users.Get(name); ok && userData.Account == "" && len(userData.PublicKeys) > 0 {
+				for key, valid := range userData.PublicKeys {
+					account.PublicKeys[key] = valid
+				}
+				account.Characters = []string{name}
+				account.LastPlayed = name
+
+				userData.Account = name
+				if err := users.Put(&userData); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(account.PublicKeys) == 0 {
+			account.PublicKeys[publicKey] = true
+			log.Printf("Saving SSH key for %s", name)
+		} else if !account.PublicKeys[publicKey] {
+			return fmt.Errorf("This is not the SSH key verified for this user. Try another username.")
+		}
+
+		return accounts.Put(&account)
+	})
+
+	return account, err
+}
+
+// CreateCharacter makes a new character for an account, ready for character setup
+func (w *dbWorld) CreateCharacter(accountName, name string) error {
+	name = strings.TrimSpace(name)
+	if err := validCharacterName(name); err != nil {
+		return err
+	}
+
+	userData := w.newUser(name)
+	userData.Account = accountName
+
+	return w.store.Update(func(tx Tx) error {
+		accounts, users := accountRepo(tx), userRepo(tx)
+
+		account, found := accounts.Get(accountName)
+		if !found {
+			return fmt.Errorf("There's no account called %v", accountName)
+		} else if len(account.Characters) >= maxCharacters {
+			return fmt.Errorf("You already have %v characters", maxCharacters)
+		} else if _, taken := users.Get(name); taken {
+			return fmt.Errorf("%v is already taken", name)
+		}
+
+		account.Characters = append(account.Characters, name)
+
+		if err := users.Put(&userData); err != nil {
+			return err
+		}
+
+		return accounts.Put(&account)
+	})
+}
+
+// DeleteCharacter gets rid of one of an account's characters for good, along with everything
+// they carry, wear and have banked, their quests and their log
+func (w *dbWorld) DeleteCharacter(accountName, name string) error {
+	for _, online := range w.OnlineUsers() {
+		if online.Username() == name {
+			return fmt.Errorf("%v is playing right now", name)
+		}
+	}
+
+	return w.store.Update(func(tx Tx) error {
+		accounts, users := accountRepo(tx), userRepo(tx)
+
+		account, found := accounts.Get(accountName)
+		if !found || !account.Owns(name) {
+			return fmt.Errorf("You don't have a character called %v", name)
+		}
+
+		if _, inParty := partyRepo(tx).ForUser(name); inParty {
+			return fmt.Errorf("%v has to leave their party first", name)
+		}
+		if trade, ok := tradeRepo(tx).Get(name); ok {
+			if err := tradeRepo(tx).Delete(&trade); err != nil {
+				return err
+			}
+		}
+		if duel, ok := duelRepo(tx).Get(name); ok {
+			if err := duelRepo(tx).Delete(&duel); err != nil {
+				return err
+			}
+		}
+
+		for _, bucket := range []string{"userinventory", "userequipment", "userbank", "userlog", "userquests"} {
+			if err := deleteOwned(tx.Bucket(bucket), []byte(name)); err != nil {
+				return err
+			}
+		}
+
+		if err := users.Delete(name); err != nil {
+			return err
+		}
+
+		characters := make([]string, 0, len(account.Characters))
+		for _, character := range account.Characters {
+			if character != name {
+				characters = append(characters, character)
+			}
+		}
+		account.Characters = characters
+
+		if account.LastPlayed == name {
+			account.LastPlayed = ""
+		}
+
+		return accounts.Put(&account)
+	})
+}
+
+// PlayCharacter picks which of an account's characters to play
+func (w *dbWorld) PlayCharacter(accountName, name string) (User, error) {
+	err := w.store.Update(func(tx Tx) error {
+		accounts := accountRepo(tx)
+
+		account, found := accounts.Get(accountName)
+		if !found || !account.Owns(name) {
+			return fmt.Errorf("You don't have a character called %v", name)
+		}
+
+		account.LastPlayed = name
+
+		return accounts.Put(&account)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.GetUser(name), nil
+}
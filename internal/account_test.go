@@ -0,0 +1,237 @@
+package mud
+
+import (
+	"fmt"
+	"testing"
+)
+
+// putTestUserData writes a user record straight to the store, the way older builds did
+func putTestUserData(t *testing.T, world *dbWorld, userData UserData) {
+	t.Helper()
+
+	err := world.store.Update(func(tx Tx) error {
+		return userRepo(tx).Put(&userData)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func storedUser(world *dbWorld, username string) (UserData, bool) {
+	var userData UserData
+	found := false
+
+	world.store.View(func(tx Tx) error {
+		userData, found = userRepo(tx).Get(username)
+
+		return nil
+	})
+
+	return userData, found
+}
+
+func TestSignIn(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []string
+		wantErr bool
+	}{
+		{"first key claims it", []string{"one"}, false},
+		{"same key again", []string{"one", "one"}, false},
+		{"someone else's key", []string{"one", "two"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+
+			var err error
+			for _, key := range test.keys {
+				_, err = world.SignIn("ann", key)
+			}
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("SignIn() = %v, want error %v", err, test.wantErr)
+			}
+			if account, _ := world.Account("ann"); len(account.PublicKeys) != 1 || !account.PublicKeys["one"] {
+				t.Fatalf("account keys = %v, want only the first", account.PublicKeys)
+			}
+		})
+	}
+}
+
+func TestSignInMigrations(t *testing.T) {
+	tests := []struct {
+		name        string
+		userData    UserData
+		wantAdopted bool
+	}{
+		{"from before accounts", UserData{Username: "ann", PublicKeys: map[string]bool{"key": true}}, true},
+		{"no keys, so never signed in", UserData{Username: "ann"}, false},
+		{"someone else's character", UserData{Username: "ann", Account: "bob"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			putTestUserData(t, world, test.userData)
+
+			if _, err := world.SignIn("ann", "key"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := world.SignIn("ann", "other"); err == nil {
+				t.Fatal("SignIn() took a second key")
+			}
+
+			account, _ := world.Account("ann")
+			if adopted := account.Owns("ann"); adopted != test.wantAdopted {
+				t.Fatalf("account owns ann: %v, want %v", adopted, test.wantAdopted)
+			}
+
+			userData, _ := storedUser(world, "ann")
+			wantAccount := test.userData.Account
+			if test.wantAdopted {
+				wantAccount = "ann"
+			}
+			if userData.Account != wantAccount {
+				t.Fatalf("ann belongs to %q, want %q", userData.Account, wantAccount)
+			}
+		})
+	}
+}
+
+func TestSaveSkipsUnknownUsers(t *testing.T) {
+	world := newTestWorld(t)
+
+	// Looking someone up by a name nobody has, then saving, mustn't make them up
+	ghost := world.GetUser("ghost")
+	ghost.Save()
+
+	if _, found := storedUser(world, "ghost"); found {
+		t.Fatal("Save() wrote a user who doesn't exist")
+	}
+
+	if _, err := world.SignIn("ghost", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if account, _ := world.Account("ghost"); len(account.Characters) != 0 {
+		t.Fatalf("new account came with characters %v", account.Characters)
+	}
+}
+
+func TestCreateCharacter(t *testing.T) {
+	tests := []struct {
+		name     string
+		account  string
+		existing int
+		taken    bool
+		newName  string
+		wantErr  bool
+	}{
+		{"first", "ann", 0, false, "Ann", false},
+		{"trims spaces", "ann", 0, false, " Ann ", false},
+		{"last slot", "ann", maxCharacters - 1, false, "Ann", false},
+		{"too many", "ann", maxCharacters, false, "Ann", true},
+		{"name taken", "ann", 0, true, "Ann", true},
+		{"name too short", "ann", 0, false, "A", true},
+		{"name with a space", "ann", 0, false, "Ann Lee", true},
+		{"no such account", "nobody", 0, false, "Ann", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			if _, err := world.SignIn("ann", "key"); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < test.existing; i++ {
+				if err := world.CreateCharacter("ann", fmt.Sprintf("alt%v", i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.taken {
+				newTestCharacter(t, world, "Ann")
+			}
+
+			err := world.CreateCharacter(test.account, test.newName)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("CreateCharacter(%q) = %v, want error %v", test.newName, err, test.wantErr)
+			}
+
+			account, _ := world.Account("ann")
+			if account.Owns("Ann") == test.wantErr {
+				t.Fatalf("account has %v", account.Characters)
+			}
+			if userData, found := storedUser(world, "Ann"); !test.wantErr && (!found || userData.Account != "ann") {
+				t.Fatalf("stored Ann = %+v, %v", userData, found)
+			}
+		})
+	}
+}
+
+func TestDeleteCharacter(t *testing.T) {
+	tests := []struct {
+		name    string
+		account string
+		online  bool
+		wantErr bool
+	}{
+		{"own character", "ann", false, false},
+		{"someone else's", "bob", false, true},
+		{"playing right now", "ann", true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			for _, name := range []string{"ann", "bob"} {
+				if _, err := world.SignIn(name, "key-"+name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := world.CreateCharacter("ann", "Ann"); err != nil {
+				t.Fatal(err)
+			}
+			user, err := world.PlayCharacter("ann", "Ann")
+			if err != nil {
+				t.Fatal(err)
+			}
+			giveTestItem(t, user, "Healing Potion")
+			if test.online {
+				user.MarkActive()
+			}
+
+			err = world.DeleteCharacter(test.account, "Ann")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DeleteCharacter() = %v, want error %v", err, test.wantErr)
+			}
+
+			account, _ := world.Account("ann")
+			_, found := storedUser(world, "Ann")
+			if account.Owns("Ann") != test.wantErr || found != test.wantErr {
+				t.Fatalf("account has %v, record still there: %v", account.Characters, found)
+			}
+			if !test.wantErr && (account.LastPlayed != "" || len(world.GetUser("Ann").InventoryItems()) != 0) {
+				t.Fatalf("last played %q, inventory left behind", account.LastPlayed)
+			}
+		})
+	}
+}
+
+func TestPlayCharacter(t *testing.T) {
+	world := newTestWorld(t)
+	newTestCharacter(t, world, "ann")
+	if _, err := world.SignIn("bob", "key-bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := world.PlayCharacter("bob", "ann"); err == nil {
+		t.Fatal("PlayCharacter() let bob play ann's character")
+	}
+	if user, err := world.PlayCharacter("ann", "ann"); err != nil || user.Username() != "ann" {
+		t.Fatalf("PlayCharacter() = %v, %v", user, err)
+	}
+	if account, _ := world.Account("ann"); account.LastPlayed != "ann" {
+		t.Fatalf("last played %q, want ann", account.LastPlayed)
+	}
+}
@@ -0,0 +1,48 @@
+package mud
+
+import "fmt"
+
+// defaultBankCapacity is how many items a bank holds before any upgrades
+const defaultBankCapacity = 20
+
+// bankUpgradeSlots is how much room each bank upgrade adds
+const bankUpgradeSlots = 10
+
+// bankUpgradeCost is what the first bank upgrade costs; each one after costs that much more
+const bankUpgradeCost = 100
+
+// BankInfo handles the items a user keeps safe in their bank
+type BankInfo interface {
+	BankItems() []*InventoryItem
+	BankCapacity() int
+	BankUpgradePrice() uint64
+	Deposit(itemID string) error
+	Withdraw(itemID string) error
+	UpgradeBank() error
+}
+
+func bankCapacity(upgrades uint64) int {
+	return defaultBankCapacity + int(upgrades)*bankUpgradeSlots
+}
+
+func bankUpgradePrice(upgrades uint64) uint64 {
+	return bankUpgradeCost * (upgrades + 1)
+}
+
+// atBank says why a user can't get to their bank, if they can't. Banks are on terrain marked
+// as one, or wherever there's a banker.
+func atBank(user User) error {
+	cell := user.Cell()
+
+	if cellInfo := cell.CellInfo(); cellInfo != nil && cellInfo.TerrainData.Bank {
+		return nil
+	}
+
+	for _, creature := range cell.GetCreatures() {
+		if creature.HP > 0 && creature.CreatureTypeStruct.Banker {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("There's no bank here")
+}
@@ -0,0 +1,157 @@
+package mud
+
+import "testing"
+
+func TestBankCapacity(t *testing.T) {
+	tests := []struct {
+		upgrades     uint64
+		wantCapacity int
+		wantPrice    uint64
+	}{
+		{0, 20, 100},
+		{1, 30, 200},
+		{4, 60, 500},
+	}
+
+	for _, test := range tests {
+		if capacity := bankCapacity(test.upgrades); capacity != test.wantCapacity {
+			t.Fatalf("bankCapacity(%v) = %v, want %v", test.upgrades, capacity, test.wantCapacity)
+		}
+		if price := bankUpgradePrice(test.upgrades); price != test.wantPrice {
+			t.Fatalf("bankUpgradePrice(%v) = %v, want %v", test.upgrades, price, test.wantPrice)
+		}
+	}
+}
+
+func TestUpgradeBank(t *testing.T) {
+	tests := []struct {
+		name         string
+		coins        uint64
+		times        int
+		wantErr      bool
+		wantCoins    uint64
+		wantCapacity int
+	}{
+		{"one upgrade", 150, 1, false, 50, 30},
+		{"two upgrades", 300, 2, false, 0, 40},
+		{"can't afford the second", 250, 2, true, 150, 30},
+		{"can't afford any", 99, 1, true, 99, 20},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user, _ := newTestVendor(t, world, "saver", "porter")
+			user.AddCoins(test.coins)
+
+			var err error
+			for i := 0; i < test.times; i++ {
+				err = user.UpgradeBank()
+			}
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("UpgradeBank() = %v, want error %v", err, test.wantErr)
+			}
+			if coins := user.Coins(); coins != test.wantCoins {
+				t.Fatalf("left with %v coins, want %v", coins, test.wantCoins)
+			}
+			if capacity := user.BankCapacity(); capacity != test.wantCapacity {
+				t.Fatalf("BankCapacity() = %v, want %v", capacity, test.wantCapacity)
+			}
+		})
+	}
+}
+
+func TestBankUpgradesSeenByStaleUser(t *testing.T) {
+	world := newTestWorld(t)
+	user, _ := newTestVendor(t, world, "saver", "porter")
+	user.AddCoins(100)
+
+	// Another session upgrades the bank; this one hasn't reloaded
+	other := world.GetUser("saver")
+	if err := other.UpgradeBank(); err != nil {
+		t.Fatal(err)
+	}
+
+	if capacity := user.BankCapacity(); capacity != 30 {
+		t.Fatalf("BankCapacity() = %v, want 30", capacity)
+	}
+	if price := user.BankUpgradePrice(); price != 200 {
+		t.Fatalf("BankUpgradePrice() = %v, want 200", price)
+	}
+	if upgrades := user.(*dbUser).UserData.BankUpgrades; upgrades != 0 {
+		t.Fatalf("reading the bank reloaded the user, with %v upgrades", upgrades)
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	tests := []struct {
+		name        string
+		banked      int
+		wantErr     bool
+		wantBanked  int
+		wantCarried int
+	}{
+		{"room to spare", 0, false, 1, 0},
+		{"last slot", defaultBankCapacity - 1, false, defaultBankCapacity, 0},
+		{"full", defaultBankCapacity, true, defaultBankCapacity, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user, _ := newTestVendor(t, world, "saver", "porter")
+
+			for i := 0; i < test.banked; i++ {
+				if err := user.Deposit(giveTestItem(t, user, "Skull").ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			item := giveTestItem(t, user, "Healing Potion")
+			err := user.Deposit(item.ID)
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Deposit() = %v, want error %v", err, test.wantErr)
+			}
+			if banked := len(user.BankItems()); banked != test.wantBanked {
+				t.Fatalf("%v items banked, want %v", banked, test.wantBanked)
+			}
+			if carried := carriedItems(user.InventoryItems())["Healing Potion"]; carried != test.wantCarried {
+				t.Fatalf("carrying %v potions, want %v", carried, test.wantCarried)
+			}
+		})
+	}
+}
+
+func TestWithdraw(t *testing.T) {
+	world := newTestWorld(t)
+	user, _ := newTestVendor(t, world, "saver", "porter")
+	item := giveTestItem(t, user, "Healing Potion")
+
+	if err := user.Deposit(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := user.Withdraw(item.ID); err != nil {
+		t.Fatalf("Withdraw() = %v", err)
+	}
+	if err := user.Withdraw(item.ID); err == nil {
+		t.Fatal("Withdraw() took the same item out twice")
+	}
+	if len(user.BankItems()) != 0 || carriedItems(user.InventoryItems())["Healing Potion"] != 1 {
+		t.Fatalf("banked %v, carrying %v", user.BankItems(), user.InventoryItems())
+	}
+}
+
+func TestBankNeedsBanker(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "saver")
+	item := giveTestItem(t, user, "Healing Potion")
+
+	if err := user.Deposit(item.ID); err == nil {
+		t.Fatal("Deposit() worked with no bank around")
+	}
+	if err := user.UpgradeBank(); err == nil {
+		t.Fatal("UpgradeBank() worked with no bank around")
+	}
+}
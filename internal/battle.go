@@ -3,6 +3,7 @@ package mud
 import (
 	"fmt"
 	"log"
+	"math/rand"
 	"regexp"
 	"strconv"
 	"strings"
@@ -29,6 +30,7 @@ type StatInfo interface {
 	XP() uint64
 	AddXP(uint64)
 	XPToNextLevel() uint64
+	Level() uint64
 }
 
 // GetStatPoints is for StatPointable
@@ -39,6 +41,36 @@ func GetStatPoints(statinfo StatInfo) StatPoints {
 		MP: statinfo.MaxMP()}
 }
 
+// GetDefensePoints is what a user defends with: their stats and Orderly skill, plus whatever their
+// equipped items add as a flat Defense block or as DefenseBonuses worked out from the user's own stats
+func GetDefensePoints(user User) StatPoints {
+	base := FullStatPoints{StatPoints: GetStatPoints(user), HP: user.MaxHP()}
+	defense := base
+
+	orderly := orderlyDefense(user)
+	defense.AP += orderly
+	defense.RP += orderly
+	defense.MP += orderly
+
+	for _, slot := range user.Equipped() {
+		if slot.Item == nil {
+			continue
+		}
+
+		if slot.Item.Defense != nil {
+			defense.AP += slot.Item.Defense.AP
+			defense.RP += slot.Item.Defense.RP
+			defense.MP += slot.Item.Defense.MP
+		}
+
+		if slot.Item.DefenseBonuses != "" {
+			ApplyBonuses(&base, &defense, slot.Item.DefenseBonuses)
+		}
+	}
+
+	return defense.StatPoints
+}
+
 // StatPointable lets an item return StatInfos for battle calculations
 type StatPointable interface {
 	StatPoints() StatPoints
@@ -95,6 +127,18 @@ func (input *StatPoints) ApplyDefense(apply *StatPoints) *StatPoints {
 	return &resolved
 }
 
+// restorePoints adds to a stat without going over its max
+func restorePoints(current, max, amount uint64) uint64 {
+	if current+amount > max {
+		if current > max {
+			return current
+		}
+		return max
+	}
+
+	return current + amount
+}
+
 // Damage is how much untyped damage is dealt via this StatPoints
 func (input *StatPoints) Damage() uint64 {
 	return input.AP + input.MP + input.RP
@@ -113,7 +157,15 @@ type Attack struct {
 	UsesItems    []string `json:",omitempty"`
 	OutputsItems []string `json:",omitempty"`
 	Effects      []string `json:""`
-	Charge       int64    `json:""` // In Seconds
+	Charge       int64    `json:""`           // In Seconds
+	Probability  float32  `json:",omitempty"` // Counterattacks only: 0-1.0 chance of triggering, 0 means always
+	Cooldown     int64    `json:",omitempty"` // Counterattacks only: seconds before it can trigger again
+	IsCounter    bool     `json:"-"`          // Counterattacks can't themselves be countered
+}
+
+// counterTriggers rolls whether a counterattack goes off this time
+func (atk *Attack) counterTriggers() bool {
+	return atk.Probability <= 0 || rand.Float32() < atk.Probability
 }
 
 func (atk *Attack) String() string {
@@ -0,0 +1,62 @@
+package mud
+
+import "testing"
+
+func TestApplyDefense(t *testing.T) {
+	tests := []struct {
+		name    string
+		attack  StatPoints
+		defense StatPoints
+		want    StatPoints
+	}{
+		{"no defense", StatPoints{AP: 5, RP: 5, MP: 5}, StatPoints{}, StatPoints{AP: 5, RP: 5, MP: 5}},
+		{"RP soaks AP", StatPoints{AP: 5}, StatPoints{RP: 3}, StatPoints{AP: 2}},
+		{"MP soaks RP", StatPoints{RP: 5}, StatPoints{MP: 8}, StatPoints{}},
+		{"AP soaks MP", StatPoints{MP: 5}, StatPoints{AP: 1}, StatPoints{MP: 4}},
+		{"wrong stat doesn't help", StatPoints{AP: 5}, StatPoints{AP: 10, MP: 10}, StatPoints{AP: 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.attack.ApplyDefense(&test.defense); *got != test.want {
+				t.Fatalf("ApplyDefense() = %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetDefensePointsWithArmor(t *testing.T) {
+	tests := []struct {
+		armor  string
+		wantRP uint64 // On top of the user's own, at least
+		wantMP uint64
+	}{
+		{"Iron Helm", 2, 0},
+		{"Leather Cowl", 0, 2},
+		{"Chain Shirt", 3, 0}, // Plus 10% of the user's RP
+	}
+
+	for _, test := range tests {
+		t.Run(test.armor, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "knight")
+			user.SetStrengths(MELEEPRIMARY, RANGESECONDARY)
+			user.Initialize(true)
+			before := GetDefensePoints(user)
+
+			item := giveTestItem(t, user, test.armor)
+			slots := user.EquippableSlots(item)
+			if len(slots) == 0 {
+				t.Fatalf("nowhere to wear %v", test.armor)
+			}
+			if err := equipFromInventory(user, slots[0], item); err != nil {
+				t.Fatal(err)
+			}
+
+			after := GetDefensePoints(user)
+			if after.RP < before.RP+test.wantRP || after.MP != before.MP+test.wantMP || after.AP != before.AP {
+				t.Fatalf("defense went from %+v to %+v wearing %v", before, after, test.armor)
+			}
+		})
+	}
+}
@@ -1,23 +1,23 @@
 package mud
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"log"
 	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	bolt "go.etcd.io/bbolt"
 )
 
 type dbWorld struct {
-	filename         string
-	database         *bolt.DB
+	store            Store
+	seed             int64
 	closeActiveCells chan struct{}
 	activeCellCache  sync.Map
+	respawns         RespawnConfig
 }
 
 type recentCellInfo struct {
@@ -27,6 +27,7 @@ type recentCellInfo struct {
 	creatures             []*Creature
 	lastCreatureAction    map[string]int64
 	desiredCreatureCharge map[string]int64
+	lastCreatureMove      map[string]int64
 }
 
 func (w *dbWorld) chargeUsers() {
@@ -50,6 +51,13 @@ func (w *dbWorld) activateCell(x, y uint32) {
 	key := string(pt.Bytes())
 	now := time.Now().Unix()
 
+	if ci, ok := w.activeCellCache.Load(key); ok {
+		if cellInfo, ok := ci.(*recentCellInfo); ok {
+			cellInfo.lastVisit = now
+			return
+		}
+	}
+
 	cell := w.Cell(x, y)
 
 	rci := &recentCellInfo{
@@ -59,7 +67,8 @@ func (w *dbWorld) activateCell(x, y uint32) {
 		cellInfo:              cell.CellInfo(),
 		creatures:             cell.GetCreatures(),
 		lastCreatureAction:    make(map[string]int64),
-		desiredCreatureCharge: make(map[string]int64)}
+		desiredCreatureCharge: make(map[string]int64),
+		lastCreatureMove:      make(map[string]int64)}
 
 	ci, _ := w.activeCellCache.LoadOrStore(key, rci)
 	cellInfo, ok := ci.(*recentCellInfo)
@@ -69,8 +78,18 @@ func (w *dbWorld) activateCell(x, y uint32) {
 	}
 }
 
+// activateCellsAround keeps every cell within a radius of a point active
+func (w *dbWorld) activateCellsAround(x, y uint32, radius uint32) {
+	for cx := x - radius; cx != x+radius+1; cx++ {
+		for cy := y - radius; cy != y+radius+1; cy++ {
+			w.activateCell(cx, cy)
+		}
+	}
+}
+
 func (w *dbWorld) updateActivatedCells() {
 	now := time.Now().Unix()
+	users := w.OnlineUsers()
 
 	w.activeCellCache.Range(func(k, v interface{}) bool {
 		cell, ok := v.(*recentCellInfo)
@@ -84,8 +103,29 @@ func (w *dbWorld) updateActivatedCells() {
 				lastAction, ok := cell.lastCreatureAction[creature.ID]
 
 				if !ok {
-					cell.lastCreatureAction[creature.ID] = now
 					lastAction = now
+				} else if creature.Countered > lastAction {
+					// Counterattacking spent its charge
+					lastAction = creature.Countered
+				}
+				cell.lastCreatureAction[creature.ID] = lastAction
+
+				creature.ChargePoints()
+
+				if effectsStun(creature.Effects, now) {
+					cell.lastCreatureAction[creature.ID] = now
+					creature.Charge = 0
+					continue
+				}
+
+				// Slowed creatures' charge only builds every other second
+				if effectsSlow(creature.Effects, now) && now%2 == 0 && lastAction < now {
+					lastAction++
+					cell.lastCreatureAction[creature.ID] = lastAction
+				}
+
+				if w.creatureBehave(cell, creature, users, now) || !creature.CreatureTypeStruct.Hostile {
+					continue
 				}
 
 				creature.Charge = now - lastAction
@@ -95,22 +135,22 @@ func (w *dbWorld) updateActivatedCells() {
 
 				desiredLevel, ok := cell.desiredCreatureCharge[creature.ID]
 				resetLevel := false
+				rng := regionRand(w, fmt.Sprintf("turn:%v:%v", creature.ID, now), Point{X: creature.X, Y: creature.Y})
 
 				if !ok || desiredLevel == 0 {
 					resetLevel = true
 				} else if desiredLevel <= creature.Charge {
 					location := Point{X: creature.X, Y: creature.Y}
 					resetLevel = true
-					attack := creature.CreatureTypeStruct.Attacks[rand.Int()%len(creature.CreatureTypeStruct.Attacks)]
-					attack = attack.ApplyBonuses(creature)
-					if attack.Charge <= creature.Charge {
+					attack := creature.PickAttack(rng)
+					if attack != nil && attack.Charge <= creature.Charge {
 						usersInCell := w.usersInCell(location)
 
 						if len(usersInCell) > 0 {
-							user := usersInCell[rand.Int()%len(usersInCell)]
+							user := usersInCell[rng.Intn(len(usersInCell))]
 							user.Reload()
 							if *(user.Location()) == location {
-								w.Attack(creature, user, &attack)
+								w.Attack(creature, user, attack)
 								cell.lastCreatureAction[creature.ID] = now
 							}
 						}
@@ -119,7 +159,7 @@ func (w *dbWorld) updateActivatedCells() {
 
 				if resetLevel {
 					if (creature.maxCharge) > 0 {
-						desiredLevel = int64(1 + rand.Int()%int(creature.maxCharge))
+						desiredLevel = 1 + rng.Int63n(creature.maxCharge)
 						cell.desiredCreatureCharge[creature.ID] = desiredLevel
 					}
 				}
@@ -130,6 +170,42 @@ func (w *dbWorld) updateActivatedCells() {
 	})
 }
 
+func (w *dbWorld) tickEffects() {
+	for _, user := range w.OnlineUsers() {
+		user.TickEffects()
+	}
+
+	now := time.Now().Unix()
+
+	w.activeCellCache.Range(func(k, v interface{}) bool {
+		cell, ok := v.(*recentCellInfo)
+
+		if ok {
+			for _, creature := range cell.creatures {
+				if creature.HP <= 0 || len(creature.Effects) == 0 {
+					continue
+				}
+
+				effects, change, _ := tickEffects(creature.Effects, now)
+				creature.Effects = effects
+				creature.HP = applyHPChange(creature.HP, creature.CreatureTypeStruct.MaxHP, change)
+
+				if creature.HP == 0 {
+					location := Point{X: creature.X, Y: creature.Y}
+					w.Chat(LogItem{Author: creature.CreatureTypeStruct.Name, Message: "Succumbed to its wounds!", MessageType: MESSAGEACTIVITY, Location: &location})
+					w.creatureKilled(creature, "")
+				} else {
+					w.advanceBossPhase(creature)
+				}
+
+				w.Cell(creature.X, creature.Y).UpdateCreature(creature)
+			}
+		}
+
+		return true
+	})
+}
+
 func (w *dbWorld) sweepExpiredKeys() {
 	keys := make([]string, 0)
 
@@ -174,44 +250,8 @@ func (w *dbWorld) GetUser(username string) User {
 }
 
 func (w *dbWorld) newUser(username string) UserData {
-	width, height := w.GetDimensions()
-	userData := UserData{
-		Username:   username,
-		X:          width / 2,
-		Y:          height / 2,
-		SpawnX:     width / 2,
-		SpawnY:     height / 2,
-		HP:         10,
-		MaxHP:      10,
-		AP:         2,
-		MaxAP:      2,
-		MP:         2,
-		MaxMP:      2,
-		RP:         2,
-		MaxRP:      2,
-		PublicKeys: make(map[string]bool)}
-	cellData := w.Cell(userData.X, userData.Y).CellInfo()
-
-	if cellData == nil {
-		newRegionID, _ := newPlaceNameInDB(w.database)
-		cellData = &CellInfo{
-			TerrainID:    DefaultCellType,
-			RegionNameID: newRegionID,
-			BiomeID:      DefaultBiomeType}
-
-		for x := -1; x <= 1; x++ {
-			for y := -1; y <= 1; y++ {
-				cell := w.Cell(uint32(int(userData.X)+x), uint32(int(userData.Y)+y))
-				if x == 0 && y == 0 {
-					cellData.TerrainID = DefaultCellType
-				} else {
-					cellData.TerrainID = "grass"
-				}
-				cell.SetCellInfo(cellData)
-			}
-		}
-	}
-
+	userData := newUserData(username, w)
+	seedSpawnArea(w, userData.X, userData.Y)
 	return userData
 }
 
@@ -231,8 +271,8 @@ func (w *dbWorld) reloadStoredCreatures(x, y uint32) {
 		ci, cast := record.(*recentCellInfo)
 
 		if cast {
-			cell := w.Cell(x, y)
-			ci.creatures = cell.GetCreatures()
+			cell := &dbCell{w: w, x: x, y: y}
+			ci.creatures = cell.getCreatures()
 		}
 	}
 }
@@ -240,51 +280,25 @@ func (w *dbWorld) reloadStoredCreatures(x, y uint32) {
 func (w *dbWorld) getCreature(id string) *Creature {
 	var creature *Creature
 
-	w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("creatures"))
-
-		id, err := uuid.Parse(id)
-
-		if err != nil {
-			return err
-		}
-
-		byteID, err := id.MarshalBinary()
+	w.store.View(func(tx Tx) error {
+		creature = creatureRepo(tx).Get(id)
 
-		if err != nil {
-			return err
-		}
-
-		recordBytes := bucket.Get(byteID)
-
-		if recordBytes != nil {
-			creature = &Creature{}
-			MSGUnpack(recordBytes, creature)
-		}
-
-		return err
+		return nil
 	})
 
+	if creature == nil {
+		return nil
+	}
+
 	creature.CreatureTypeStruct = CreatureTypes[creature.CreatureType]
 	creature.maxCharge = int64(creature.CreatureTypeStruct.MaxAP+creature.CreatureTypeStruct.MaxMP+creature.CreatureTypeStruct.MaxRP) / 3
 	creature.Charge = 0
+	creature.world = w
 
 	return creature
 }
 
 func (w *dbWorld) KillCreature(id string) {
-	cID, err := uuid.Parse(id)
-
-	if err != nil {
-		return
-	}
-
-	byteID, err := cID.MarshalBinary()
-
-	if err != nil {
-		return
-	}
-
 	creature := w.getCreature(id)
 
 	if creature == nil {
@@ -293,28 +307,25 @@ func (w *dbWorld) KillCreature(id string) {
 
 	location := Point{X: creature.X, Y: creature.Y}
 
-	w.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("creatures"))
-		err := bucket.Delete(byteID)
+	w.store.Update(func(tx Tx) error {
+		creatures := creatureRepo(tx)
+		err := creatures.Delete(id)
 
 		if err != nil {
 			return err
 		}
 
-		cID, _ := uuid.Parse(id)
+		if creature.Region != 0 {
+			if err := respawnRepo(tx).AddToRegion(creature.Region, -1); err != nil {
+				return err
+			}
+		}
 
-		if err != nil {
+		if err := shopRepo(tx).Delete(id); err != nil {
 			return err
 		}
 
-		creatureListBucket := tx.Bucket([]byte("creaturelist"))
-		var keyBuf bytes.Buffer
-		location.ToBytes(&keyBuf)
-		binary.Write(&keyBuf, binary.BigEndian, byte(0))
-		uidBytes, _ := cID.MarshalBinary()
-		binary.Write(&keyBuf, binary.BigEndian, uidBytes)
-
-		return creatureListBucket.Delete(keyBuf.Bytes())
+		return creatures.Unplace(location, id)
 	})
 }
 
@@ -347,17 +358,55 @@ func (w *dbWorld) Attack(source interface{}, target interface{}, attack *Attack)
 
 	if userok {
 		user.Reload()
-		targetpoints = GetStatPoints(user)
+		targetpoints = GetDefensePoints(user)
 		location = user.Location()
 		hitTarget = user.Username()
 	} else if creatureok {
+		// The creature in hand may be out of date, or already dead, so go by the one where it
+		// is now
+		stored := w.getCreature(creature.ID)
+		if stored == nil {
+			return
+		}
+
+		cell := &dbCell{w: w, x: stored.X, y: stored.Y}
+		creature = cell.getCreature(creature.ID)
+		target = creature
+
+		if creature.HP == 0 {
+			return
+		}
+
 		targetpoints = creature.StatPoints()
 		location = &Point{X: creature.X, Y: creature.Y}
 		hitTarget = creature.CreatureTypeStruct.Name
+
+		if !creature.CreatureTypeStruct.Hostile {
+			w.Chat(LogItem{Author: sourceString, Message: fmt.Sprintf("%v won't fight.", hitTarget), MessageType: MESSAGEACTIVITY, Location: location})
+			return
+		}
+	}
+
+	if sourceUserok && userok && !attack.IsCounter {
+		if err := canFight(w, sourceUser, user); err != nil {
+			sourceUser.Log(LogItem{Message: err.Error(), MessageType: MESSAGEACTIVITY})
+			return
+		}
+
+		sourceUser.EnterPvPCombat()
+		user.EnterPvPCombat()
+	}
+
+	accuracy := int(attack.Accuracy)
+	if sourceUserok {
+		accuracy += cunningAccuracy(sourceUser)
 	}
 
-	hit := rand.Int()%100 < int(attack.Accuracy)
+	hit := rand.Int()%100 < accuracy
+	afflictions, boons := splitEffects(attack.Effects)
 	killed := false
+	duelWon := false
+	critical := false
 
 	if hit {
 		attackpoints := attack.StatPoints()
@@ -367,20 +416,36 @@ func (w *dbWorld) Attack(source interface{}, target interface{}, attack *Attack)
 			damage += uint64(rand.Int() % int(attack.Trample+1))
 		}
 
+		if sourceUserok && damage > 0 && rand.Float32() < critChance(sourceUser) {
+			damage *= 2
+			critical = true
+		}
+
 		if userok {
 			user.Reload()
 
-			if damage > 0 {
-				counterAttack = user.MusterCounterAttack()
+			if user.HP() == 0 {
+				w.Chat(LogItem{Author: sourceString, Message: fmt.Sprintf("%v is already dead, attack failed.", user.Username()), MessageType: MESSAGEACTIVITY, Location: location})
+				return
 			}
 
-			if user.HP() == 0 {
-				message = fmt.Sprintf("%v is already dead, attack failed.", user.Username())
+			if damage > 0 && !attack.IsCounter {
+				counterAttack = user.MusterCounterAttack()
 			}
 
 			if counterAttack == nil {
+				damage = user.AbsorbDamage(damage)
+
 				if user.HP() > damage {
 					user.SetHP(user.HP() - damage)
+
+					for _, effect := range afflictions {
+						user.AddEffect(effect)
+					}
+				} else if sourceUserok && dueling(sourceUser, user) {
+					// Losing a duel leaves you standing
+					user.SetHP(1)
+					duelWon = true
 				} else {
 					user.SetHP(0)
 					killed = true
@@ -390,36 +455,102 @@ func (w *dbWorld) Attack(source interface{}, target interface{}, attack *Attack)
 			}
 
 			user.Save()
+
+			if counterAttack == nil && damage > 0 {
+				user.TrainSkill(SKILLORDERLY, 1)
+			}
+
+			if duelWon {
+				user.EndDuel()
+				sourceUser.RecordPvP(true, true)
+				user.RecordPvP(false, true)
+			} else if killed && sourceUserok {
+				sourceUser.RecordPvP(true, false)
+				user.RecordPvP(false, false)
+			}
 		} else if creatureok {
-			if creature.HP > damage {
-				creature.HP -= damage
-			} else {
-				creature.HP = 0
-				w.creatureDrop(creature)
-				killed = true
+			if damage > 0 && !attack.IsCounter {
+				counterAttack = creature.MusterCounterAttack()
 			}
 
-			c := w.Cell(creature.X, creature.Y)
-			c.UpdateCreature(creature)
+			if counterAttack != nil {
+				log.Printf("Attack blocked via %v", counterAttack)
+			} else {
+				damage = creature.AbsorbDamage(damage)
+
+				if sourceUserok {
+					creature.recordDamage(sourceUser.Username(), damage)
+				}
+
+				if creature.HP > damage {
+					creature.HP -= damage
+
+					for _, effect := range afflictions {
+						creature.AddEffect(effect)
+					}
+
+					w.advanceBossPhase(creature)
+				} else {
+					creature.HP = 0
+					killed = true
+				}
+			}
 
 			if killed {
-				for _, user := range w.usersInCell(Point{X: creature.X, Y: creature.Y}) {
-					user.AddXP(uint64(creature.maxCharge))
+				killer := ""
+				if sourceUserok {
+					killer = sourceUser.Username()
 				}
+				w.creatureKilled(creature, killer)
 			}
+
+			c := w.Cell(creature.X, creature.Y)
+			c.UpdateCreature(creature)
 		} else {
 			log.Printf("How do I handle %v for attacks?", target)
 		}
 
-		if killed {
+		if sourceUserok && counterAttack == nil && damage > 0 {
+			sourceUser.TrainSkill(SKILLCUNNING, 1)
+		}
+
+		if counterAttack == nil && len(boons) > 0 {
+			if sourceUserok {
+				sourceUser.Reload()
+				for _, effect := range boons {
+					sourceUser.AddEffect(effect)
+				}
+				sourceUser.Save()
+			} else if sourceCreatureok {
+				for _, effect := range boons {
+					sourceCreature.AddEffect(effect)
+				}
+				w.Cell(sourceCreature.X, sourceCreature.Y).UpdateCreature(sourceCreature)
+			}
+		}
+
+		if duelWon {
+			message = fmt.Sprintf("%v beat %v in a duel with %v! (%v)", sourceString, hitTarget, attack.Name, damage)
+		} else if killed {
 			message = fmt.Sprintf("%v took fatal damage from %v! (%v)", hitTarget, attack.Name, damage)
 		} else if len(message) == 0 {
 			if counterAttack == nil {
 				message = fmt.Sprintf("%v hit %v for %v damage!", attack.Name, hitTarget, damage)
+
+				if names := effectNames(afflictions); len(names) > 0 {
+					message += fmt.Sprintf(" %v is %v.", hitTarget, strings.Join(names, " and "))
+				}
+				if names := effectNames(boons); len(names) > 0 {
+					message += fmt.Sprintf(" %v is %v.", sourceString, strings.Join(names, " and "))
+				}
 			} else {
-				message = fmt.Sprintf("Attempted %v against %v; blocked with a counterattack!", attack.Name, hitTarget)
+				message = fmt.Sprintf("Attempted %v against %v; blocked with %v!", attack.Name, hitTarget, counterAttack.Name)
 			}
 		}
+
+		if critical && counterAttack == nil {
+			message = "Critical hit! " + message
+		}
 	} else {
 		message = fmt.Sprintf("%v missed!", attack.Name)
 	}
@@ -433,150 +564,188 @@ func (w *dbWorld) Attack(source interface{}, target interface{}, attack *Attack)
 	}
 }
 
-func (w *dbWorld) creatureDrop(creature *Creature) {
+// creatureKilled drops a dead creature's loot and shares out XP between whoever fought it,
+// along with their parties
+func (w *dbWorld) creatureKilled(creature *Creature, killer string) {
+	present := make([]string, 0)
+	for _, user := range w.usersInCell(creature.Location()) {
+		present = append(present, user.Username())
+	}
+
+	w.creatureDrop(creature, killer, present)
+	if creature.IsBoss() {
+		w.scheduleRespawn(creature.Home(), RESPAWNBOSS)
+	} else {
+		w.scheduleRespawn(creature.Home(), RESPAWNCREATURES)
+	}
+
+	names := append([]string{}, present...)
+	for username := range creature.Damage {
+		names = append(names, username)
+	}
+	parties := w.partiesOf(names)
+
+	for username, xp := range splitKillXP(uint64(creature.maxCharge), creature.Damage, parties, present) {
+		user := w.GetUser(username)
+		user.AddXP(xp)
+		user.QuestEvent(QUESTKILL, creature.CreatureType)
+	}
+
+	party, inParty := parties[killer]
+	for username, coins := range splitCoins(coinDrop(creature.CreatureTypeStruct, regionRand(w, "coins:"+creature.ID, creature.Location())), killer, party, inParty, present) {
+		if coins > 0 {
+			user := w.GetUser(username)
+			user.AddCoins(coins)
+			user.Log(LogItem{Message: fmt.Sprintf("You found %v on %v", coinString(coins), creature.CreatureTypeStruct.Name), MessageType: MESSAGEACTIVITY})
+		}
+	}
+}
+
+// creatureDrop rolls a dead creature's loot, with better odds the more Creative the killer. If
+// the killer's party doesn't loot free-for-all the drops go straight to whichever member the loot
+// mode picks; otherwise they land on the ground. A boss's unique drops are announced to everyone online.
+func (w *dbWorld) creatureDrop(creature *Creature, killer string, present []string) {
 	drops := creature.CreatureTypeStruct.ItemDrops
+	items := make([]InventoryItem, 0)
+	unique := make([]bool, 0)
+
+	luck := float32(1)
+	if killer != "" {
+		luck = creativeLuck(w.GetUser(killer))
+	}
+
+	if creature.IsBoss() {
+		drops = append(append([]ItemDrop{}, drops...), creature.CreatureTypeStruct.Boss.UniqueDrops...)
+	}
 
 	if drops != nil && len(drops) > 0 {
-		for _, drop := range drops {
+		rng := regionRand(w, "loot:"+creature.ID, Point{X: creature.X, Y: creature.Y})
+
+		for index, drop := range drops {
 			cluster := drop.Cluster
 			if cluster == 0 {
 				cluster = 1
 			}
 
 			for i := 0; i < int(cluster); i++ {
-				prob := rand.Float32()
-				if drop.Probability >= prob {
-					dropItem := ItemTypes[drop.Name]
-					c := w.Cell(creature.X, creature.Y)
-					c.AddInventoryItem(&dropItem)
+				prob := rng.Float32()
+				if drop.Probability*luck >= prob {
+					items = append(items, ItemTypes[drop.Name])
+					unique = append(unique, index >= len(creature.CreatureTypeStruct.ItemDrops))
 				}
 			}
 		}
 	}
-}
-
-func (w *dbWorld) InventoryItems(x, y uint32) []*InventoryItem {
-	items := make([]*InventoryItem, 0)
-
-	pt := Point{X: x, Y: y}
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-	binary.Write(minBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
 
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
-
-	w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
+	if len(items) == 0 {
+		return
+	}
 
-		cur := bucket.Cursor()
+	looters := make([]string, len(items))
+	var party Party
 
-		for k, v := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = cur.Next() {
-			var inventoryItem InventoryItem
+	if killer != "" {
+		isPresent := make(map[string]bool)
+		for _, username := range present {
+			isPresent[username] = true
+		}
 
-			err := MSGUnpack(v, &inventoryItem)
+		w.store.Update(func(tx Tx) error {
+			parties := partyRepo(tx)
 
-			if err != nil {
-				return err
+			var ok bool
+			if party, ok = parties.ForUser(killer); !ok || party.Loot() == LOOTFREEFORALL {
+				return nil
 			}
 
-			items = append(items, &inventoryItem)
-		}
+			for index := range items {
+				looters[index], _ = party.Looter(isPresent)
+			}
 
-		return nil
-	})
+			return parties.Put(&party)
+		})
+	}
 
-	return items
-}
+	c := w.Cell(creature.X, creature.Y)
+	for index := range items {
+		dropItem := items[index]
 
-func (w *dbWorld) inventoryItem(x, y uint32, id string, pull bool) *InventoryItem {
-	itemID, err := uuid.Parse(id)
-	if err != nil {
-		return nil
-	}
+		if looters[index] != "" && w.GetUser(looters[index]).AddInventoryItem(&dropItem) {
+			w.tellParty(party, fmt.Sprintf("%v got %v", looters[index], dropItem.Name))
+		} else {
+			looters[index] = ""
+			c.AddInventoryItem(&dropItem)
+		}
 
-	idBytes, err := itemID.MarshalBinary()
-	if err != nil {
-		return nil
+		if unique[index] {
+			w.announceUniqueDrop(creature, looters[index], dropItem)
+		}
 	}
+}
 
-	pt := Point{X: x, Y: y}
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, idBytes)
+// partiesOf looks up the party each of a list of users is in, leaving out anyone not in one
+func (w *dbWorld) partiesOf(usernames []string) map[string]Party {
+	parties := make(map[string]Party)
 
-	found := false
-	var inventoryItem InventoryItem
-	w.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
+	w.store.View(func(tx Tx) error {
+		repo := partyRepo(tx)
 
-		itemBytes := bucket.Get(keyBuf.Bytes())
-		if itemBytes != nil {
-			if MSGUnpack(itemBytes, &inventoryItem) == nil {
-				inventoryItem.ID = id
-				found = true
+		for _, username := range usernames {
+			if party, ok := repo.ForUser(username); ok {
+				parties[username] = party
 			}
 		}
 
-		if pull && found {
-			return bucket.Delete(keyBuf.Bytes())
-		}
-
 		return nil
 	})
 
-	if found {
-		return &inventoryItem
+	return parties
+}
+
+// tellParty logs a message for every member of a party, online or not
+func (w *dbWorld) tellParty(party Party, message string) {
+	for _, member := range party.Members {
+		w.GetUser(member).Log(LogItem{Message: message, MessageType: MESSAGEACTIVITY})
 	}
-	return nil
+}
+
+func (w *dbWorld) InventoryItems(x, y uint32) []*InventoryItem {
+	return w.CellAtPoint(Point{X: x, Y: y}).InventoryItems()
 }
 
 func (w *dbWorld) InventoryItem(x, y uint32, id string) *InventoryItem {
-	return w.inventoryItem(x, y, id, false)
+	return w.CellAtPoint(Point{X: x, Y: y}).InventoryItem(id)
 }
 
 func (w *dbWorld) PullInventoryItem(x, y uint32, id string) *InventoryItem {
-	return w.inventoryItem(x, y, id, true)
+	return w.CellAtPoint(Point{X: x, Y: y}).PullInventoryItem(id)
 }
 
 func (w *dbWorld) HasInventoryItems(x, y uint32) bool {
-	var hasItems bool
-
-	pt := Point{X: x, Y: y}
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-	binary.Write(minBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
-
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
+	return w.CellAtPoint(Point{X: x, Y: y}).HasInventoryItems()
+}
 
-	w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
+func (w *dbWorld) NewPlaceID() uint64 {
+	var id uint64
 
-		cur := bucket.Cursor()
+	w.store.Update(func(tx Tx) error {
+		placeNames := placeNameRepo(tx)
 
-		for k, _ := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, _ = cur.Next() {
-			hasItems = true
+		var err error
+		id, err = placeNames.NextID()
 
-			return nil
+		if err != nil {
+			return err
 		}
 
-		return nil
+		return placeNames.Put(id, randomPlaceName(seededRand(w.seed, "placename", id)))
 	})
 
-	return hasItems
+	return id
 }
 
-func (w *dbWorld) NewPlaceID() uint64 {
-	id, _ := newPlaceNameInDB(w.database)
-	return id
+func (w *dbWorld) Seed() int64 {
+	return w.seed
 }
 
 func (w *dbWorld) OnlineUsers() []User {
@@ -584,31 +753,28 @@ func (w *dbWorld) OnlineUsers() []User {
 	arr := make([]User, 0)
 	offlineNames := make([]string, 0)
 
-	w.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("onlineusers"))
+	w.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
 		now := time.Now().UTC().Unix()
 
-		bucket.ForEach(func(k, v []byte) error {
-			var lastUpdate int64
-			buf := bytes.NewBuffer(v)
-			binary.Read(buf, binary.BigEndian, &lastUpdate)
-
+		for name, lastUpdate := range users.Online() {
 			if (now - lastUpdate) < 15 {
-				names = append(names, string(k))
+				names = append(names, name)
 			} else {
-				offlineNames = append(offlineNames, string(k))
+				offlineNames = append(offlineNames, name)
 			}
-
-			return nil
-		})
+		}
 
 		for _, name := range offlineNames {
-			bucket.Delete([]byte(name))
+			users.MarkOffline(name)
 		}
 
 		return nil
 	})
 
+	sort.Strings(names)
+	sort.Strings(offlineNames)
+
 	for _, name := range names {
 		arr = append(arr, w.GetUser(name))
 	}
@@ -634,8 +800,14 @@ func (w *dbWorld) usersInCell(p Point) []User {
 }
 
 func (w *dbWorld) Chat(message LogItem) {
+	channel, isChannel := ChatChannels[message.Channel]
+
 	for _, user := range w.OnlineUsers() {
-		if message.Location == nil || *(message.Location) == *(user.Location()) {
+		if isChannel {
+			if heard, ok := w.hearChat(user, message, channel); ok {
+				user.Log(heard)
+			}
+		} else if message.Location == nil || *(message.Location) == *(user.Location()) {
 			user.Log(message)
 		}
 	}
@@ -643,13 +815,16 @@ func (w *dbWorld) Chat(message LogItem) {
 
 func (w *dbWorld) Close() {
 	w.closeActiveCells <- struct{}{}
-	if w.database != nil {
-		w.database.Close()
+	if w.store != nil {
+		w.store.Close()
 	}
 }
 
+// tickInterval is how often users recharge, effects tick and active cells update
+const tickInterval = time.Second
+
 func (w *dbWorld) tickOnActiveItems() {
-	tick := time.Tick(1 * time.Second)
+	tick := time.Tick(tickInterval)
 
 	for {
 		select {
@@ -657,36 +832,50 @@ func (w *dbWorld) tickOnActiveItems() {
 			return
 		case <-tick:
 			w.chargeUsers()
+			w.tickEffects()
 			w.sweepExpiredKeys()
 			w.updateActivatedCells()
+			w.runRespawns()
 		}
 	}
 }
 
-func (w *dbWorld) load() {
-	log.Printf("Loading world database %s", w.filename)
-	db, err := bolt.Open(w.filename, 0600, nil)
-
-	if err != nil {
-		panic(err)
-	}
-
+func (w *dbWorld) load(seed int64) {
 	// Make default tables
-	db.Update(func(tx *bolt.Tx) error {
-		buckets := []string{"users", "userinventory", "userequipment", "userlog", "onlineusers", "lastuseraction", "terrain", "placenames", "placeitems", "creaturelist", "creatures"}
-
-		for _, bucket := range buckets {
-			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+	err := w.store.Update(func(tx Tx) error {
+		for _, bucket := range storeBuckets {
+			_, err := tx.CreateBucketIfNotExists(bucket)
 
 			if err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// The first seed a world is made with sticks, so the terrain already on disk stays consistent
+		settings := settingsRepo(tx)
+		storedSeed, ok := settings.Seed()
+
+		if ok {
+			if seed != 0 && seed != storedSeed {
+				log.Printf("World was generated with seed %v; ignoring configured seed %v", storedSeed, seed)
+			}
+			w.seed = storedSeed
+
+			return nil
+		}
+
+		if seed == 0 {
+			seed = newWorldSeed()
+		}
+		w.seed = seed
+
+		return settings.SetSeed(seed)
 	})
 
-	w.database = db
+	if err != nil {
+		panic(err)
+	}
+
 	w.closeActiveCells = make(chan struct{})
 	go w.tickOnActiveItems()
 }
@@ -705,20 +894,18 @@ func (c *dbCell) Location() Point {
 
 func (c *dbCell) CellInfo() *CellInfo {
 	var cellInfo CellInfo
-	c.w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("terrain"))
+	placeName := "Delaware"
 
-		pt := Point{X: c.x, Y: c.y}
-		record := bucket.Get(pt.Bytes())
+	c.w.store.View(func(tx Tx) error {
+		cellInfo, _ = terrainRepo(tx).Get(c.Location())
 
-		if record != nil {
-			MSGUnpack(record, &cellInfo)
+		if name, ok := placeNameRepo(tx).Get(cellInfo.RegionNameID); ok {
+			placeName = name
 		}
 
 		return nil
 	})
 
-	placeName := getPlaceNameByIDFromDB(cellInfo.RegionNameID, c.w.database)
 	cellTerrain, ok := CellTypes[cellInfo.TerrainID]
 	biomeData, biomeOK := BiomeTypes[cellInfo.BiomeID]
 
@@ -745,17 +932,15 @@ func (c *dbCell) CellInfo() *CellInfo {
 
 func (c *dbCell) SetCellInfo(cellInfo *CellInfo) {
 	pt := Point{X: c.x, Y: c.y}
-	key := pt.Bytes()
 
-	c.w.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("terrain"))
+	c.w.store.Update(func(tx Tx) error {
+		terrain := terrainRepo(tx)
 
 		if cellInfo == nil {
-			return bucket.Delete(key)
+			return terrain.Delete(pt)
 		}
 
-		bytes, _ := MSGPack(cellInfo)
-		return bucket.Put(key, bytes)
+		return terrain.Put(pt, cellInfo)
 	})
 
 	if cellInfo == nil {
@@ -763,59 +948,79 @@ func (c *dbCell) SetCellInfo(cellInfo *CellInfo) {
 		return
 	}
 
-	_, ok := c.w.activeCellCache.Load(string(pt.Bytes()))
-
-	if ok {
-		c.w.activeCellCache.Store(string(key), &recentCellInfo{x: c.x, y: c.y, lastVisit: time.Now().Unix(), cellInfo: cellInfo})
+	if record, ok := c.w.activeCellCache.Load(string(pt.Bytes())); ok {
+		if recent, ok := record.(*recentCellInfo); ok {
+			recent.cellInfo = cellInfo
+			recent.lastVisit = time.Now().Unix()
+		}
 	}
 
 	ct, ok := CellTypes[cellInfo.TerrainID]
 
-	var spawns []CreatureSpawn
-	var drops []ItemDrop
-
 	if ok {
-		spawns = ct.CreatureSpawns
-		drops = ct.ItemDrops
+		c.spawnCreatures(ct.CreatureSpawns, regionRand(c.w, "spawn", pt), -1)
+		c.dropItems(ct.ItemDrops, regionRand(c.w, "drop", pt))
 	}
+}
 
-	if spawns != nil {
-		for _, spawn := range spawns {
-			cl := spawn.Cluster
-			if cl < 1 {
-				cl = 1
-			}
+// spawnCreatures rolls a terrain's creature spawns into the cell, adding at most limit
+// creatures (any number if it's negative). It returns how many it added.
+func (c *dbCell) spawnCreatures(spawns []CreatureSpawn, rng *rand.Rand, limit int) int {
+	added := 0
 
-			prob := rand.Float32()
-			for clusterCount := 0; clusterCount < int(cl); clusterCount++ {
+	for _, spawn := range spawns {
+		cl := spawn.Cluster
+		if cl < 1 {
+			cl = 1
+		}
 
-				if spawn.Probability >= prob {
-					if clusterCount > 0 {
-						prob += (spawn.Probability / 2.0)
-					}
+		prob := rng.Float32()
+		for clusterCount := 0; clusterCount < int(cl); clusterCount++ {
 
-					c.AddStockCreature(spawn.Name)
+			if spawn.Probability >= prob {
+				if clusterCount > 0 {
+					prob += (spawn.Probability / 2.0)
 				}
-			}
+
+				// Draw the ID from the spawn stream too so loot rolls keyed on it are reproducible
+				cID, err := uuid.NewRandomFromReader(rng)
+				if err != nil {
+					cID = uuid.New()
+				}
+
+				if added == limit {
+					return added
+				}
+				c.addStockCreature(spawn.Name, cID)
+				added++
+			}
 		}
 	}
 
-	if drops != nil {
-		for _, drop := range drops {
-			cluster := drop.Cluster
-			if cluster == 0 {
-				cluster = 1
-			}
+	return added
+}
 
-			for i := 0; i < int(cluster); i++ {
-				prob := rand.Float32()
-				if drop.Probability >= prob {
-					dropItem := ItemTypes[drop.Name]
-					c.AddInventoryItem(&dropItem)
-				}
+// dropItems rolls a terrain's item drops onto the cell, returning how many it dropped
+func (c *dbCell) dropItems(drops []ItemDrop, rng *rand.Rand) int {
+	dropped := 0
+
+	for _, drop := range drops {
+		cluster := drop.Cluster
+		if cluster == 0 {
+			cluster = 1
+		}
+
+		for i := 0; i < int(cluster); i++ {
+			prob := rng.Float32()
+			if drop.Probability >= prob {
+				dropItem := ItemTypes[drop.Name]
+				c.AddInventoryItem(&dropItem)
+				dropped++
 			}
 		}
 	}
+
+	return dropped
 }
 
 func (c *dbCell) IsEmpty() bool {
@@ -836,39 +1041,10 @@ func (c *dbCell) reloadStoredCreatures() {
 }
 
 func (c *dbCell) creatureList() []string {
-	creatureList := make([]string, 0)
+	var creatureList []string
 
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-
-	loc := c.Location()
-	loc.ToBytes(minBuf)
-	loc.ToBytes(maxBuf)
-
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
-
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
-
-	c.w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("creaturelist"))
-
-		cur := bucket.Cursor()
-
-		for k, v := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = cur.Next() {
-			if v != nil {
-				buf := bytes.NewBuffer(k)
-				PointFromBuffer(buf)
-				var b byte
-				binary.Read(buf, binary.BigEndian, &b)
-
-				var creatureID uuid.UUID
-				binary.Read(buf, binary.BigEndian, &creatureID)
-
-				creatureList = append(creatureList, creatureID.String())
-			}
-		}
+	c.w.store.View(func(tx Tx) error {
+		creatureList = creatureRepo(tx).IDsAt(c.Location())
 
 		return nil
 	})
@@ -892,38 +1068,7 @@ func (c *dbCell) getCreature(id string) *Creature {
 		}
 	}
 
-	var creature *Creature
-
-	c.w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("creatures"))
-
-		id, err := uuid.Parse(id)
-
-		if err != nil {
-			return err
-		}
-
-		byteID, err := id.MarshalBinary()
-
-		if err != nil {
-			return err
-		}
-
-		recordBytes := bucket.Get(byteID)
-
-		if recordBytes != nil {
-			creature = &Creature{}
-			MSGUnpack(recordBytes, creature)
-		}
-
-		return err
-	})
-
-	creature.CreatureTypeStruct = CreatureTypes[creature.CreatureType]
-	creature.maxCharge = int64(creature.CreatureTypeStruct.MaxAP+creature.CreatureTypeStruct.MaxMP+creature.CreatureTypeStruct.MaxRP) / 3
-	creature.Charge = 0
-
-	return creature
+	return c.w.getCreature(id)
 }
 
 func (c *dbCell) getCreatures() []*Creature {
@@ -944,7 +1089,7 @@ func (c *dbCell) getCreatures() []*Creature {
 					nameFixers[cr.CreatureTypeStruct.Name] = 1
 				}
 
-				for _, attack := range cr.CreatureTypeStruct.Attacks {
+				for _, attack := range cr.attacks() {
 					if attack.Charge > cr.maxCharge {
 						cr.maxCharge = attack.Charge
 					}
@@ -987,7 +1132,7 @@ func (c *dbCell) GetCreatures() []*Creature {
 					nameFixers[c.CreatureTypeStruct.Name] = 1
 				}
 
-				for _, attack := range c.CreatureTypeStruct.Attacks {
+				for _, attack := range c.attacks() {
 					if attack.Charge > c.maxCharge {
 						c.maxCharge = attack.Charge
 					}
@@ -1034,28 +1179,8 @@ func (c *dbCell) HasCreatures() bool {
 }
 
 func (c *dbCell) UpdateCreature(creature *Creature) {
-	cID, err := uuid.Parse(creature.ID)
-
-	if err != nil {
-		return
-	}
-
-	c.w.database.Update(func(tx *bolt.Tx) error {
-		creatureBucket := tx.Bucket([]byte("creatures"))
-
-		creatureBytes, err := MSGPack(creature)
-
-		if err != nil {
-			return err
-		}
-
-		byteKey, err := cID.MarshalBinary()
-
-		if err != nil {
-			return err
-		}
-
-		return creatureBucket.Put(byteKey, creatureBytes)
+	c.w.store.Update(func(tx Tx) error {
+		return creatureRepo(tx).Put(creature)
 	})
 
 	c.w.reloadStoredCreatures(creature.X, creature.Y)
@@ -1074,84 +1199,56 @@ func (c *dbCell) ClearCreatures() {
 }
 
 func (c *dbCell) AddStockCreature(id string) {
-	cID := uuid.New()
+	c.addStockCreature(id, uuid.New())
+}
+
+func (c *dbCell) addStockCreature(id string, cID uuid.UUID) {
 	creatureType := CreatureTypes[id]
+	region := uint64(0)
+	if cellInfo := c.CellInfo(); cellInfo != nil {
+		region = cellInfo.RegionNameID
+	}
+
 	creature := &Creature{
 		ID:           cID.String(),
 		CreatureType: creatureType.ID,
 		X:            c.x,
 		Y:            c.y,
+		SpawnX:       c.x,
+		SpawnY:       c.y,
 		HP:           creatureType.MaxHP,
 		AP:           creatureType.MaxAP,
 		MP:           creatureType.MaxMP,
 		RP:           creatureType.MaxRP,
+		Region:       region,
 		world:        c.w}
 
-	c.w.database.Update(func(tx *bolt.Tx) error {
-		creatureBucket := tx.Bucket([]byte("creatures"))
-		creatureListBucket := tx.Bucket([]byte("creaturelist"))
-
-		creatureBytes, err := MSGPack(creature)
-
-		if err != nil {
-			return err
-		}
+	c.w.store.Update(func(tx Tx) error {
+		creatures := creatureRepo(tx)
 
-		byteKey, err := cID.MarshalBinary()
+		err := creatures.Put(creature)
 
 		if err != nil {
 			return err
 		}
 
-		err = creatureBucket.Put(byteKey, creatureBytes)
-
-		if err != nil {
+		if err := respawnRepo(tx).AddToRegion(region, 1); err != nil {
 			return err
 		}
 
-		pt := Point{X: c.x, Y: c.y}
-		var keyBuf bytes.Buffer
-		pt.ToBytes(&keyBuf)
-		binary.Write(&keyBuf, binary.BigEndian, byte(0))
-		uidBytes, _ := cID.MarshalBinary()
-		binary.Write(&keyBuf, binary.BigEndian, uidBytes)
-
-		creatureListBucket.Put(keyBuf.Bytes(), make([]byte, 0))
-
-		return nil
+		return creatures.Place(c.Location(), creature.ID)
 	})
+
+	c.reloadStoredCreatures()
 }
 
 func (c *dbCell) InventoryItems() []*InventoryItem {
-	items := make([]*InventoryItem, 0)
-
-	pt := Point{X: c.x, Y: c.y}
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-	binary.Write(minBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
-
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
-
-	c.w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
-
-		cur := bucket.Cursor()
-
-		for k, v := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = cur.Next() {
-			var inventoryItem InventoryItem
-
-			err := MSGUnpack(v, &inventoryItem)
+	var items []*InventoryItem
 
-			if err != nil {
-				return err
-			}
+	pt := c.Location()
 
-			items = append(items, &inventoryItem)
-		}
+	c.w.store.View(func(tx Tx) error {
+		items = placeItemRepo(tx).List(pt.Bytes())
 
 		return nil
 	})
@@ -1169,77 +1266,33 @@ func (c *dbCell) AddInventoryItem(item *InventoryItem) bool {
 		inventoryItem.ID = uuid.New().String()
 	}
 
-	itemID, err := uuid.Parse(inventoryItem.ID)
-	if err != nil {
-		return false
-	}
-
-	idBytes, err := itemID.MarshalBinary()
-	if err != nil {
-		return false
-	}
-
-	pt := Point{X: c.x, Y: c.y}
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, idBytes)
-	dataBytes, err := MSGPack(inventoryItem)
-
-	if err != nil {
-		return false
-	}
-
-	c.w.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
+	pt := c.Location()
 
-		return bucket.Put(keyBuf.Bytes(), dataBytes)
+	err := c.w.store.Update(func(tx Tx) error {
+		return placeItemRepo(tx).Put(pt.Bytes(), &inventoryItem)
 	})
 
-	return true
+	return err == nil
 }
 
 func (c *dbCell) inventoryItem(id string, pull bool) *InventoryItem {
-	itemID, err := uuid.Parse(id)
-	if err != nil {
-		return nil
-	}
-
-	idBytes, err := itemID.MarshalBinary()
-	if err != nil {
-		return nil
-	}
+	var inventoryItem *InventoryItem
 
-	pt := Point{X: c.x, Y: c.y}
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, idBytes)
+	pt := c.Location()
 
-	found := false
-	var inventoryItem InventoryItem
-	c.w.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
+	c.w.store.Update(func(tx Tx) error {
+		items := placeItemRepo(tx)
 
-		itemBytes := bucket.Get(keyBuf.Bytes())
-		if itemBytes != nil {
-			if MSGUnpack(itemBytes, &inventoryItem) == nil {
-				inventoryItem.ID = id
-				found = true
-			}
-		}
+		inventoryItem = items.Get(pt.Bytes(), id)
 
-		if pull && found {
-			return bucket.Delete(keyBuf.Bytes())
+		if pull && inventoryItem != nil {
+			return items.Delete(pt.Bytes(), id)
 		}
 
 		return nil
 	})
 
-	if found {
-		return &inventoryItem
-	}
-	return nil
+	return inventoryItem
 }
 
 func (c *dbCell) InventoryItem(id string) *InventoryItem {
@@ -1247,33 +1300,22 @@ func (c *dbCell) InventoryItem(id string) *InventoryItem {
 }
 
 func (c *dbCell) PullInventoryItem(id string) *InventoryItem {
-	return c.inventoryItem(id, true)
+	item := c.inventoryItem(id, true)
+
+	if item != nil && !c.HasInventoryItems() {
+		c.w.scheduleRespawn(c.Location(), RESPAWNITEMS)
+	}
+
+	return item
 }
 
 func (c *dbCell) HasInventoryItems() bool {
 	var hasItems bool
 
-	pt := Point{X: c.x, Y: c.y}
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-	binary.Write(minBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, pt.Bytes())
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
-
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
-
-	c.w.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placeitems"))
-
-		cur := bucket.Cursor()
-
-		for k, _ := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, _ = cur.Next() {
-			hasItems = true
+	pt := c.Location()
 
-			return nil
-		}
+	c.w.store.View(func(tx Tx) error {
+		hasItems = placeItemRepo(tx).Any(pt.Bytes())
 
 		return nil
 	})
@@ -1282,9 +1324,28 @@ func (c *dbCell) HasInventoryItems() bool {
 }
 
 // LoadWorldFromDB will set up an on-disk based world
-func LoadWorldFromDB(filename string) World {
-	newWorld := dbWorld{filename: filename}
-	newWorld.load()
+func LoadWorldFromDB(filename string, seed int64) World {
+	log.Printf("Loading world database %s", filename)
+	store, err := OpenBoltStore(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return NewWorldFromStore(store, seed)
+}
+
+// NewMemoryWorld sets up a world that is never written to disk
+func NewMemoryWorld(seed int64) World {
+	return NewWorldFromStore(NewMemoryStore(), seed)
+}
+
+// NewWorldFromStore sets up a world persisted to any Store. The seed is only used if
+// the store doesn't already have one; 0 picks a random seed.
+func NewWorldFromStore(store Store, seed int64) World {
+	newWorld := dbWorld{store: store}
+	newWorld.load(seed)
+	log.Printf("World seed is %v", newWorld.seed)
 	return &newWorld
 }
 
@@ -1304,11 +1365,28 @@ type UserData struct {
 	RP          uint64               `json:""`
 	MaxRP       uint64               `json:""`
 	XP          uint64               `json:""`
+	Level       uint64               `json:",omitempty"`
 	ClassInfo   byte                 `json:""`
 	Initialized bool                 `json:""`
-	PublicKeys  map[string]bool      `json:""`
+	PublicKeys  map[string]bool      `json:",omitempty"` // Only on users from before accounts; SignIn moves them to the account
 	Slots       []*EquipmentSlotInfo `json:""`
 	Attacks     []*Attack            `json:""`
+
+	CounterCooldowns map[string]int64  `json:",omitempty"` // Counterattack name -> unix time it's usable again
+	Effects          []ActiveEffect    `json:",omitempty"`
+	LeftChannels     []string          `json:",omitempty"` // Optional chat channels the user doesn't want to hear
+	Admin            bool              `json:",omitempty"`
+	PvP              bool              `json:",omitempty"` // Agreed to fight other players
+	PvPStats         PvPStats          `json:",omitempty"`
+	LastPvP          int64             `json:",omitempty"` // Unix time of the last hit given or taken in a fight with another player
+	ProtectedUntil   int64             `json:",omitempty"` // Unix time respawn protection runs out
+	Coins            uint64            `json:",omitempty"`
+	BankUpgrades     uint64            `json:",omitempty"`
+	SkillPoints      map[string]uint64 `json:",omitempty"` // Skill name -> XP earned using it
+	Talents          []string          `json:",omitempty"` // IDs of talents learned, in the order they were
+	Account          string            `json:",omitempty"` // SSH account the character belongs to
+	Respec           bool              `json:",omitempty"` // Back in character setup picking a new class
+	RespecFrom       byte              `json:",omitempty"` // ClassInfo to go back to if the new class is called off
 }
 
 type dbUser struct {
@@ -1333,205 +1411,13 @@ func (user *dbUser) Title() string {
 	return GetTitle(st1, st2, sk1, sk2)
 }
 
-func (user *dbUser) getDefaultAttacks() []*Attack {
-	primary, secondary := user.Strengths()
-
-	var primaryattack Attack
-	var secondaryattack Attack
-	rockAttack := Attack{
-		Name:         "Rock user",
-		Accuracy:     100,
-		MP:           1,
-		AP:           1,
-		RP:           1,
-		Trample:      6,
-		Charge:       1,
-		UsesItems:    []string{"Shiny Rock"},
-		OutputsItems: []string{"Broken Rock"}}
-
-	switch primary {
-	case MELEEPRIMARY:
-		primaryattack = Attack{Name: "Punch",
-			Accuracy: 95,
-			MP:       0,
-			AP:       4,
-			RP:       0,
-			Charge:   2}
-
-		rockAttack.Name = "Smash Rock"
-		rockAttack.AP *= 2
-		rockAttack.Bonuses = "AP+25%AP"
-	case RANGEPRIMARY:
-		primaryattack = Attack{Name: "Dart",
-			Accuracy: 95,
-			MP:       0,
-			AP:       0,
-			RP:       4,
-			Charge:   2}
-
-		rockAttack.Name = "Throw Rock"
-		rockAttack.RP *= 2
-		rockAttack.Bonuses = "RP+25%RP"
-	case MAGICPRIMARY:
-		primaryattack = Attack{Name: "Mage push",
-			Accuracy: 95,
-			MP:       4,
-			AP:       0,
-			RP:       0,
-			Charge:   2}
-
-		rockAttack.Name = "Rock Bomb"
-		rockAttack.MP *= 2
-		rockAttack.Bonuses = "MP+25%MP"
-	}
-	switch secondary {
-	case MELEESECONDARY:
-		secondaryattack = Attack{Name: "Biff",
-			Accuracy: 95,
-			MP:       0,
-			AP:       2,
-			RP:       0,
-			Charge:   4}
-		if primary == MELEEPRIMARY {
-			secondaryattack.Charge = 1
-			secondaryattack.Trample = 1
-		} else if primary == RANGEPRIMARY {
-			secondaryattack.RP++
-		} else if primary == MAGICPRIMARY {
-			secondaryattack.MP++
-		}
-	case RANGESECONDARY:
-		secondaryattack = Attack{Name: "Toss",
-			Accuracy: 95,
-			MP:       0,
-			AP:       0,
-			RP:       2,
-			Charge:   4}
-		if primary == RANGEPRIMARY {
-			secondaryattack.Charge = 1
-			secondaryattack.Trample = 1
-		} else if primary == MELEEPRIMARY {
-			secondaryattack.AP++
-		} else if primary == MAGICPRIMARY {
-			secondaryattack.MP++
-		}
-	case MAGICSECONDARY:
-		secondaryattack = Attack{Name: "Crackle",
-			Accuracy: 95,
-			MP:       2,
-			AP:       0,
-			RP:       0,
-			Charge:   4}
-		if primary == MAGICPRIMARY {
-			secondaryattack.Charge = 1
-			secondaryattack.Trample = 1
-		} else if primary == RANGEPRIMARY {
-			secondaryattack.RP++
-		} else if primary == MELEEPRIMARY {
-			secondaryattack.AP++
-		}
-	}
-
-	attacks := []*Attack{&primaryattack, &secondaryattack, &rockAttack}
-
-	return attacks
-}
-
-func (user *dbUser) setupStatBonuses() {
-	primary, secondary := user.Strengths()
-
-	switch primary {
-	case MELEEPRIMARY:
-		user.UserData.MaxAP *= 3
-	case RANGEPRIMARY:
-		user.UserData.MaxRP *= 3
-	case MAGICPRIMARY:
-		user.UserData.MaxMP *= 3
-	}
-	switch secondary {
-	case MELEESECONDARY:
-		user.UserData.MaxAP *= 2
-	case RANGESECONDARY:
-		user.UserData.MaxRP *= 2
-	case MAGICSECONDARY:
-		user.UserData.MaxMP *= 2
-	}
-}
-
-func (user *dbUser) getDefaultSlots() []*EquipmentSlotInfo {
-	weapon := EquipmentSlotInfo{
-		Name:      "Weapon",
-		SlotTypes: []string{}}
-	headwear := EquipmentSlotInfo{
-		Name:      "Headwear",
-		SlotTypes: []string{}}
-	armor := EquipmentSlotInfo{
-		Name:      "Armor",
-		SlotTypes: []string{}}
-
-	primary, secondary := user.Strengths()
-
-	switch primary {
-	case MELEEPRIMARY:
-		headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHELM)
-		armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECHESTPLATE)
-
-		switch secondary {
-		case MELEESECONDARY:
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPESWORD, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEDAGGER)
-		case RANGESECONDARY:
-			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPECOWL)
-			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPELIGHTARMOR)
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPESWORD, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEJAVELIN)
-		case MAGICSECONDARY:
-			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHAT)
-			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECLOAK)
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPESWORD, WEAPONSUBTYPESPEAR, WEAPONSUBTYPESPEAR)
-		}
-	case RANGEPRIMARY:
-		headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPECOWL)
-
-		switch secondary {
-		case MELEESECONDARY:
-			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHELM)
-			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECHESTPLATE)
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEBOW, WEAPONSUBTYPEDART, WEAPONSUBTYPEJAVELIN)
-		case RANGESECONDARY:
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEBOW, WEAPONSUBTYPEJAVELIN, WEAPONSUBTYPESPEAR)
-		case MAGICSECONDARY:
-			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHAT)
-			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECLOAK)
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEBOW, WEAPONSUBTYPEDART, WEAPONSUBTYPEORB)
-		}
-	case MAGICPRIMARY:
-		headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHAT)
-		armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECLOAK)
-
-		switch secondary {
-		case MELEESECONDARY:
-			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHELM)
-			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECHESTPLATE)
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEWAND, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEDAGGER)
-		case RANGESECONDARY:
-			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPECOWL)
-			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPELIGHTARMOR)
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEWAND, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEORB)
-		case MAGICSECONDARY:
-			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEWAND, WEAPONSUBTYPEORB, WEAPONSUBTYPEDART)
-		}
-	}
-
-	slots := []*EquipmentSlotInfo{&weapon, &headwear, &armor}
-
-	return slots
-}
-
 func (user *dbUser) Initialize(initialize bool) {
 	user.Reload()
 
-	user.UserData.Attacks = user.getDefaultAttacks()
-	user.UserData.Slots = user.getDefaultSlots()
-	user.setupStatBonuses()
+	primary, secondary := user.Strengths()
+	user.UserData.Attacks = defaultAttacks(primary, secondary)
+	user.UserData.Slots = defaultSlots(primary, secondary)
+	setClassStats(&user.UserData, primary, secondary)
 	user.Initialized = initialize
 	user.Save()
 }
@@ -1618,6 +1504,14 @@ func (user *dbUser) AddXP(xp uint64) {
 	user.Save()
 }
 
+func (user *dbUser) Level() uint64 {
+	if user.UserData.Level == 0 {
+		return 1
+	}
+
+	return user.UserData.Level
+}
+
 func (user *dbUser) XPToNextLevel() uint64 {
 	return user.MaxAP() + user.MaxRP() + user.MaxMP()
 }
@@ -1672,204 +1566,450 @@ func (user *dbUser) SetSkills(primary, secondary byte) {
 	user.Save()
 }
 
-func (user *dbUser) Cell() Cell {
-	return user.world.Cell(user.X, user.Y)
+func (user *dbUser) SkillXP(skill string) uint64 {
+	return user.UserData.SkillPoints[skill]
 }
 
-// Location returns the name of the current cell
-func (user *dbUser) LocationName() string {
-	ci := user.Cell().CellInfo()
-	if ci != nil {
-		return ci.RegionName
-	}
-	return "Delaware"
+func (user *dbUser) SkillLevel(skill string) uint64 {
+	return skillLevel(user.SkillXP(skill))
 }
 
-func (user *dbUser) MoveNorth() {
-	user.Reload()
-	if user.Y > 0 {
-		user.Y--
-		user.world.activateCell(user.X, user.Y)
-		user.Act()
-		user.Save()
+// TrainSkill adds skill XP for using a skill, teaching any attacks a new level unlocks
+func (user *dbUser) TrainSkill(skill string, xp uint64) {
+	gain := skillGain(user, skill, xp)
+	if gain == 0 {
+		return
 	}
-}
 
-func (user *dbUser) MoveSouth() {
 	user.Reload()
-	_, height := user.world.GetDimensions()
+	before := user.SkillLevel(skill)
 
-	if user.Y < height-1 {
-		user.Y++
-		user.world.activateCell(user.X, user.Y)
-		user.Act()
-		user.Save()
+	if user.UserData.SkillPoints == nil {
+		user.UserData.SkillPoints = make(map[string]uint64)
 	}
-}
+	user.UserData.SkillPoints[skill] += gain
 
-func (user *dbUser) MoveEast() {
-	user.Reload()
-	width, _ := user.world.GetDimensions()
+	after := user.SkillLevel(skill)
+	learned := make([]string, 0)
 
-	if user.X < width-1 {
-		user.X++
-		user.world.activateCell(user.X, user.Y)
-		user.Act()
-		user.Save()
+	if after > before {
+		for _, unlock := range skillUnlocks(skill, after) {
+			known := false
+			for _, attack := range user.UserData.Attacks {
+				if attack.Name == unlock.Name {
+					known = true
+					break
+				}
+			}
+
+			if !known {
+				attack := unlock
+				user.UserData.Attacks = append(user.UserData.Attacks, &attack)
+				learned = append(learned, attack.Name)
+			}
+		}
 	}
-}
 
-func (user *dbUser) MoveWest() {
-	user.Reload()
-	if user.X > 0 {
-		user.X--
-		user.world.activateCell(user.X, user.Y)
-		user.Act()
-		user.Save()
+	user.Save()
+
+	if after > before {
+		user.Log(LogItem{Message: fmt.Sprintf("Your %v skill is now level %v", skill, after), MessageType: MESSAGEACTIVITY})
+	}
+	for _, name := range learned {
+		user.Log(LogItem{Message: fmt.Sprintf("You learned %v!", name), MessageType: MESSAGEACTIVITY})
 	}
 }
 
-func (user *dbUser) ChargePoints() {
-	user.Reload()
-	ap, rp, mp, xp := user.MaxAP(), user.MaxRP(), user.MaxMP(), user.XPToNextLevel()
-	cap, crp, cmp, cxp := user.AP(), user.RP(), user.MP(), user.XP()
+func (user *dbUser) Respeccing() bool {
+	return user.UserData.Respec
+}
 
-	changed := false
-	full := true
+func (user *dbUser) ClassRespecPrice() uint64 {
+	return classRespecPrice(user.Level())
+}
 
-	if cap < ap {
-		user.SetAP(cap + 1)
-		changed = true
-		full = false
-	}
+// StartRespec sends the user back to character setup to pick a new class
+func (user *dbUser) StartRespec() error {
+	user.Reload()
 
-	if crp < rp {
-		user.SetRP(crp + 1)
-		changed = true
-		full = false
+	if user.UserData.Respec {
+		return nil
 	}
 
-	if cmp < mp {
-		user.SetMP(cmp + 1)
-		changed = true
-		full = false
+	if price := user.ClassRespecPrice(); user.UserData.Coins < price {
+		return fmt.Errorf("Picking a new class costs %v; you have %v", coinString(price), user.UserData.Coins)
 	}
 
-	if cxp >= xp {
-		user.levelUp()
-		changed = true
-	}
+	user.UserData.Respec = true
+	user.UserData.RespecFrom = user.UserData.ClassInfo
+	user.Save()
 
-	if full {
-		hp, maxhp := user.HP(), user.MaxHP()
+	return nil
+}
 
-		if hp == 0 {
-			user.Save()
-			user.Respawn()
-		} else {
-			chg, maxchg := user.Charge()
-			if hp < maxhp && chg == maxchg && time.Now().Unix()%5 == 0 {
-				user.SetHP(hp + 1)
-				changed = true
-			}
-		}
-	}
+// CancelRespec goes back to the class the user had before they started picking a new one
+func (user *dbUser) CancelRespec() {
+	user.Reload()
 
-	if changed {
-		user.Save()
+	if !user.UserData.Respec {
+		return
 	}
+
+	user.UserData.ClassInfo = user.UserData.RespecFrom
+	user.UserData.Respec = false
+	user.UserData.RespecFrom = 0
+	user.Save()
 }
 
-func (user *dbUser) levelUp() {
-	if user.XP() >= user.XPToNextLevel() {
-		var apbonus, rpbonus, mpbonus, hpbonus uint64 = 1, 1, 1, 1
+// FinishRespec keeps the class picked in setup, for a price. Max stats are worked out again from
+// the base stats, attacks and slots become the new class's along with those from skills and
+// talents, and anything equipped that the new slots can't hold goes back in the inventory.
+// New strengths mean a new talent tree, so talents are forgotten if they changed.
+func (user *dbUser) FinishRespec() error {
+	var userData UserData
 
-		user.UserData.XP -= user.XPToNextLevel()
+	err := user.world.store.Update(func(tx Tx) error {
+		users, items, equipment := userRepo(tx), userItemRepo(tx), equipmentRepo(tx)
 
-		primary, secondary := user.Strengths()
+		var found bool
+		if userData, found = users.Get(user.UserData.Username); !found {
+			userData = user.UserData
+		}
 
-		switch primary {
-		case MELEEPRIMARY:
-			apbonus += 2
-		case RANGEPRIMARY:
-			rpbonus += 2
-		case MAGICPRIMARY:
-			mpbonus += 2
+		if !userData.Respec {
+			return fmt.Errorf("You aren't picking a new class")
 		}
 
-		switch secondary {
-		case MELEESECONDARY:
-			apbonus++
-		case RANGESECONDARY:
-			rpbonus++
-		case MAGICSECONDARY:
-			mpbonus++
+		price := classRespecPrice(userData.Level)
+		if userData.Coins < price {
+			return fmt.Errorf("Picking a new class costs %v; you have %v", coinString(price), userData.Coins)
 		}
+		userData.Coins -= price
 
-		user.SetMaxAP(user.MaxAP() + apbonus)
-		user.SetMaxRP(user.MaxRP() + rpbonus)
-		user.SetMaxMP(user.MaxMP() + mpbonus)
-		user.SetMaxHP(user.MaxHP() + hpbonus)
+		oldSlots := userData.Slots
+		strengthMask := PRIMARYSTRENGTHMASK | SECONDARYSTRENGTHMASK
+		if userData.ClassInfo&strengthMask != userData.RespecFrom&strengthMask {
+			forgetTalents(&userData)
+		}
 
-		user.Log(LogItem{Message: "Leveled Up!", MessageType: MESSAGEACTIVITY})
-	}
-}
+		primary, secondary := userData.ClassInfo&PRIMARYSTRENGTHMASK, userData.ClassInfo&SECONDARYSTRENGTHMASK
+		userData.Attacks = classAttacks(&userData, primary, secondary)
+		userData.Slots = classSlots(&userData, primary, secondary)
+		setClassStats(&userData, primary, secondary)
+		userData.Respec = false
+		userData.RespecFrom = 0
 
-func (user *dbUser) Log(message LogItem) {
-	now := time.Now().UTC()
+		for _, slot := range oldSlots {
+			item := equipment.Get(userData.Username, slot.Name)
+			if item == nil || slotHolds(userData.Slots, slot.Name, item) {
+				continue
+			}
 
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(buf, binary.BigEndian, byte(0))
-	binary.Write(buf, binary.BigEndian, -now.UnixNano())
+			if err := items.Put([]byte(userData.Username), item); err != nil {
+				return err
+			}
+			if err := equipment.Put(userData.Username, slot.Name, nil); err != nil {
+				return err
+			}
+		}
 
-	messageBytes, err := MSGPack(message)
+		return users.Put(&userData)
+	})
 
 	if err != nil {
-		log.Printf("Log serialization failure: %v", err)
-		return
+		return err
 	}
 
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userlog"))
+	user.UserData = userData
+	user.Log(LogItem{Message: fmt.Sprintf("You're now a %v", user.Title()), MessageType: MESSAGEACTIVITY})
 
-		err := bucket.Put(buf.Bytes(), messageBytes)
+	return nil
+}
 
-		return err
-	})
+func (user *dbUser) Talents() []string {
+	return user.UserData.Talents
 }
 
-func (user *dbUser) GetLog() []LogItem {
-	logMessages := make([]LogItem, 0)
+func (user *dbUser) TalentPoints() uint64 {
+	earned, spent := talentPointsEarned(user.Level()), talentPointsSpent(user.UserData.Talents)
+	if spent >= earned {
+		return 0
+	}
 
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-	binary.Write(minBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
+	return earned - spent
+}
 
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
-	ct := 0
+// LearnTalent spends talent points on a talent from the user's tree, granting whatever it carries
+func (user *dbUser) LearnTalent(id string) error {
+	user.Reload()
 
-	user.world.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userlog"))
+	tree, ok := talentTree(user)
+	if !ok {
+		return fmt.Errorf("You have no talents to learn")
+	}
 
-		cur := bucket.Cursor()
+	talent, ok := tree.Talents[id]
+	if !ok {
+		return fmt.Errorf("That talent isn't in your tree")
+	}
 
-		for k, v := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0 && ct < 80; k, v = cur.Next() {
-			var messageStruct LogItem
+	if err := canLearnTalent(user, &talent); err != nil {
+		return err
+	}
 
-			err := MSGUnpack(v, &messageStruct)
+	user.UserData.Talents = append(user.UserData.Talents, talent.ID)
 
-			if err != nil {
+	if talent.Attack != nil {
+		attack := *talent.Attack
+		user.UserData.Attacks = append(user.UserData.Attacks, &attack)
+	}
+	if talent.Slot != nil {
+		slot := *talent.Slot
+		user.UserData.Slots = append(user.UserData.Slots, &slot)
+	}
+	if talent.Passive != "" {
+		user.UserData.Effects = addEffect(user.UserData.Effects, talent.Passive, time.Now().Unix())
+	}
+
+	user.Save()
+	user.Log(LogItem{Message: fmt.Sprintf("You learned %v!", talent.Name), MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
+
+func (user *dbUser) RespecPrice() uint64 {
+	user.Reload()
+
+	return talentRespecCost * talentPointsSpent(user.UserData.Talents)
+}
+
+// RespecTalents forgets every talent for a price, giving back the points spent on them. Anything
+// equipped in a slot a talent gave goes back in the inventory.
+func (user *dbUser) RespecTalents() error {
+	var userData UserData
+
+	err := user.world.store.Update(func(tx Tx) error {
+		users, items, equipment := userRepo(tx), userItemRepo(tx), equipmentRepo(tx)
+
+		var found bool
+		if userData, found = users.Get(user.UserData.Username); !found {
+			userData = user.UserData
+		}
+
+		if len(userData.Talents) == 0 {
+			return fmt.Errorf("You haven't learned any talents")
+		}
+
+		price := talentRespecCost * talentPointsSpent(userData.Talents)
+		if userData.Coins < price {
+			return fmt.Errorf("Forgetting your talents costs %v; you have %v", coinString(price), userData.Coins)
+		}
+		userData.Coins -= price
+
+		for _, slot := range forgetTalents(&userData) {
+			item := equipment.Get(userData.Username, slot)
+			if item == nil {
+				continue
+			}
+
+			if err := items.Put([]byte(userData.Username), item); err != nil {
 				return err
 			}
+			if err := equipment.Put(userData.Username, slot, nil); err != nil {
+				return err
+			}
+		}
+
+		return users.Put(&userData)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.UserData = userData
+	user.Log(LogItem{Message: fmt.Sprintf("You forgot your talents. Talent points to spend: %v", user.TalentPoints()), MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
+
+func (user *dbUser) Cell() Cell {
+	return user.world.Cell(user.X, user.Y)
+}
+
+// Location returns the name of the current cell
+func (user *dbUser) LocationName() string {
+	ci := user.Cell().CellInfo()
+	if ci != nil {
+		return ci.RegionName
+	}
+	return "Delaware"
+}
+
+func (user *dbUser) MoveNorth() {
+	user.Reload()
+	if user.Y > 0 {
+		user.Y--
+		user.world.activateCell(user.X, user.Y)
+		user.Act()
+		user.Save()
+		user.QuestEvent(QUESTREACH, user.LocationName())
+	}
+}
+
+func (user *dbUser) MoveSouth() {
+	user.Reload()
+	_, height := user.world.GetDimensions()
+
+	if user.Y < height-1 {
+		user.Y++
+		user.world.activateCell(user.X, user.Y)
+		user.Act()
+		user.Save()
+		user.QuestEvent(QUESTREACH, user.LocationName())
+	}
+}
+
+func (user *dbUser) MoveEast() {
+	user.Reload()
+	width, _ := user.world.GetDimensions()
+
+	if user.X < width-1 {
+		user.X++
+		user.world.activateCell(user.X, user.Y)
+		user.Act()
+		user.Save()
+		user.QuestEvent(QUESTREACH, user.LocationName())
+	}
+}
+
+func (user *dbUser) MoveWest() {
+	user.Reload()
+	if user.X > 0 {
+		user.X--
+		user.world.activateCell(user.X, user.Y)
+		user.Act()
+		user.Save()
+		user.QuestEvent(QUESTREACH, user.LocationName())
+	}
+}
+
+// ChargePoints restores a point of AP, RP and MP, levels the user up and slowly heals them once
+// they're rested. It runs on the ticker alongside whatever else the user is doing, so it only
+// touches those stats, all in one transaction.
+func (user *dbUser) ChargePoints() {
+	var userData UserData
+	found, leveled, dead := false, false, false
+
+	err := user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+
+		if userData, found = users.Get(user.UserData.Username); !found {
+			return nil
+		}
+
+		changed := false
+		full := true
+
+		for _, points := range []struct{ current, max *uint64 }{
+			{&userData.AP, &userData.MaxAP},
+			{&userData.RP, &userData.MaxRP},
+			{&userData.MP, &userData.MaxMP}} {
+			if *points.current < *points.max {
+				*points.current++
+				changed = true
+				full = false
+			}
+		}
+
+		if leveled = levelUp(&userData); leveled {
+			changed = true
+		}
+
+		if full {
+			if userData.HP == 0 {
+				dead = true
+			} else {
+				charge := int64(0)
+				if last, ok := users.LastAction(userData.Username); ok {
+					charge = (time.Now().UTC().UnixNano() - last) / 1000000000
+				}
+
+				chg, maxchg := userCharge(&userData, charge)
+				if userData.HP < userData.MaxHP && chg == maxchg && time.Now().Unix()%5 == 0 {
+					userData.HP++
+					changed = true
+				}
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+
+		return users.Put(&userData)
+	})
+
+	if err != nil {
+		log.Printf("Can't charge %v: %v", user.UserData.Username, err)
+		return
+	} else if !found {
+		return
+	}
 
-			logMessages = append(logMessages, messageStruct)
-			ct++
+	user.UserData = userData
+
+	if leveled {
+		user.Log(LogItem{Message: "Leveled Up!", MessageType: MESSAGEACTIVITY})
+
+		if _, ok := talentTree(user); ok {
+			user.Log(LogItem{Message: fmt.Sprintf("Talent points to spend: %v (/talents)", user.TalentPoints()), MessageType: MESSAGEACTIVITY})
 		}
+	}
+
+	if dead {
+		user.Respawn()
+	}
+}
+
+// levelUp spends a level's worth of XP on the next level, if there's enough, raising max stats to match
+func levelUp(userData *UserData) bool {
+	need := userData.MaxAP + userData.MaxRP + userData.MaxMP
+	if userData.XP < need {
+		return false
+	}
+
+	userData.XP -= need
+	if userData.Level == 0 {
+		userData.Level = 1
+	}
+	userData.Level++
+
+	apbonus, rpbonus, mpbonus, hpbonus := levelUpBonuses(userData.ClassInfo&PRIMARYSTRENGTHMASK, userData.ClassInfo&SECONDARYSTRENGTHMASK)
+
+	userData.MaxAP += apbonus
+	userData.MaxRP += rpbonus
+	userData.MaxMP += mpbonus
+	userData.MaxHP += hpbonus
+
+	return true
+}
+
+func (user *dbUser) Log(message LogItem) {
+	err := user.world.store.Update(func(tx Tx) error {
+		return logRepo(tx).Append(user.UserData.Username, message, time.Now())
+	})
+
+	if err != nil {
+		log.Printf("Log serialization failure: %v", err)
+	}
+}
+
+func (user *dbUser) GetLog() []LogItem {
+	var logMessages []LogItem
+
+	user.world.store.View(func(tx Tx) error {
+		logMessages = logRepo(tx).Recent(user.UserData.Username, 80)
 
 		return nil
 	})
@@ -1877,88 +2017,124 @@ func (user *dbUser) GetLog() []LogItem {
 	return logMessages
 }
 
-func (user *dbUser) MarkActive() {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.BigEndian, time.Now().UTC().Unix())
+func (user *dbUser) GetLogSince(since time.Time) []LogItem {
+	var logMessages []LogItem
 
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("onlineusers"))
-		err := bucket.Put([]byte(user.UserData.Username), buf.Bytes())
+	user.world.store.View(func(tx Tx) error {
+		logMessages = logRepo(tx).Since(user.UserData.Username, since)
 
-		return err
+		return nil
+	})
+
+	return logMessages
+}
+
+func (user *dbUser) MarkActive() {
+	user.world.store.Update(func(tx Tx) error {
+		return userRepo(tx).MarkOnline(user.UserData.Username, time.Now())
 	})
 
-	user.world.activateCell(user.X, user.Y)
+	user.world.activateCellsAround(user.X, user.Y, creatureWakeRadius)
 }
 
+// Respawn brings a dead user back to life at their spawn point
 func (user *dbUser) Respawn() {
-	user.Reload()
-	if user.HP() == 0 {
-		user.SetHP(user.MaxHP())
-		user.Save()
-		user.Log(LogItem{Message: "You died. Be more careful.", MessageType: MESSAGESYSTEM})
-		user.X = user.SpawnX
-		user.Y = user.SpawnY
-		user.SetHP(user.MaxHP())
-		user.Save()
+	var userData UserData
+	respawned := false
+
+	err := user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+
+		var found bool
+		if userData, found = users.Get(user.UserData.Username); !found || userData.HP > 0 {
+			return nil
+		}
+
+		userData.X, userData.Y = userData.SpawnX, userData.SpawnY
+		userData.HP = userData.MaxHP
+		userData.Effects = nil
+		userData.ProtectedUntil = time.Now().Unix() + pvpProtectionSeconds
+		respawned = true
+
+		return users.Put(&userData)
+	})
+
+	if err != nil {
+		log.Printf("Can't respawn %v: %v", user.UserData.Username, err)
+		return
+	} else if !respawned {
+		return
 	}
+
+	user.UserData = userData
+	user.Log(LogItem{Message: "You died. Be more careful.", MessageType: MESSAGESYSTEM})
+	user.Log(LogItem{Message: fmt.Sprintf("Other players can't attack you for %v seconds, unless you attack first.", pvpProtectionSeconds), MessageType: MESSAGESYSTEM})
 }
 
 func (user *dbUser) Reload() {
-	var record []byte
-	user.world.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("users"))
+	var userData UserData
+	found := false
 
-		record = bucket.Get([]byte(user.UserData.Username))
+	user.world.store.View(func(tx Tx) error {
+		userData, found = userRepo(tx).Get(user.UserData.Username)
 
 		return nil
 	})
 
-	if record == nil {
-		log.Printf("User %s does not exist, creating anew...", user.UserData.Username)
+	if !found {
+		log.Printf("User %s does not exist, using a blank one...", user.UserData.Username)
 		user.UserData = user.world.newUser(user.UserData.Username)
 	} else {
-		MSGUnpack(record, &(user.UserData))
+		user.UserData = userData
 	}
 }
 
-func (user *dbUser) Save() {
-	bytes, err := MSGPack(user.UserData)
-	if err != nil {
-		log.Printf("Can't marshal user: %v", err)
-		return
-	}
+// stored reads the user as they are in the store without replacing the copy in hand, for
+// getters whose fields only change in transactions
+func (user *dbUser) stored() UserData {
+	userData := user.UserData
+
+	user.world.store.View(func(tx Tx) error {
+		if found, ok := userRepo(tx).Get(user.UserData.Username); ok {
+			userData = found
+		}
+
+		return nil
+	})
 
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("users"))
+	return userData
+}
 
-		err = bucket.Put([]byte(user.UserData.Username), bytes)
+// Save writes the user back. Only CreateCharacter makes new users, so a user loaded by a name
+// nobody has isn't written.
+func (user *dbUser) Save() {
+	err := user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
 
-		return err
+		if _, found := users.Get(user.UserData.Username); !found {
+			log.Printf("Not saving %s, who does not exist", user.UserData.Username)
+			return nil
+		}
+
+		return users.Put(&user.UserData)
 	})
+
+	if err != nil {
+		log.Printf("Can't marshal user: %v", err)
+	}
 }
 
 func (user *dbUser) Act() {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.BigEndian, time.Now().UTC().UnixNano())
-
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("lastuseraction"))
-		return bucket.Put([]byte(user.UserData.Username), buf.Bytes())
+	user.world.store.Update(func(tx Tx) error {
+		return userRepo(tx).Act(user.UserData.Username, time.Now())
 	})
 }
 
 func (user *dbUser) GetLastAction() int64 {
 	timeDelta := int64(0)
 
-	user.world.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("lastuseraction"))
-		stamp := bucket.Get([]byte(user.UserData.Username))
-		buf := bytes.NewBuffer(stamp)
-
-		var last int64
-
-		if binary.Read(buf, binary.BigEndian, &last) == nil {
+	user.world.store.View(func(tx Tx) error {
+		if last, ok := userRepo(tx).LastAction(user.UserData.Username); ok {
 			timeDelta = time.Now().UTC().UnixNano() - last
 		}
 
@@ -1969,8 +2145,12 @@ func (user *dbUser) GetLastAction() int64 {
 }
 
 func (user *dbUser) Charge() (int64, int64) {
-	charge := user.GetLastAction()
-	maxCharge := int64(user.MaxAP()+user.MaxMP()+user.MaxRP()) / 3
+	return userCharge(&user.UserData, user.GetLastAction())
+}
+
+// userCharge caps the seconds since a user's last action at the most charge they can hold
+func userCharge(userData *UserData, charge int64) (int64, int64) {
+	maxCharge := int64(userData.MaxAP+userData.MaxMP+userData.MaxRP) / 3
 	if charge > maxCharge {
 		charge = maxCharge
 	}
@@ -2099,6 +2279,8 @@ func (user *dbUser) MusterAttack(attackName string) *Attack {
 					user.Act()
 
 					attack := potentialAttack.ApplyBonuses(user)
+					attack = talentBonuses(user.UserData.Talents, attack)
+					attack = effectBonuses(user.UserData.Effects, attack, time.Now().Unix())
 
 					return &attack
 				}
@@ -2110,176 +2292,1586 @@ func (user *dbUser) MusterAttack(attackName string) *Attack {
 }
 
 func (user *dbUser) MusterCounterAttack() *Attack {
-	return nil
-}
+	user.Reload()
+	now := time.Now().Unix()
+	charge, _ := user.Charge()
 
-func (user *dbUser) InventoryItems() []*InventoryItem {
-	items := make([]*InventoryItem, 0)
+	for _, slot := range user.Equipped() {
+		if slot.Item == nil {
+			continue
+		}
 
-	minBuf := new(bytes.Buffer)
-	maxBuf := new(bytes.Buffer)
-	binary.Write(minBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(minBuf, binary.BigEndian, byte(0))
-	binary.Write(maxBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(maxBuf, binary.BigEndian, byte(1))
+		for _, counter := range slot.Item.CounterAttacks {
+			if user.CounterCooldowns[counter.Name] > now {
+				continue
+			}
 
-	min := minBuf.Bytes()
-	max := maxBuf.Bytes()
+			if charge < counter.Charge || !user.canAffordAttack(&counter) {
+				continue
+			}
 
-	user.world.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userinventory"))
+			if !counter.counterTriggers() {
+				continue
+			}
 
-		cur := bucket.Cursor()
+			user.SetAP(user.AP() - counter.AP)
+			user.SetRP(user.RP() - counter.RP)
+			user.SetMP(user.MP() - counter.MP)
 
-		for k, v := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = cur.Next() {
-			var inventoryItem InventoryItem
+			if counter.Cooldown > 0 {
+				if user.CounterCooldowns == nil {
+					user.CounterCooldowns = make(map[string]int64)
+				}
+				user.CounterCooldowns[counter.Name] = now + counter.Cooldown
+			}
 
-			err := MSGUnpack(v, &inventoryItem)
+			user.Save()
+			user.Act()
 
-			if err != nil {
-				return err
-			}
+			attack := counter.ApplyBonuses(user)
+			attack = talentBonuses(user.UserData.Talents, attack)
+			attack = effectBonuses(user.UserData.Effects, attack, now)
+			attack.IsCounter = true
 
-			items = append(items, &inventoryItem)
+			return &attack
 		}
+	}
 
-		return nil
-	})
+	return nil
+}
 
-	return items
+func (user *dbUser) Effects() []ActiveEffect {
+	return user.UserData.Effects
 }
 
-func (user *dbUser) AddInventoryItem(item *InventoryItem) bool {
-	if item == nil {
-		return false
-	}
+func (user *dbUser) AddEffect(id string) {
+	user.UserData.Effects = addEffect(user.UserData.Effects, id, time.Now().Unix())
+}
 
-	inventoryItem := *item
+func (user *dbUser) AbsorbDamage(damage uint64) uint64 {
+	user.UserData.Effects, damage = absorbDamage(user.UserData.Effects, damage)
+	return damage
+}
 
-	if inventoryItem.ID == "" {
-		inventoryItem.ID = uuid.New().String()
-	}
+// TickEffects runs a second of the user's status effects. It runs on the ticker alongside
+// whatever else the user is doing, so it only touches their effects, HP and charge, in one transaction.
+func (user *dbUser) TickEffects() {
+	var ticked *UserData
+	var passives map[string]bool
+	var expired []string
+	succumbed := false
 
-	itemID, err := uuid.Parse(inventoryItem.ID)
-	if err != nil {
-		return false
-	}
+	err := user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
 
-	idBytes, err := itemID.MarshalBinary()
-	if err != nil {
-		return false
-	}
+		userData, found := users.Get(user.UserData.Username)
+		if !found {
+			return nil
+		}
 
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, idBytes)
-	dataBytes, err := MSGPack(inventoryItem)
+		passives = talentPassives(userData.Talents)
+		if len(userData.Effects) == 0 && len(passives) == 0 {
+			return nil
+		}
 
-	if err != nil {
-		return false
-	}
+		now := time.Now()
+		effects, change, gone := tickEffects(userData.Effects, now.Unix())
+		effects = keepPassives(effects, passives, now.Unix())
+		userData.Effects = effects
+		expired = gone
 
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userinventory"))
+		if userData.HP > 0 {
+			userData.HP = applyHPChange(userData.HP, userData.MaxHP, change)
+			succumbed = userData.HP == 0
+		}
 
-		return bucket.Put(keyBuf.Bytes(), dataBytes)
+		// Stunned users' charge is held at zero until it wears off
+		if effectsStun(effects, now.Unix()) {
+			if err := users.Act(userData.Username, now); err != nil {
+				return err
+			}
+		} else if effectsSlow(effects, now.Unix()) {
+			// Slowed users' charge builds at half speed, so only half of each tick counts
+			if err := users.Delay(userData.Username, tickInterval/2, now); err != nil {
+				return err
+			}
+		}
+
+		ticked = &userData
+
+		return users.Put(&userData)
+	})
+
+	if err != nil {
+		log.Printf("Can't tick effects for %v: %v", user.UserData.Username, err)
+		return
+	} else if ticked == nil {
+		return
+	}
+
+	user.UserData.Effects, user.UserData.HP = ticked.Effects, ticked.HP
+
+	if succumbed {
+		user.Log(LogItem{Message: "You succumbed to your wounds.", MessageType: MESSAGEACTIVITY})
+	}
+
+	for _, name := range expired {
+		if passiveNamed(passives, name) {
+			continue
+		}
+		user.Log(LogItem{Message: fmt.Sprintf("No longer %v.", name), MessageType: MESSAGEACTIVITY})
+	}
+}
+
+func (user *dbUser) InventoryItems() []*InventoryItem {
+	var items []*InventoryItem
+
+	user.world.store.View(func(tx Tx) error {
+		items = userItemRepo(tx).List([]byte(user.UserData.Username))
+
+		return nil
+	})
+
+	return items
+}
+
+func (user *dbUser) AddInventoryItem(item *InventoryItem) bool {
+	if item == nil {
+		return false
+	}
+
+	inventoryItem := *item
+
+	if inventoryItem.ID == "" {
+		inventoryItem.ID = uuid.New().String()
+	}
+
+	err := user.world.store.Update(func(tx Tx) error {
+		return userItemRepo(tx).Put([]byte(user.UserData.Username), &inventoryItem)
+	})
+
+	if err != nil {
+		return false
+	}
+
+	user.QuestEvent(QUESTCOLLECT, inventoryItem.Name)
+
+	return true
+}
+
+func (user *dbUser) inventoryItem(id string, pull bool) *InventoryItem {
+	var inventoryItem *InventoryItem
+
+	owner := []byte(user.UserData.Username)
+
+	user.world.store.Update(func(tx Tx) error {
+		items := userItemRepo(tx)
+
+		inventoryItem = items.Get(owner, id)
+
+		if pull && inventoryItem != nil {
+			return items.Delete(owner, id)
+		}
+
+		return nil
+	})
+
+	return inventoryItem
+}
+
+func (user *dbUser) InventoryItem(id string) *InventoryItem {
+	return user.inventoryItem(id, false)
+}
+
+func (user *dbUser) PullInventoryItem(id string) *InventoryItem {
+	return user.inventoryItem(id, true)
+}
+
+func (user *dbUser) Use(item *InventoryItem) error {
+	if item == nil {
+		return fmt.Errorf("Nothing to use")
+	} else if item.Use == nil {
+		return fmt.Errorf("Can't use %v", item.Name)
+	}
+
+	usedItem := user.PullInventoryItem(item.ID)
+	if usedItem == nil || usedItem.Use == nil {
+		return fmt.Errorf("You don't have %v", item.Name)
+	}
+
+	use := usedItem.Use
+	now := time.Now().Unix()
+
+	user.Reload()
+	if user.HP() == 0 {
+		user.AddInventoryItem(usedItem)
+		return fmt.Errorf("Can't use %v while dead", item.Name)
+	}
+
+	user.SetHP(restorePoints(user.HP(), user.MaxHP(), use.HP))
+	user.SetAP(restorePoints(user.AP(), user.MaxAP(), use.AP))
+	user.SetRP(restorePoints(user.RP(), user.MaxRP(), use.RP))
+	user.SetMP(restorePoints(user.MP(), user.MaxMP(), use.MP))
+	user.UserData.XP += use.XP
+
+	for _, effect := range use.Effects {
+		user.AddEffect(effect)
+	}
+
+	if use.Bonuses != "" {
+		user.UserData.Effects = addBonusEffect(user.UserData.Effects, usedItem.Name, use.Bonuses, use.Duration, now)
+	}
+
+	if use.Teleport {
+		user.X = user.SpawnX
+		user.Y = user.SpawnY
+	}
+
+	user.Save()
+
+	if use.Teleport {
+		user.world.activateCell(user.X, user.Y)
+	}
+
+	user.Log(LogItem{Message: fmt.Sprintf("Used %v", usedItem.Name), MessageType: MESSAGEACTIVITY})
+	user.TrainSkill(SKILLCREATIVE, 1)
+
+	return nil
+}
+
+func (user *dbUser) pullInventoryItemByName(name string) *InventoryItem {
+	for _, item := range user.InventoryItems() {
+		if item.Name == name {
+			return user.PullInventoryItem(item.ID)
+		}
+	}
+
+	return nil
+}
+
+func (user *dbUser) Craft(recipeID string) error {
+	recipe, ok := RecipeTypes[recipeID]
+	if !ok {
+		return fmt.Errorf("No such recipe %v", recipeID)
+	}
+
+	user.Reload()
+	if err := canCraft(user, &recipe); err != nil {
+		return err
+	}
+
+	owner := []byte(user.UserData.Username)
+
+	// Orderly crafters sometimes get a second batch out of the same inputs
+	outputs := recipe.Outputs
+	spare := rand.Float32() < orderlyYield(user)
+	if spare {
+		outputs = append(append([]string{}, outputs...), outputs...)
+	}
+
+	// Inputs come out and outputs go in together, so a failure part way leaves the inventory alone
+	err := user.world.store.Update(func(tx Tx) error {
+		items := userItemRepo(tx)
+		needs := recipe.Needs()
+
+		for _, item := range items.List(owner) {
+			if needs[item.Name] == 0 {
+				continue
+			}
+
+			if err := items.Delete(owner, item.ID); err != nil {
+				return err
+			}
+			needs[item.Name]--
+		}
+
+		for name, need := range needs {
+			if need > 0 {
+				return fmt.Errorf("%v needs more %v", recipe.Name, name)
+			}
+		}
+
+		for _, name := range outputs {
+			output, ok := ItemTypes[name]
+			if !ok {
+				return fmt.Errorf("%v makes %v, which doesn't exist", recipe.Name, name)
+			}
+
+			output.ID = uuid.New().String()
+			if err := items.Put(owner, &output); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if recipe.XP > 0 {
+		user.AddXP(recipe.XP)
+	}
+
+	for _, name := range outputs {
+		user.QuestEvent(QUESTCOLLECT, name)
+	}
+
+	if spare {
+		user.Log(LogItem{Message: fmt.Sprintf("You made %v, with enough left over for a second batch", itemList(outputs)), MessageType: MESSAGEACTIVITY})
+	} else {
+		user.Log(LogItem{Message: fmt.Sprintf("You made %v", itemList(outputs)), MessageType: MESSAGEACTIVITY})
+	}
+
+	// Crafting trains the skill the recipe calls for, and being tidy about it trains Orderly
+	if recipe.Skill != "" {
+		user.TrainSkill(recipe.Skill, 3)
+	}
+	user.TrainSkill(SKILLORDERLY, 1)
+
+	return nil
+}
+
+func (user *dbUser) Coins() uint64 {
+	return user.stored().Coins
+}
+
+func (user *dbUser) AddCoins(coins uint64) {
+	err := user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+
+		userData, found := users.Get(user.UserData.Username)
+		if !found {
+			userData = user.UserData
+		}
+
+		userData.Coins += coins
+		user.UserData.Coins = userData.Coins
+
+		return users.Put(&userData)
+	})
+
+	if err != nil {
+		log.Printf("Can't give %v coins to %v: %v", coins, user.UserData.Username, err)
+	}
+}
+
+func (user *dbUser) Buy(vendorID string, itemName string) error {
+	vendor, shop, err := vendorHere(user, vendorID)
+	if err != nil {
+		return err
+	}
+
+	selling, ok := shop.Selling(itemName)
+	if !ok {
+		return fmt.Errorf("%v doesn't sell %v", vendor.CreatureTypeStruct.Name, itemName)
+	}
+
+	bought, ok := ItemTypes[selling.Item]
+	if !ok {
+		return fmt.Errorf("%v sells %v, which doesn't exist", vendor.CreatureTypeStruct.Name, selling.Item)
+	}
+
+	var userData UserData
+
+	err = user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+
+		var found bool
+		if userData, found = users.Get(user.UserData.Username); !found {
+			userData = user.UserData
+		}
+
+		if userData.Coins < selling.Price {
+			return fmt.Errorf("%v costs %v; you have %v", selling.Item, coinString(selling.Price), userData.Coins)
+		}
+
+		if selling.Stock > 0 {
+			shops := shopRepo(tx)
+			stock := stockOf(shop, shops.Get(vendor.ID), time.Now().Unix())
+
+			level := stock[selling.Item]
+			if level.Count == 0 {
+				return fmt.Errorf("%v is out of %v", vendor.CreatureTypeStruct.Name, selling.Item)
+			}
+			level.Count--
+			stock[selling.Item] = level
+
+			if err := shops.Put(vendor.ID, stock); err != nil {
+				return err
+			}
+		}
+
+		userData.Coins -= selling.Price
+		if err := users.Put(&userData); err != nil {
+			return err
+		}
+
+		bought.ID = uuid.New().String()
+		return userItemRepo(tx).Put([]byte(userData.Username), &bought)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.UserData = userData
+	user.QuestEvent(QUESTCOLLECT, bought.Name)
+	user.Log(LogItem{Message: fmt.Sprintf("You bought %v from %v for %v", bought.Name, vendor.CreatureTypeStruct.Name, coinString(selling.Price)), MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
+
+func (user *dbUser) Sell(vendorID string, itemID string) error {
+	vendor, shop, err := vendorHere(user, vendorID)
+	if err != nil {
+		return err
+	}
+
+	var userData UserData
+	var sold *InventoryItem
+	price := uint64(0)
+
+	err = user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+		items := userItemRepo(tx)
+		owner := []byte(user.UserData.Username)
+
+		var found bool
+		if userData, found = users.Get(user.UserData.Username); !found {
+			userData = user.UserData
+		}
+
+		sold = items.Get(owner, itemID)
+		if sold == nil {
+			return fmt.Errorf("You aren't carrying that")
+		}
+
+		price = shop.BuyPrice(sold)
+		if price == 0 {
+			return fmt.Errorf("%v won't buy %v", vendor.CreatureTypeStruct.Name, sold.Name)
+		}
+
+		if err := items.Delete(owner, itemID); err != nil {
+			return err
+		}
+
+		userData.Coins += price
+		return users.Put(&userData)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.UserData = userData
+	user.Log(LogItem{Message: fmt.Sprintf("You sold %v to %v for %v", sold.Name, vendor.CreatureTypeStruct.Name, coinString(price)), MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
+
+func (user *dbUser) Quests() []QuestProgress {
+	var quests []QuestProgress
+
+	user.world.store.View(func(tx Tx) error {
+		quests = questRepo(tx).List(user.UserData.Username)
+
+		return nil
+	})
+
+	return quests
+}
+
+func (user *dbUser) StartQuest(id string) error {
+	questType, ok := QuestTypes[id]
+	if !ok {
+		return fmt.Errorf("No such quest %v", id)
+	}
+
+	err := user.world.store.Update(func(tx Tx) error {
+		quests := questRepo(tx)
+
+		if _, started := quests.Get(user.UserData.Username, id); started {
+			return fmt.Errorf("Already on quest %v", questType.Name)
+		}
+
+		return quests.Put(user.UserData.Username, &QuestProgress{QuestID: id, Counts: make([]uint64, len(questType.Objectives))})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.Log(LogItem{Message: fmt.Sprintf("New quest: %v", questType.Name), MessageType: MESSAGEACTIVITY})
+
+	// It might already be done, if it only asks for things the user is carrying
+	user.QuestEvent("", "")
+
+	return nil
+}
+
+func (user *dbUser) QuestEvent(kind, subject string) {
+	var quests []QuestProgress
+	carrying := carriedItems(user.InventoryItems())
+
+	err := user.world.store.Update(func(tx Tx) error {
+		repo := questRepo(tx)
+		quests = repo.List(user.UserData.Username)
+
+		for index := range quests {
+			if quests[index].Record(kind, subject, carrying) {
+				if err := repo.Put(user.UserData.Username, &quests[index]); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Can't update quests for %v: %v", user.UserData.Username, err)
+		return
+	}
+
+	for _, quest := range quests {
+		if quest.Complete(carrying) {
+			user.finishQuest(quest.QuestID)
+			carrying = carriedItems(user.InventoryItems())
+		}
+	}
+}
+
+// finishQuest marks a quest done, takes any items it asked for and hands out the reward
+func (user *dbUser) finishQuest(id string) {
+	questType, ok := QuestTypes[id]
+	if !ok {
+		return
+	}
+
+	owner := []byte(user.UserData.Username)
+	finished := false
+
+	err := user.world.store.Update(func(tx Tx) error {
+		quests := questRepo(tx)
+
+		progress, ok := quests.Get(user.UserData.Username, id)
+		if !ok || progress.Done {
+			return nil
+		}
+
+		needs := make(map[string]uint64)
+		for _, objective := range questType.Objectives {
+			if kind, name := objective.Kind(); kind == QUESTCOLLECT {
+				needs[name] += objective.Needed()
+			}
+		}
+
+		items := userItemRepo(tx)
+		for _, item := range items.List(owner) {
+			if needs[item.Name] == 0 {
+				continue
+			}
+
+			if err := items.Delete(owner, item.ID); err != nil {
+				return err
+			}
+			needs[item.Name]--
+		}
+
+		for name, need := range needs {
+			if need > 0 {
+				return fmt.Errorf("%v needs more %v", questType.Name, name)
+			}
+		}
+
+		progress.Done = true
+		finished = true
+
+		return quests.Put(user.UserData.Username, &progress)
+	})
+
+	if err != nil {
+		log.Printf("Can't finish quest %v for %v: %v", id, user.UserData.Username, err)
+		return
+	}
+
+	// Someone else got here first
+	if !finished {
+		return
+	}
+
+	user.Log(LogItem{Message: fmt.Sprintf("Quest complete: %v", questType.Name), MessageType: MESSAGEACTIVITY})
+
+	if questType.Reward.XP > 0 {
+		user.AddXP(questType.Reward.XP)
+		user.Log(LogItem{Message: fmt.Sprintf("Gained %v XP", questType.Reward.XP), MessageType: MESSAGEACTIVITY})
+	}
+
+	for _, itemName := range questType.Reward.Items {
+		item, ok := ItemTypes[itemName]
+		if !ok {
+			log.Printf("Unknown reward item %v for quest %v", itemName, id)
+			continue
+		}
+
+		if !user.AddInventoryItem(&item) {
+			user.Cell().AddInventoryItem(&item)
+		}
+		user.Log(LogItem{Message: fmt.Sprintf("Received %v", item.Name), MessageType: MESSAGEACTIVITY})
+	}
+}
+
+func (user *dbUser) InChannel(name string) bool {
+	channel, ok := ChatChannels[name]
+	if !ok || (channel.Admin && !user.IsAdmin()) {
+		return false
+	}
+
+	for _, left := range user.LeftChannels {
+		if left == name {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (user *dbUser) JoinChannel(name string) error {
+	channel, ok := ChatChannels[name]
+	if !ok || (channel.Admin && !user.IsAdmin()) {
+		return fmt.Errorf("No such channel %v", name)
+	}
+
+	user.Reload()
+	left := make([]string, 0, len(user.LeftChannels))
+	for _, leftName := range user.LeftChannels {
+		if leftName != name {
+			left = append(left, leftName)
+		}
+	}
+	user.LeftChannels = left
+	user.Save()
+
+	return nil
+}
+
+func (user *dbUser) LeaveChannel(name string) error {
+	channel, ok := ChatChannels[name]
+	if !ok || (channel.Admin && !user.IsAdmin()) {
+		return fmt.Errorf("No such channel %v", name)
+	} else if !channel.Optional {
+		return fmt.Errorf("Can't leave %v", name)
+	}
+
+	user.Reload()
+	if user.InChannel(name) {
+		user.LeftChannels = append(user.LeftChannels, name)
+		user.Save()
+	}
+
+	return nil
+}
+
+func (user *dbUser) IsAdmin() bool {
+	return user.UserData.Admin
+}
+
+func (user *dbUser) SetAdmin(admin bool) {
+	user.Reload()
+	if user.UserData.Admin != admin {
+		user.UserData.Admin = admin
+		user.Save()
+	}
+}
+
+func (user *dbUser) Party() (Party, bool) {
+	var party Party
+	found := false
+
+	user.world.store.View(func(tx Tx) error {
+		party, found = partyRepo(tx).ForUser(user.UserData.Username)
+
+		return nil
+	})
+
+	return party, found
+}
+
+func (user *dbUser) PartyInvitations() []Party {
+	var parties []Party
+
+	user.world.store.View(func(tx Tx) error {
+		parties = partyRepo(tx).Invitations(user.UserData.Username)
+
+		return nil
+	})
+
+	return parties
+}
+
+func (user *dbUser) InviteToParty(username string) error {
+	online := false
+	for _, other := range user.world.OnlineUsers() {
+		if other.Username() == username {
+			online = true
+			break
+		}
+	}
+
+	if username == user.UserData.Username {
+		return fmt.Errorf("You can't invite yourself")
+	} else if !online {
+		return fmt.Errorf("%v isn't online", username)
+	}
+
+	err := user.world.store.Update(func(tx Tx) error {
+		parties := partyRepo(tx)
+
+		party, ok := parties.ForUser(user.UserData.Username)
+		if !ok {
+			party = Party{ID: uuid.New().String(), Leader: user.UserData.Username, Members: []string{user.UserData.Username}}
+		}
+
+		if party.Leader != user.UserData.Username {
+			return fmt.Errorf("Only %v can invite people to the party", party.Leader)
+		} else if _, inParty := parties.ForUser(username); inParty {
+			return fmt.Errorf("%v is already in a party", username)
+		} else if len(party.Members)+len(party.Invited) >= partyMaxSize {
+			return fmt.Errorf("The party is full")
+		}
+
+		if !party.IsInvited(username) {
+			party.Invited = append(party.Invited, username)
+		}
+
+		return parties.Put(&party)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.world.GetUser(username).Log(LogItem{
+		Message:     fmt.Sprintf("%v invited you to their party; /party accept or /party decline", user.UserData.Username),
+		MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
+
+// invitation finds the party a user means to answer, by its leader's name or, if there's only
+// one, whichever invited them
+func invitation(invitations []Party, leader string) (Party, error) {
+	if len(invitations) == 0 {
+		return Party{}, fmt.Errorf("Nobody has invited you to a party")
+	}
+
+	leaders := make([]string, 0, len(invitations))
+	for _, party := range invitations {
+		leaders = append(leaders, party.Leader)
+	}
+
+	if leader == "" && len(invitations) > 1 {
+		sort.Strings(leaders)
+		return Party{}, fmt.Errorf("Which party? %v", strings.Join(leaders, ", "))
+	} else if leader == "" {
+		return invitations[0], nil
+	}
+
+	for _, party := range invitations {
+		if strings.EqualFold(party.Leader, leader) {
+			return party, nil
+		}
+	}
+
+	return Party{}, fmt.Errorf("%v hasn't invited you to a party", leader)
+}
+
+func (user *dbUser) JoinParty(leader string) error {
+	var joined Party
+
+	err := user.world.store.Update(func(tx Tx) error {
+		parties := partyRepo(tx)
+
+		if _, inParty := parties.ForUser(user.UserData.Username); inParty {
+			return fmt.Errorf("You're already in a party; /party leave first")
+		}
+
+		party, err := invitation(parties.Invitations(user.UserData.Username), leader)
+		if err != nil {
+			return err
+		} else if len(party.Members) >= partyMaxSize {
+			return fmt.Errorf("The party is full")
+		}
+
+		party.removeInvite(user.UserData.Username)
+		party.Members = append(party.Members, user.UserData.Username)
+		joined = party
+
+		return parties.Put(&party)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.world.tellParty(joined, fmt.Sprintf("%v joined the party", user.UserData.Username))
+
+	return nil
+}
+
+func (user *dbUser) DeclineParty(leader string) error {
+	var declined Party
+
+	err := user.world.store.Update(func(tx Tx) error {
+		parties := partyRepo(tx)
+
+		party, err := invitation(parties.Invitations(user.UserData.Username), leader)
+		if err != nil {
+			return err
+		}
+
+		party.removeInvite(user.UserData.Username)
+		declined = party
+
+		if len(party.Members) == 1 && len(party.Invited) == 0 {
+			// Nobody else is coming, so there's no party left
+			return parties.Delete(&party)
+		}
+
+		return parties.Put(&party)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.world.tellParty(declined, fmt.Sprintf("%v declined the invitation", user.UserData.Username))
+
+	return nil
+}
+
+// removeFromParty takes a user out of their party, disbanding it if there'd be nobody left to
+// party with. Only the leader can remove someone else.
+func (user *dbUser) removeFromParty(username string) error {
+	var before, after Party
+	disbanded := false
+
+	err := user.world.store.Update(func(tx Tx) error {
+		parties := partyRepo(tx)
+
+		party, ok := parties.ForUser(user.UserData.Username)
+		if !ok {
+			return fmt.Errorf("You're not in a party")
+		} else if username != user.UserData.Username && party.Leader != user.UserData.Username {
+			return fmt.Errorf("Only %v can remove people from the party", party.Leader)
+		} else if !party.HasMember(username) {
+			return fmt.Errorf("%v isn't in the party", username)
+		}
+
+		before = party
+		party.removeMember(username)
+		after = party
+
+		if err := parties.Unindex(username); err != nil {
+			return err
+		} else if len(party.Members) < 2 && len(party.Invited) == 0 {
+			disbanded = true
+			return parties.Delete(&party)
+		}
+
+		return parties.Put(&party)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if username == user.UserData.Username {
+		user.world.tellParty(before, fmt.Sprintf("%v left the party", username))
+	} else {
+		user.world.tellParty(before, fmt.Sprintf("%v removed %v from the party", user.UserData.Username, username))
+	}
+
+	if disbanded {
+		user.world.tellParty(after, "The party has disbanded")
+	} else if after.Leader != before.Leader {
+		user.world.tellParty(after, fmt.Sprintf("%v now leads the party", after.Leader))
+	}
+
+	return nil
+}
+
+func (user *dbUser) LeaveParty() error {
+	return user.removeFromParty(user.UserData.Username)
+}
+
+func (user *dbUser) KickFromParty(username string) error {
+	if username == user.UserData.Username {
+		return fmt.Errorf("Use /party leave to leave the party")
+	}
+
+	return user.removeFromParty(username)
+}
+
+// updateParty changes the user's party, as long as they lead it
+func (user *dbUser) updateParty(change func(*Party) error) (Party, error) {
+	var party Party
+
+	err := user.world.store.Update(func(tx Tx) error {
+		parties := partyRepo(tx)
+
+		var ok bool
+		party, ok = parties.ForUser(user.UserData.Username)
+		if !ok {
+			return fmt.Errorf("You're not in a party")
+		} else if party.Leader != user.UserData.Username {
+			return fmt.Errorf("Only %v can do that", party.Leader)
+		}
+
+		if err := change(&party); err != nil {
+			return err
+		}
+
+		return parties.Put(&party)
+	})
+
+	return party, err
+}
+
+func (user *dbUser) SetPartyLeader(username string) error {
+	party, err := user.updateParty(func(party *Party) error {
+		if !party.HasMember(username) {
+			return fmt.Errorf("%v isn't in the party", username)
+		}
+
+		party.Leader = username
+		return nil
+	})
+
+	if err == nil {
+		user.world.tellParty(party, fmt.Sprintf("%v now leads the party", username))
+	}
+
+	return err
+}
+
+func (user *dbUser) SetLootMode(mode string) error {
+	party, err := user.updateParty(func(party *Party) error {
+		for _, lootMode := range LootModes {
+			if lootMode == mode {
+				party.LootMode = mode
+				return nil
+			}
+		}
+
+		return fmt.Errorf("No such loot mode %v; pick %v", mode, strings.Join(LootModes, ", "))
+	})
+
+	if err == nil {
+		user.world.tellParty(party, fmt.Sprintf("Loot is now %v", mode))
+	}
+
+	return err
+}
+
+func (user *dbUser) PvP() bool {
+	return user.UserData.PvP
+}
+
+func (user *dbUser) SetPvP(pvp bool) error {
+	user.Reload()
+
+	if since := time.Now().Unix() - user.LastPvP; !pvp && since < pvpCombatSeconds {
+		return fmt.Errorf("You've been fighting; wait %v seconds to turn PvP off", pvpCombatSeconds-since)
+	}
+
+	user.UserData.PvP = pvp
+	user.Save()
+
+	return nil
+}
+
+func (user *dbUser) PvPStats() PvPStats {
+	return user.UserData.PvPStats
+}
+
+func (user *dbUser) ProtectedFor() int64 {
+	if remaining := user.ProtectedUntil - time.Now().Unix(); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
+
+// EnterPvPCombat notes a fight with another player, which ends any respawn protection
+func (user *dbUser) EnterPvPCombat() {
+	user.Reload()
+	user.LastPvP = time.Now().Unix()
+	user.ProtectedUntil = 0
+	user.Save()
+}
+
+func (user *dbUser) RecordPvP(won bool, duel bool) {
+	user.Reload()
+
+	switch {
+	case duel && won:
+		user.UserData.PvPStats.DuelsWon++
+	case duel:
+		user.UserData.PvPStats.DuelsLost++
+	case won:
+		user.UserData.PvPStats.Kills++
+	default:
+		user.UserData.PvPStats.Deaths++
+	}
+
+	user.Save()
+}
+
+func (user *dbUser) Duel() (Duel, bool) {
+	var duel Duel
+	found := false
+
+	user.world.store.View(func(tx Tx) error {
+		duel, found = duelRepo(tx).Get(user.UserData.Username)
+
+		return nil
+	})
+
+	return duel, found && !duel.Expired(time.Now().Unix())
+}
+
+func (user *dbUser) ChallengeToDuel(username string) error {
+	online := false
+	for _, other := range user.world.OnlineUsers() {
+		if other.Username() == username {
+			online = true
+			break
+		}
+	}
+
+	if username == user.UserData.Username {
+		return fmt.Errorf("You can't duel yourself")
+	} else if !online {
+		return fmt.Errorf("%v isn't online", username)
+	}
+
+	now := time.Now().Unix()
+	err := user.world.store.Update(func(tx Tx) error {
+		duels := duelRepo(tx)
+
+		if duel, ok := duels.Get(user.UserData.Username); ok && !duel.Expired(now) {
+			return fmt.Errorf("You're already in a duel with %v", duel.Other(user.UserData.Username))
+		} else if duel, ok := duels.Get(username); ok && !duel.Expired(now) {
+			return fmt.Errorf("%v is already in a duel", username)
+		}
+
+		return duels.Put(&Duel{Challenger: user.UserData.Username, Opponent: username, Started: now})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.world.GetUser(username).Log(LogItem{
+		Message:     fmt.Sprintf("%v challenged you to a duel; /duel accept or /duel decline within %v seconds", user.UserData.Username, duelChallengeSeconds),
+		MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
+
+func (user *dbUser) AcceptDuel() error {
+	var accepted Duel
+
+	now := time.Now().Unix()
+	err := user.world.store.Update(func(tx Tx) error {
+		duels := duelRepo(tx)
+
+		duel, ok := duels.Get(user.UserData.Username)
+		if !ok || duel.Expired(now) || duel.Opponent != user.UserData.Username {
+			return fmt.Errorf("Nobody has challenged you to a duel")
+		} else if duel.Accepted {
+			return fmt.Errorf("You're already dueling %v", duel.Challenger)
+		}
+
+		duel.Accepted = true
+		duel.Started = now
+		accepted = duel
+
+		return duels.Put(&duel)
 	})
 
-	return true
+	if err != nil {
+		return err
+	}
+
+	location := *user.Location()
+	user.world.Chat(LogItem{
+		Message:     fmt.Sprintf("%v and %v are dueling!", accepted.Challenger, accepted.Opponent),
+		MessageType: MESSAGEACTIVITY,
+		Location:    &location})
+
+	return nil
 }
 
-func (user *dbUser) inventoryItem(id string, pull bool) *InventoryItem {
-	itemID, err := uuid.Parse(id)
-	if err != nil {
+// EndDuel calls off a duel or challenge the user is part of, returning what it was
+func (user *dbUser) EndDuel() (Duel, error) {
+	var ended Duel
+
+	err := user.world.store.Update(func(tx Tx) error {
+		duels := duelRepo(tx)
+
+		duel, ok := duels.Get(user.UserData.Username)
+		if !ok || duel.Expired(time.Now().Unix()) {
+			return fmt.Errorf("You're not in a duel")
+		}
+
+		ended = duel
+
+		return duels.Delete(&duel)
+	})
+
+	return ended, err
+}
+
+func (user *dbUser) BankItems() []*InventoryItem {
+	var items []*InventoryItem
+
+	user.world.store.View(func(tx Tx) error {
+		items = bankRepo(tx).List([]byte(user.UserData.Username))
+
 		return nil
+	})
+
+	return items
+}
+
+func (user *dbUser) BankCapacity() int {
+	return bankCapacity(user.stored().BankUpgrades)
+}
+
+func (user *dbUser) BankUpgradePrice() uint64 {
+	return bankUpgradePrice(user.stored().BankUpgrades)
+}
+
+func (user *dbUser) Deposit(itemID string) error {
+	if err := atBank(user); err != nil {
+		return err
 	}
 
-	idBytes, err := itemID.MarshalBinary()
+	capacity := user.BankCapacity()
+	owner := []byte(user.UserData.Username)
+
+	return user.world.store.Update(func(tx Tx) error {
+		items, bank := userItemRepo(tx), bankRepo(tx)
+
+		item := items.Get(owner, itemID)
+		if item == nil {
+			return fmt.Errorf("You aren't carrying that")
+		} else if len(bank.List(owner)) >= capacity {
+			return fmt.Errorf("Your bank is full (%v items)", capacity)
+		}
+
+		if err := items.Delete(owner, itemID); err != nil {
+			return err
+		}
+
+		return bank.Put(owner, item)
+	})
+}
+
+func (user *dbUser) Withdraw(itemID string) error {
+	if err := atBank(user); err != nil {
+		return err
+	}
+
+	owner := []byte(user.UserData.Username)
+
+	return user.world.store.Update(func(tx Tx) error {
+		items, bank := userItemRepo(tx), bankRepo(tx)
+
+		item := bank.Get(owner, itemID)
+		if item == nil {
+			return fmt.Errorf("That isn't in your bank")
+		}
+
+		if err := bank.Delete(owner, itemID); err != nil {
+			return err
+		}
+
+		return items.Put(owner, item)
+	})
+}
+
+func (user *dbUser) UpgradeBank() error {
+	if err := atBank(user); err != nil {
+		return err
+	}
+
+	var userData UserData
+
+	err := user.world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+
+		var found bool
+		if userData, found = users.Get(user.UserData.Username); !found {
+			userData = user.UserData
+		}
+
+		price := bankUpgradePrice(userData.BankUpgrades)
+		if userData.Coins < price {
+			return fmt.Errorf("A bigger bank costs %v; you have %v", coinString(price), userData.Coins)
+		}
+
+		userData.Coins -= price
+		userData.BankUpgrades++
+
+		return users.Put(&userData)
+	})
+
 	if err != nil {
-		return nil
+		return err
 	}
 
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, idBytes)
+	user.UserData = userData
+	user.Log(LogItem{Message: fmt.Sprintf("Your bank now holds %v items", bankCapacity(userData.BankUpgrades)), MessageType: MESSAGEACTIVITY})
+
+	return nil
+}
 
+func (user *dbUser) Trade() (Trade, bool) {
+	var trade Trade
 	found := false
-	var inventoryItem InventoryItem
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userinventory"))
 
-		itemBytes := bucket.Get(keyBuf.Bytes())
+	user.world.store.View(func(tx Tx) error {
+		trade, found = tradeRepo(tx).Get(user.UserData.Username)
 
-		if itemBytes != nil {
-			if MSGUnpack(itemBytes, &inventoryItem) == nil {
-				inventoryItem.ID = id
-				found = true
-			}
+		return nil
+	})
+
+	return trade, found && !trade.Expired(time.Now().Unix())
+}
+
+func (user *dbUser) RequestTrade(username string) error {
+	var other User
+	for _, online := range user.world.OnlineUsers() {
+		if online.Username() == username {
+			other = online
+			break
 		}
+	}
+
+	if other == nil {
+		return fmt.Errorf("%v isn't online", username)
+	} else if err := canTrade(user, other); err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	err := user.world.store.Update(func(tx Tx) error {
+		trades := tradeRepo(tx)
 
-		if pull && found {
-			bucket.Delete(keyBuf.Bytes())
+		if trade, ok := trades.Get(user.UserData.Username); ok && !trade.Expired(now) {
+			return fmt.Errorf("You're already trading with %v", trade.Other(user.UserData.Username))
+		} else if trade, ok := trades.Get(username); ok && !trade.Expired(now) {
+			return fmt.Errorf("%v is already trading", username)
 		}
 
-		return nil
+		return trades.Put(&Trade{Requester: user.UserData.Username, Partner: username, Updated: now})
 	})
 
-	if found {
-		return &inventoryItem
+	if err != nil {
+		return err
 	}
+
+	other.Log(LogItem{
+		Message:     fmt.Sprintf("%v wants to trade; /trade accept or /trade cancel within %v seconds", user.UserData.Username, tradeRequestSeconds),
+		MessageType: MESSAGEACTIVITY})
+
 	return nil
 }
 
-func (user *dbUser) InventoryItem(id string) *InventoryItem {
-	return user.inventoryItem(id, false)
-}
+func (user *dbUser) AcceptTrade() error {
+	var accepted Trade
 
-func (user *dbUser) PullInventoryItem(id string) *InventoryItem {
-	return user.inventoryItem(id, true)
+	if trade, ok := user.Trade(); ok && trade.Partner == user.UserData.Username {
+		if err := canTrade(user, user.world.GetUser(trade.Requester)); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().Unix()
+	err := user.world.store.Update(func(tx Tx) error {
+		trades := tradeRepo(tx)
+
+		trade, ok := trades.Get(user.UserData.Username)
+		if !ok || trade.Expired(now) || trade.Partner != user.UserData.Username {
+			return fmt.Errorf("Nobody has asked to trade with you")
+		} else if trade.Accepted {
+			return fmt.Errorf("You're already trading with %v", trade.Requester)
+		}
+
+		trade.Accepted = true
+		trade.Updated = now
+		accepted = trade
+
+		return trades.Put(&trade)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.world.GetUser(accepted.Requester).Log(LogItem{
+		Message:     fmt.Sprintf("%v agreed to trade", user.UserData.Username),
+		MessageType: MESSAGEACTIVITY})
+
+	return nil
 }
 
-func (user *dbUser) pullInventoryItemByName(name string) *InventoryItem {
-	for _, item := range user.InventoryItems() {
-		if item.Name == name {
-			return user.PullInventoryItem(item.ID)
+// changeOffer changes what the user has put up in their trade, letting the other side know
+func (user *dbUser) changeOffer(change func(offer *TradeOffer, items itemRepository, userData UserData) error) error {
+	var changed Trade
+
+	now := time.Now().Unix()
+	err := user.world.store.Update(func(tx Tx) error {
+		trades := tradeRepo(tx)
+
+		trade, ok := trades.Get(user.UserData.Username)
+		if !ok || trade.Expired(now) {
+			return fmt.Errorf("You're not trading with anyone")
+		} else if !trade.Accepted {
+			return fmt.Errorf("%v hasn't agreed to trade yet", trade.Other(user.UserData.Username))
+		}
+
+		userData, found := userRepo(tx).Get(user.UserData.Username)
+		if !found {
+			userData = user.UserData
 		}
+
+		offer := trade.Offer(user.UserData.Username)
+		if err := change(&offer, userItemRepo(tx), userData); err != nil {
+			return err
+		}
+
+		trade.setOffer(user.UserData.Username, offer, now)
+		changed = trade
+
+		return trades.Put(&trade)
+	})
+
+	if err != nil {
+		return err
 	}
 
+	other := user.world.GetUser(changed.Other(user.UserData.Username))
+	other.Log(LogItem{
+		Message:     fmt.Sprintf("%v now offers %v", user.UserData.Username, describeOffer(changed.Offer(user.UserData.Username), user.InventoryItems())),
+		MessageType: MESSAGEACTIVITY})
+
 	return nil
 }
 
-func (user *dbUser) Equip(slot string, item *InventoryItem) (*InventoryItem, error) {
-	if !user.CanEquip(slot, item) {
-		return item, fmt.Errorf("Can't equip item in slot %v", slot)
+func (user *dbUser) AddToTrade(itemID string) error {
+	return user.changeOffer(func(offer *TradeOffer, items itemRepository, userData UserData) error {
+		if items.Get([]byte(userData.Username), itemID) == nil {
+			return fmt.Errorf("You aren't carrying that")
+		} else if offer.Offering(itemID) {
+			return fmt.Errorf("You've already offered that")
+		}
+
+		offer.Items = append(offer.Items, itemID)
+
+		return nil
+	})
+}
+
+func (user *dbUser) RemoveFromTrade(itemID string) error {
+	return user.changeOffer(func(offer *TradeOffer, items itemRepository, userData UserData) error {
+		for index, id := range offer.Items {
+			if id == itemID {
+				offer.Items = append(offer.Items[:index], offer.Items[index+1:]...)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("You haven't offered that")
+	})
+}
+
+func (user *dbUser) OfferCoins(coins uint64) error {
+	return user.changeOffer(func(offer *TradeOffer, items itemRepository, userData UserData) error {
+		if coins > userData.Coins {
+			return fmt.Errorf("You only have %v", coinString(userData.Coins))
+		}
+
+		offer.Coins = coins
+
+		return nil
+	})
+}
+
+// ConfirmTrade agrees to the trade as it stands. Once both sides have, the items and coin change
+// hands.
+func (user *dbUser) ConfirmTrade() error {
+	var confirmed Trade
+	received := make(map[string][]string)
+	done := false
+
+	now := time.Now().Unix()
+	err := user.world.store.Update(func(tx Tx) error {
+		trades := tradeRepo(tx)
+
+		trade, ok := trades.Get(user.UserData.Username)
+		if !ok || trade.Expired(now) {
+			return fmt.Errorf("You're not trading with anyone")
+		} else if !trade.Accepted {
+			return fmt.Errorf("%v hasn't agreed to trade yet", trade.Other(user.UserData.Username))
+		}
+
+		offer := trade.Offer(user.UserData.Username)
+		offer.Confirmed = true
+		if trade.Offers == nil {
+			trade.Offers = make(map[string]TradeOffer)
+		}
+		trade.Offers[user.UserData.Username] = offer
+		trade.Updated = now
+		confirmed = trade
+
+		if !trade.Offer(trade.Other(user.UserData.Username)).Confirmed {
+			return trades.Put(&trade)
+		}
+
+		users := userRepo(tx)
+		items := userItemRepo(tx)
+		parties := make(map[string]UserData)
+
+		for _, username := range []string{trade.Requester, trade.Partner} {
+			userData, found := users.Get(username)
+			if !found {
+				return fmt.Errorf("User %v does not exist", username)
+			}
+			parties[username] = userData
+		}
+
+		if parties[trade.Requester].X != parties[trade.Partner].X || parties[trade.Requester].Y != parties[trade.Partner].Y {
+			return fmt.Errorf("You have to be standing together to trade")
+		}
+
+		for _, from := range []string{trade.Requester, trade.Partner} {
+			to := trade.Other(from)
+			offer := trade.Offer(from)
+
+			for _, itemID := range offer.Items {
+				item := items.Get([]byte(from), itemID)
+				if item == nil {
+					return fmt.Errorf("%v no longer has something they offered", from)
+				}
+
+				if err := items.Delete([]byte(from), itemID); err != nil {
+					return err
+				}
+				if err := items.Put([]byte(to), item); err != nil {
+					return err
+				}
+				received[to] = append(received[to], item.Name)
+			}
+
+			giver, taker := parties[from], parties[to]
+			if giver.Coins < offer.Coins {
+				return fmt.Errorf("%v no longer has the coin they offered", from)
+			}
+			giver.Coins -= offer.Coins
+			taker.Coins += offer.Coins
+			parties[from], parties[to] = giver, taker
+		}
+
+		for _, userData := range parties {
+			if err := users.Put(&userData); err != nil {
+				return err
+			}
+		}
+
+		done = true
+
+		return trades.Delete(&trade)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	oldItem := user.EquipmentSlotItem(slot)
+	other := user.world.GetUser(confirmed.Other(user.UserData.Username))
+	if !done {
+		other.Log(LogItem{Message: fmt.Sprintf("%v confirmed the trade", user.UserData.Username), MessageType: MESSAGEACTIVITY})
+		return nil
+	}
 
-	inventoryItem := *item
+	for _, trader := range []User{user, other} {
+		trader.Reload()
 
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, []byte(slot))
-	var serr error
-	var dataBytes []byte
+		got := confirmed.Offer(confirmed.Other(trader.Username()))
 
-	if item != nil {
-		dataBytes, serr = MSGPack(inventoryItem)
+		gotList := make([]string, 0, 2)
+		if len(received[trader.Username()]) > 0 {
+			gotList = append(gotList, itemList(received[trader.Username()]))
+		}
+		if got.Coins > 0 {
+			gotList = append(gotList, coinString(got.Coins))
+		}
+		if len(gotList) == 0 {
+			gotList = append(gotList, "nothing")
+		}
+
+		trader.Log(LogItem{
+			Message:     fmt.Sprintf("Traded with %v; you got %v", confirmed.Other(trader.Username()), strings.Join(gotList, ", ")),
+			MessageType: MESSAGEACTIVITY})
+
+		for _, name := range received[trader.Username()] {
+			trader.QuestEvent(QUESTCOLLECT, name)
+		}
 	}
 
-	if serr != nil {
-		return item, serr
+	return nil
+}
+
+// CancelTrade calls off a trade or request the user is part of, returning what it was
+func (user *dbUser) CancelTrade() (Trade, error) {
+	var cancelled Trade
+
+	err := user.world.store.Update(func(tx Tx) error {
+		trades := tradeRepo(tx)
+
+		trade, ok := trades.Get(user.UserData.Username)
+		if !ok || trade.Expired(time.Now().Unix()) {
+			return fmt.Errorf("You're not trading with anyone")
+		}
+
+		cancelled = trade
+
+		return trades.Delete(&trade)
+	})
+
+	return cancelled, err
+}
+
+func (user *dbUser) Equip(slot string, item *InventoryItem) (*InventoryItem, error) {
+	if !user.CanEquip(slot, item) {
+		return item, fmt.Errorf("Can't equip item in slot %v", slot)
 	}
 
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userequipment"))
+	oldItem := user.EquipmentSlotItem(slot)
 
-		return bucket.Put(keyBuf.Bytes(), dataBytes)
+	err := user.world.store.Update(func(tx Tx) error {
+		return equipmentRepo(tx).Put(user.UserData.Username, slot, item)
 	})
 
+	if err != nil {
+		return item, err
+	}
+
 	return oldItem, nil
 }
 
@@ -2325,31 +3917,15 @@ func (user *dbUser) Equipped() []SlottedInventoryItem {
 }
 
 func (user *dbUser) EquipmentSlotItem(slotName string) *InventoryItem {
-	keyBuf := new(bytes.Buffer)
-	binary.Write(keyBuf, binary.BigEndian, []byte(user.UserData.Username))
-	binary.Write(keyBuf, binary.BigEndian, byte(0))
-	binary.Write(keyBuf, binary.BigEndian, []byte(slotName))
-
-	found := false
-	var inventoryItem InventoryItem
-	user.world.database.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("userequipment"))
-
-		itemBytes := bucket.Get(keyBuf.Bytes())
+	var inventoryItem *InventoryItem
 
-		if itemBytes != nil {
-			if MSGUnpack(itemBytes, &inventoryItem) == nil {
-				found = true
-			}
-		}
+	user.world.store.View(func(tx Tx) error {
+		inventoryItem = equipmentRepo(tx).Get(user.UserData.Username, slotName)
 
 		return nil
 	})
 
-	if found {
-		return &inventoryItem
-	}
-	return nil
+	return inventoryItem
 }
 
 func (user *dbUser) EquipSlots() []string {
@@ -2362,20 +3938,6 @@ func (user *dbUser) EquipSlots() []string {
 	return slots
 }
 
-func (user *dbUser) SSHKeysEmpty() bool {
-	return len(user.UserData.PublicKeys) == 0
-}
-
-func (user *dbUser) ValidateSSHKey(sshKey string) bool {
-	val, ok := user.UserData.PublicKeys[sshKey]
-	return val && ok
-}
-
-func (user *dbUser) AddSSHKey(sshKey string) {
-	user.UserData.PublicKeys[sshKey] = true
-	user.Save()
-}
-
 func getUserFromDB(world *dbWorld, username string) User {
 	user := dbUser{UserData: UserData{
 		Username: username},
@@ -2385,46 +3947,3 @@ func getUserFromDB(world *dbWorld, username string) User {
 
 	return &user
 }
-
-func newPlaceNameInDB(db *bolt.DB) (uint64, string) {
-	var id uint64
-	placeName := randomPlaceName()
-
-	db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placenames"))
-
-		var err error
-		id, err = bucket.NextSequence()
-
-		if err != nil {
-			return err
-		}
-
-		b := make([]byte, 8)
-		binary.BigEndian.PutUint64(b, uint64(id))
-		err = bucket.Put(b, []byte(placeName))
-
-		return err
-	})
-
-	return id, placeName
-}
-
-func getPlaceNameByIDFromDB(id uint64, db *bolt.DB) string {
-	placeName := "Delaware"
-
-	db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("placenames"))
-		b := make([]byte, 8)
-		binary.BigEndian.PutUint64(b, uint64(id))
-		record := bucket.Get(b)
-
-		if record != nil {
-			placeName = string(record)
-		}
-
-		return nil
-	})
-
-	return placeName
-}
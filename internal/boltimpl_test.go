@@ -0,0 +1,131 @@
+package mud
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttackKillsCreature(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestCharacter(t, world, "hunter")
+	cell := world.CellAtPoint(*user.Location())
+
+	// Creatures have to be there before the user is, or the user's cell has already been cached
+	cell.AddStockCreature("rat")
+	user.MarkActive()
+	creatures := cell.GetCreatures()
+	if len(creatures) != 1 {
+		t.Fatalf("cell has %v creatures, want 1", len(creatures))
+	}
+	rat := creatures[0]
+
+	world.Attack(user, rat, &Attack{Name: "Smite", Accuracy: 100, AP: 1000, MP: 1000, RP: 1000})
+
+	for _, creature := range cell.GetCreatures() {
+		if creature.ID == rat.ID && creature.HP > 0 {
+			t.Fatalf("rat survived with %v HP", creature.HP)
+		}
+	}
+
+	user.Reload()
+	if user.XP() == 0 {
+		t.Fatal("no XP for the kill")
+	}
+}
+
+func TestUse(t *testing.T) {
+	tests := []struct {
+		item    string
+		setup   func(*dbUser)
+		wantErr bool
+		check   func(user *dbUser, spawn Point) bool
+	}{
+		{"Healing Potion", func(user *dbUser) { user.SetHP(1) }, false, func(user *dbUser, spawn Point) bool {
+			return user.HP() > 1
+		}},
+		{"Potion of Regeneration", func(user *dbUser) {}, false, func(user *dbUser, spawn Point) bool {
+			return len(user.Effects()) == 1 && user.Effects()[0].ID == "regen"
+		}},
+		{"Scroll of Recall", func(user *dbUser) { user.X += 5 }, false, func(user *dbUser, spawn Point) bool {
+			return *user.Location() == spawn
+		}},
+		{"Healing Potion while dead", func(user *dbUser) { user.SetHP(0) }, true, func(user *dbUser, spawn Point) bool {
+			return user.HP() == 0
+		}},
+		{"Wooden Shield", func(user *dbUser) {}, true, func(user *dbUser, spawn Point) bool {
+			return true
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.item, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "drinker").(*dbUser)
+			spawn := *user.Location()
+			item := giveTestItem(t, user, strings.TrimSuffix(test.item, " while dead"))
+
+			user.Reload()
+			test.setup(user)
+			user.Save()
+
+			if err := user.Use(item); (err != nil) != test.wantErr {
+				t.Fatalf("Use(%v) = %v, want error %v", test.item, err, test.wantErr)
+			}
+
+			user.Reload()
+			if !test.check(user, spawn) {
+				t.Fatalf("Use(%v) left HP %v, effects %+v at %v", test.item, user.HP(), user.Effects(), *user.Location())
+			}
+			if kept := user.InventoryItem(item.ID) != nil; kept != test.wantErr {
+				t.Fatalf("still carrying %v = %v after using it", test.item, kept)
+			}
+		})
+	}
+}
+
+func TestChargePoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(*UserData)
+		wantAP    uint64
+		wantLevel uint64
+		wantHP    uint64
+		wantSpawn bool
+	}{
+		{"recovers a point", func(u *UserData) { u.AP = 4 }, 5, 1, 10, false},
+		{"levels up", func(u *UserData) { u.XP = 30 }, 10, 2, 10, false},
+		{"not enough to level", func(u *UserData) { u.XP = 29 }, 10, 1, 10, false},
+		{"respawns once rested", func(u *UserData) { u.HP = 0 }, 10, 1, 10, true},
+		{"stays down while tired", func(u *UserData) { u.HP, u.AP = 0, 9 }, 10, 1, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "rester")
+
+			user.Reload()
+			userData := &user.(*dbUser).UserData
+			userData.Level, userData.XP = 1, 0
+			userData.HP, userData.MaxHP = 10, 10
+			userData.AP, userData.RP, userData.MP = 10, 10, 10
+			userData.MaxAP, userData.MaxRP, userData.MaxMP = 10, 10, 10
+			userData.X, userData.Y = userData.SpawnX+5, userData.SpawnY
+			test.setup(userData)
+			user.Save()
+
+			user.ChargePoints()
+
+			stored := world.GetUser("rester").(*dbUser).UserData
+			if stored.AP != test.wantAP || stored.Level != test.wantLevel || stored.HP != test.wantHP {
+				t.Fatalf("AP/level/HP = %v/%v/%v, want %v/%v/%v", stored.AP, stored.Level, stored.HP, test.wantAP, test.wantLevel, test.wantHP)
+			}
+			if atSpawn := stored.X == stored.SpawnX; atSpawn != test.wantSpawn {
+				t.Fatalf("back at spawn: %v, want %v", atSpawn, test.wantSpawn)
+			}
+			if user.HP() != stored.HP {
+				t.Fatalf("user in hand has %v HP, stored %v", user.HP(), stored.HP)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package mud
+
+import (
+	"bytes"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type boltStore struct {
+	database *bolt.DB
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+type boltBucket struct {
+	bucket *bolt.Bucket
+}
+
+// OpenBoltStore opens (or creates) a bbolt file as a Store
+func OpenBoltStore(filename string) (Store, error) {
+	db, err := bolt.Open(filename, 0600, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStore{database: db}, nil
+}
+
+func (s *boltStore) View(fn func(Tx) error) error {
+	return s.database.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *boltStore) Update(fn func(Tx) error) error {
+	return s.database.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.database.Close()
+}
+
+func (t *boltTx) Bucket(name string) Bucket {
+	bucket := t.tx.Bucket([]byte(name))
+
+	if bucket == nil {
+		return nil
+	}
+
+	return &boltBucket{bucket: bucket}
+}
+
+func (t *boltTx) CreateBucketIfNotExists(name string) (Bucket, error) {
+	bucket, err := t.tx.CreateBucketIfNotExists([]byte(name))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltBucket{bucket: bucket}, nil
+}
+
+func (b *boltBucket) Get(key []byte) []byte {
+	return b.bucket.Get(key)
+}
+
+func (b *boltBucket) Put(key, value []byte) error {
+	return b.bucket.Put(key, value)
+}
+
+func (b *boltBucket) Delete(key []byte) error {
+	return b.bucket.Delete(key)
+}
+
+func (b *boltBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.bucket.ForEach(fn)
+}
+
+func (b *boltBucket) Range(min, max []byte, visitor func(k, v []byte) error) error {
+	cur := b.bucket.Cursor()
+
+	for k, v := cur.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = cur.Next() {
+		if err := visitor(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *boltBucket) NextSequence() (uint64, error) {
+	return b.bucket.NextSequence()
+}
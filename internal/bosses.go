@@ -0,0 +1,148 @@
+package mud
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// BossPhase is one stage of a boss fight. A phase starts once the boss's HP falls to Below
+// its max, and swaps in its own attacks and bonuses.
+type BossPhase struct {
+	Name     string   `json:""`
+	Below    float32  `json:""`           // 0-1.0 fraction of max HP at or under which the phase starts; the first phase should be 1
+	Attacks  []Attack `json:",omitempty"` // Replaces the creature's attacks for the phase; empty keeps the last phase's
+	Bonuses  string   `json:",omitempty"` // Bonus string added to every attack made during the phase
+	Summon   []string `json:",omitempty"` // IDs of creatures from the bestiary that appear as the phase starts
+	Announce string   `json:",omitempty"` // What the boss says as the phase starts
+}
+
+// BossInfo makes a creature type a boss: it fights in phases and has drops of its own that
+// the whole server hears about
+type BossInfo struct {
+	Phases      []BossPhase `json:""`
+	UniqueDrops []ItemDrop  `json:",omitempty"` // Rolled on top of ItemDrops and announced to everyone online
+}
+
+// IsBoss checks whether a creature fights in phases
+func (creature *Creature) IsBoss() bool {
+	return creature.CreatureTypeStruct.Boss != nil && len(creature.CreatureTypeStruct.Boss.Phases) > 0
+}
+
+// phaseFor is the deepest phase a boss has reached at a given HP
+func (creature *Creature) phaseFor(hp uint64) int {
+	phase := 0
+
+	if !creature.IsBoss() || creature.CreatureTypeStruct.MaxHP == 0 {
+		return phase
+	}
+
+	health := float32(hp) / float32(creature.CreatureTypeStruct.MaxHP)
+	for index, bossPhase := range creature.CreatureTypeStruct.Boss.Phases {
+		if health <= bossPhase.Below {
+			phase = index
+		}
+	}
+
+	return phase
+}
+
+// attacks is what the creature can attack with right now; a boss uses the attacks of the
+// phase it's in, or of the last phase before it that had any
+func (creature *Creature) attacks() []Attack {
+	attacks := creature.CreatureTypeStruct.Attacks
+
+	if creature.IsBoss() {
+		for index, phase := range creature.CreatureTypeStruct.Boss.Phases {
+			if index > creature.Phase {
+				break
+			} else if len(phase.Attacks) > 0 {
+				attacks = phase.Attacks
+			}
+		}
+	}
+
+	return attacks
+}
+
+// PickAttack chooses one of the creature's attacks at random with its bonuses applied, along
+// with those of the boss phase it's in
+func (creature *Creature) PickAttack(rng *rand.Rand) *Attack {
+	attacks := creature.attacks()
+
+	if len(attacks) == 0 {
+		return nil
+	}
+
+	attack := attacks[rng.Intn(len(attacks))]
+
+	if creature.IsBoss() && creature.Phase < len(creature.CreatureTypeStruct.Boss.Phases) {
+		if bonuses := creature.CreatureTypeStruct.Boss.Phases[creature.Phase].Bonuses; bonuses != "" {
+			attack.Bonuses = strings.Trim(attack.Bonuses+";"+bonuses, ";")
+		}
+	}
+
+	attack = attack.ApplyBonuses(creature)
+
+	return &attack
+}
+
+// advanceBossPhase moves a boss on to the phase its HP has fallen to, announcing each phase it
+// passes and summoning its minions. Bosses never go back to an earlier phase.
+func (w *dbWorld) advanceBossPhase(creature *Creature) {
+	if !creature.IsBoss() || creature.HP == 0 {
+		return
+	}
+
+	reached := creature.phaseFor(creature.HP)
+	if reached <= creature.Phase {
+		return
+	}
+
+	location := creature.Location()
+	cell := w.CellAtPoint(location)
+
+	for creature.Phase < reached {
+		creature.Phase++
+		phase := creature.CreatureTypeStruct.Boss.Phases[creature.Phase]
+
+		announcement := phase.Announce
+		if announcement == "" && phase.Name != "" {
+			announcement = fmt.Sprintf("enters its %v phase!", phase.Name)
+		}
+		if announcement != "" {
+			w.Chat(LogItem{Author: creature.CreatureTypeStruct.Name, Message: announcement, MessageType: MESSAGEACTIVITY, Location: &location})
+		}
+
+		for _, minion := range phase.Summon {
+			if _, ok := CreatureTypes[minion]; ok {
+				cell.AddStockCreature(minion)
+			}
+		}
+	}
+}
+
+// lairBoss is the ID of the boss whose lair a cell is in, if it's in one. A biome makes its
+// cells a lair with a "boss" algorithm parameter naming a boss in the bestiary.
+func lairBoss(cellInfo *CellInfo) string {
+	if cellInfo == nil {
+		return ""
+	}
+
+	boss := getStringSetting(cellInfo.BiomeData.AlgorithmParameters, "boss", "")
+	if creatureType, ok := CreatureTypes[boss]; !ok || creatureType.Boss == nil {
+		return ""
+	}
+
+	return boss
+}
+
+// announceUniqueDrop tells everyone online that a boss dropped one of its unique items
+func (w *dbWorld) announceUniqueDrop(creature *Creature, looter string, item InventoryItem) {
+	message := fmt.Sprintf("%v has fallen, leaving behind %v!", creature.CreatureTypeStruct.Name, item.Name)
+	if looter != "" {
+		message = fmt.Sprintf("%v has fallen, and %v claimed %v!", creature.CreatureTypeStruct.Name, looter, item.Name)
+	}
+
+	w.Chat(LogItem{Message: message, MessageType: MESSAGESYSTEM})
+}
@@ -0,0 +1,81 @@
+package mud
+
+import "testing"
+
+// testBoss is a boss with three phases: biting, then nothing new at half health, then
+// summoning rats and breathing fire under a quarter
+func testBoss() *Creature {
+	return &Creature{
+		ID:           "boss",
+		CreatureType: "test-boss",
+		HP:           100,
+		CreatureTypeStruct: CreatureType{
+			Name:    "Test Boss",
+			MaxHP:   100,
+			Hostile: true,
+			Attacks: []Attack{{Name: "Claw"}},
+			Boss: &BossInfo{Phases: []BossPhase{
+				{Name: "calm", Below: 1, Attacks: []Attack{{Name: "Bite"}}},
+				{Name: "angry", Below: 0.5},
+				{Name: "desperate", Below: 0.25, Attacks: []Attack{{Name: "Fire"}}, Summon: []string{"rat", "rat"}},
+			}},
+		},
+	}
+}
+
+func TestBossPhases(t *testing.T) {
+	tests := []struct {
+		hp         uint64
+		wantPhase  int
+		wantAttack string
+	}{
+		{100, 0, "Bite"},
+		{51, 0, "Bite"},
+		{50, 1, "Bite"}, // No attacks of its own, so it keeps the last phase's
+		{25, 2, "Fire"},
+		{1, 2, "Fire"},
+	}
+
+	for _, test := range tests {
+		boss := testBoss()
+		boss.Phase = boss.phaseFor(test.hp)
+
+		if boss.Phase != test.wantPhase {
+			t.Fatalf("phaseFor(%v) = %v, want %v", test.hp, boss.Phase, test.wantPhase)
+		}
+		if attacks := boss.attacks(); len(attacks) != 1 || attacks[0].Name != test.wantAttack {
+			t.Fatalf("at %v HP attacks = %+v, want %v", test.hp, attacks, test.wantAttack)
+		}
+	}
+
+	creature := testBoss()
+	creature.CreatureTypeStruct.Boss = nil
+	if creature.IsBoss() || creature.phaseFor(1) != 0 || creature.attacks()[0].Name != "Claw" {
+		t.Fatal("a creature without phases fights like a boss")
+	}
+}
+
+func TestAdvanceBossPhase(t *testing.T) {
+	world := newTestWorld(t)
+	location := Point{X: 10, Y: 10}
+	world.CellAtPoint(location).SetCellInfo(&CellInfo{TerrainID: "clearing-grass"})
+
+	boss := testBoss()
+	boss.X, boss.Y = location.X, location.Y
+
+	boss.HP = 20
+	world.advanceBossPhase(boss)
+	if boss.Phase != 2 {
+		t.Fatalf("boss is in phase %v at 20 HP, want 2", boss.Phase)
+	}
+	if creatures := world.CellAtPoint(location).GetCreatures(); len(creatures) != 2 {
+		t.Fatalf("boss summoned %v creatures, want 2", len(creatures))
+	}
+
+	// Healing doesn't take it back to an earlier phase
+	boss.HP = 100
+	world.advanceBossPhase(boss)
+	if boss.Phase != 2 {
+		t.Fatalf("boss went back to phase %v after healing", boss.Phase)
+	}
+}
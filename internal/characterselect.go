@@ -0,0 +1,189 @@
+package mud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/ahmetb/go-cursor"
+	"github.com/gliderlabs/ssh"
+	"github.com/mgutz/ansi"
+)
+
+type selectState int
+
+const (
+	selectPICK selectState = iota
+	selectNAME
+	selectDELETE
+	selectCONFIRM
+)
+
+// characterLine describes one of an account's characters on the character select screen
+func characterLine(world World, index int, name string) string {
+	user := world.GetUser(name)
+
+	if !user.IsInitialized() {
+		return fmt.Sprintf("%v: %v, not set up yet", index+1, name)
+	}
+
+	return fmt.Sprintf("%v: %v, level %v %v", index+1, name, user.Level(), user.Title())
+}
+
+// renderCharacterSelect draws the character select screen for whatever step the account is on
+func renderCharacterSelect(session ssh.Session, world World, account Account, state selectState, name, target, message string) {
+	header := ansi.ColorFunc("white+b:black")
+	selectedf := ansi.ColorFunc("black:white")
+	messagef := ansi.ColorFunc("230+b:black")
+
+	io.WriteString(session, cursor.ClearEntireScreen()+cursor.MoveUpperLeft(1))
+	io.WriteString(session, fmt.Sprintf("Welcome back, %v.\n\n", account.Name))
+
+	io.WriteString(session, header(fmt.Sprintf("Characters (%v of %v):", len(account.Characters), maxCharacters)))
+	io.WriteString(session, "\n")
+	if len(account.Characters) == 0 {
+		io.WriteString(session, "    None yet\n")
+	}
+	for index, character := range account.Characters {
+		line := characterLine(world, index, character)
+		if character == account.LastPlayed {
+			line = selectedf(line)
+		}
+		io.WriteString(session, "    "+line+"\n")
+	}
+
+	io.WriteString(session, "\n")
+	if message != "" {
+		io.WriteString(session, messagef(message))
+	}
+	io.WriteString(session, "\n\n")
+
+	switch state {
+	case selectPICK:
+		io.WriteString(session, "Press a number to play that character, N for a new one, D to delete one, or escape to log off.")
+	case selectNAME:
+		io.WriteString(session, "Name your new character: "+name+"\n\n")
+		io.WriteString(session, "Press enter to create them, or escape to go back.")
+	case selectDELETE:
+		io.WriteString(session, "Press the number of the character to delete, or escape to go back.")
+	case selectCONFIRM:
+		io.WriteString(session, fmt.Sprintf("Delete %v for good, with everything they own? Press Y to delete them.", target))
+	}
+}
+
+// selectCharacter runs the character select screen, where an account creates, deletes and picks
+// which of its characters to play. It returns nil if the session ends first.
+func selectCharacter(ctx context.Context, cancel context.CancelFunc, done <-chan struct{}, session ssh.Session, world World, accountName string, stringInput chan inputEvent) User {
+	account, _ := world.Account(accountName)
+	state := selectPICK
+	name, target, message := "", "", ""
+
+	if len(account.Characters) == 0 {
+		state = selectNAME
+		message = "You don't have any characters yet."
+	}
+
+	renderCharacterSelect(session, world, account, state, name, target, message)
+
+	for {
+		select {
+		case inputString := <-stringInput:
+			if inputString.err != nil {
+				session.Close()
+				return nil
+			}
+
+			key := inputString.inputString
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 1 || index > len(account.Characters) {
+				index = 0
+			}
+			message = ""
+
+			switch state {
+			case selectPICK:
+				switch {
+				case index > 0:
+					user, err := world.PlayCharacter(account.Name, account.Characters[index-1])
+					if err == nil {
+						return user
+					}
+					message = err.Error()
+				case key == "n" || key == "N":
+					if len(account.Characters) >= maxCharacters {
+						message = fmt.Sprintf("You already have %v characters. Delete one to make room.", maxCharacters)
+					} else {
+						state, name = selectNAME, ""
+					}
+				case key == "d" || key == "D":
+					if len(account.Characters) == 0 {
+						message = "You don't have any characters to delete."
+					} else {
+						state = selectDELETE
+					}
+				case key == "ESCAPE":
+					session.Close()
+					return nil
+				}
+
+			case selectNAME:
+				switch key {
+				case "ENTER":
+					if err := world.CreateCharacter(account.Name, name); err != nil {
+						message = err.Error()
+					} else if user, err := world.PlayCharacter(account.Name, name); err != nil {
+						message = err.Error()
+					} else {
+						return user
+					}
+				case "ESCAPE":
+					state = selectPICK
+				case "BACKSPACE":
+					if len(name) > 0 {
+						_, size := utf8.DecodeLastRuneInString(name)
+						name = name[:len(name)-size]
+					}
+				default:
+					r, size := utf8.DecodeRuneInString(key)
+					if size == len(key) && characterNameRune(r) && utf8.RuneCountInString(name) < maxCharacterName {
+						name += key
+					}
+				}
+
+			case selectDELETE:
+				if index > 0 {
+					state, target = selectCONFIRM, account.Characters[index-1]
+				} else if key == "ESCAPE" {
+					state = selectPICK
+				}
+
+			case selectCONFIRM:
+				if key == "y" || key == "Y" {
+					if err := world.DeleteCharacter(account.Name, target); err != nil {
+						message = err.Error()
+					} else {
+						log.Printf("%v deleted %v", account.Name, target)
+						message = fmt.Sprintf("Deleted %v.", target)
+					}
+				} else {
+					message = fmt.Sprintf("Kept %v.", target)
+				}
+				state = selectPICK
+			}
+
+			account, _ = world.Account(accountName)
+			renderCharacterSelect(session, world, account, state, name, target, message)
+
+		case <-ctx.Done():
+			session.Close()
+			return nil
+		case <-done:
+			log.Printf("Disconnected character select %v", session.RemoteAddr())
+			session.Close()
+			return nil
+		}
+	}
+}
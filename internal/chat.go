@@ -0,0 +1,195 @@
+package mud
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Chat channels
+const (
+	CHANNELGLOBAL  = "global"
+	CHANNELSAY     = "say"
+	CHANNELSHOUT   = "shout"
+	CHANNELREGION  = "region"
+	CHANNELPARTY   = "party"
+	CHANNELWHISPER = "whisper"
+	CHANNELADMIN   = "admin"
+)
+
+// ChatChannel describes who hears a channel and how it looks in the log
+type ChatChannel struct {
+	Name     string
+	Prompt   string // Shown in front of the chat input; 5 columns
+	Tag      string // Shown in front of the author in the log
+	Color    int    // xterm 256 color for the log
+	Radius   uint   // For speech, how many cells away it can be heard; 0 means no limit
+	Optional bool   // Users can leave it and join again
+	Admin    bool   // Only admins hear it or speak on it
+}
+
+// ChatChannels is every channel a user can speak on
+var ChatChannels = map[string]ChatChannel{
+	CHANNELGLOBAL:  ChatChannel{Name: CHANNELGLOBAL, Prompt: "ALL▶ ", Color: 255, Optional: true},
+	CHANNELSAY:     ChatChannel{Name: CHANNELSAY, Prompt: "SAY▶ ", Color: 229, Radius: 6},
+	CHANNELSHOUT:   ChatChannel{Name: CHANNELSHOUT, Prompt: "YELL▶", Color: 215, Radius: 20},
+	CHANNELREGION:  ChatChannel{Name: CHANNELREGION, Prompt: "RGN▶ ", Tag: "Region", Color: 150, Optional: true},
+	CHANNELPARTY:   ChatChannel{Name: CHANNELPARTY, Prompt: "PTY▶ ", Tag: "Party", Color: 117, Optional: true},
+	CHANNELWHISPER: ChatChannel{Name: CHANNELWHISPER, Color: 183},
+	CHANNELADMIN:   ChatChannel{Name: CHANNELADMIN, Prompt: "ADM▶ ", Tag: "Admin", Color: 203, Optional: true, Admin: true}}
+
+// ChannelInfo handles which chat channels a user listens to
+type ChannelInfo interface {
+	InChannel(string) bool
+	JoinChannel(string) error
+	LeaveChannel(string) error
+	IsAdmin() bool
+	SetAdmin(bool)
+}
+
+// ChatAuthor is how the author of a chat message is shown, which depends on the channel
+func (item *LogItem) ChatAuthor() string {
+	channel, ok := ChatChannels[item.Channel]
+	if !ok {
+		return item.Author
+	}
+
+	switch item.Channel {
+	case CHANNELSAY:
+		return item.Author + " says"
+	case CHANNELSHOUT:
+		return item.Author + " shouts"
+	case CHANNELWHISPER:
+		return item.Author + " → " + item.To
+	}
+
+	if channel.Tag != "" {
+		return fmt.Sprintf("[%v] %v", channel.Tag, item.Author)
+	}
+
+	return item.Author
+}
+
+// channelNames lists the channels a user can pick, for listing and tab completion; whispers
+// go through /whisper instead
+func channelNames(user User) []string {
+	names := make([]string, 0, len(ChatChannels))
+
+	for name, channel := range ChatChannels {
+		if name != CHANNELWHISPER && (!channel.Admin || user.IsAdmin()) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// fadeSpeech drops words from speech heard from far away. Past half the radius each word is
+// more and more likely to be lost.
+func fadeSpeech(message string, distance, radius uint) string {
+	if radius == 0 || distance <= radius/2 {
+		return message
+	}
+
+	clarity := float32(radius-distance+1) / float32(radius-radius/2+1)
+	words := strings.Fields(message)
+
+	for index := range words {
+		if rand.Float32() > clarity {
+			words[index] = "…"
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// sendChat checks a user can speak on a channel and sends their message. To is only used
+// for whispers.
+func sendChat(builder WorldBuilder, user User, channelName, to, message string) (LogItem, error) {
+	var chatItem LogItem
+
+	channel, ok := ChatChannels[channelName]
+	message = strings.TrimSpace(message)
+
+	if !ok || (channel.Admin && !user.IsAdmin()) {
+		return chatItem, fmt.Errorf("No such channel %v", channelName)
+	} else if !user.InChannel(channelName) {
+		return chatItem, fmt.Errorf("You've left %v; /join %v to use it again", channelName, channelName)
+	} else if message == "" {
+		return chatItem, fmt.Errorf("Say what?")
+	}
+
+	location := *user.Location()
+	chatItem = LogItem{
+		Author:      user.Username(),
+		Message:     message,
+		MessageType: MESSAGECHAT,
+		Channel:     channelName,
+		Location:    &location}
+
+	switch channelName {
+	case CHANNELWHISPER:
+		found := false
+		for _, online := range builder.World().OnlineUsers() {
+			if online.Username() == to && online.Username() != user.Username() {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return chatItem, fmt.Errorf("%v isn't online", to)
+		}
+		chatItem.To = to
+	case CHANNELPARTY:
+		if _, ok := user.Party(); !ok {
+			return chatItem, fmt.Errorf("You're not in a party")
+		}
+	}
+
+	builder.Chat(chatItem)
+
+	return chatItem, nil
+}
+
+// hearChat decides whether a user hears a message on a channel, and what they hear of it
+func (w *dbWorld) hearChat(user User, message LogItem, channel ChatChannel) (LogItem, bool) {
+	if user.Username() == message.Author {
+		return message, true
+	} else if !user.InChannel(channel.Name) {
+		return message, false
+	}
+
+	switch channel.Name {
+	case CHANNELWHISPER:
+		return message, user.Username() == message.To
+	case CHANNELREGION:
+		if message.Location == nil {
+			return message, false
+		}
+
+		here := w.CellAtPoint(*user.Location()).CellInfo()
+		there := w.CellAtPoint(*message.Location).CellInfo()
+
+		return message, here != nil && there != nil && here.RegionNameID == there.RegionNameID
+	case CHANNELSAY, CHANNELSHOUT:
+		if message.Location == nil {
+			return message, false
+		}
+
+		away := distance(*user.Location(), *message.Location)
+		if away > channel.Radius {
+			return message, false
+		}
+
+		message.Message = fadeSpeech(message.Message, away, channel.Radius)
+		return message, true
+	case CHANNELPARTY:
+		party, ok := user.Party()
+		return message, ok && party.HasMember(message.Author)
+	}
+
+	return message, true
+}
@@ -0,0 +1,80 @@
+package mud
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChatAuthor(t *testing.T) {
+	tests := []struct {
+		item LogItem
+		want string
+	}{
+		{LogItem{Author: "ann", Channel: CHANNELGLOBAL}, "ann"},
+		{LogItem{Author: "ann", Channel: CHANNELSAY}, "ann says"},
+		{LogItem{Author: "ann", Channel: CHANNELWHISPER, To: "bob"}, "ann → bob"},
+		{LogItem{Author: "ann", Channel: CHANNELPARTY}, "[Party] ann"},
+		{LogItem{Author: "ann"}, "ann"},
+	}
+
+	for _, test := range tests {
+		if got := test.item.ChatAuthor(); got != test.want {
+			t.Fatalf("ChatAuthor() on %v = %q, want %q", test.item.Channel, got, test.want)
+		}
+	}
+}
+
+func TestFadeSpeech(t *testing.T) {
+	message := "the quick brown fox"
+
+	if got := fadeSpeech(message, 3, 6); got != message {
+		t.Fatalf("fadeSpeech() within half the radius = %q", got)
+	}
+	if got := fadeSpeech(message, 100, 0); got != message {
+		t.Fatalf("fadeSpeech() with no radius = %q", got)
+	}
+	if got := fadeSpeech(message, 20, 20); len(strings.Fields(got)) != 4 {
+		t.Fatalf("fadeSpeech() at the edge of hearing = %q, want a word or gap for each word", got)
+	}
+}
+
+func TestHearChat(t *testing.T) {
+	world := newTestWorld(t)
+	speaker := newTestUser(t, world, "speaker")
+	listener := newTestUser(t, world, "listener").(*dbUser)
+	here := *speaker.Location()
+
+	tests := []struct {
+		name    string
+		channel string
+		to      string
+		away    uint32
+		want    bool
+	}{
+		{"global from afar", CHANNELGLOBAL, "", 100, true},
+		{"say nearby", CHANNELSAY, "", 2, true},
+		{"say out of earshot", CHANNELSAY, "", 7, false},
+		{"shout from afar", CHANNELSHOUT, "", 15, true},
+		{"whisper to them", CHANNELWHISPER, "listener", 100, true},
+		{"whisper to someone else", CHANNELWHISPER, "other", 0, false},
+		{"party when not in one", CHANNELPARTY, "", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listener.X = here.X + test.away
+			message := LogItem{Author: speaker.Username(), Message: "hi", Channel: test.channel, To: test.to, Location: &here}
+
+			if _, heard := world.hearChat(listener, message, ChatChannels[test.channel]); heard != test.want {
+				t.Fatalf("hearChat() = %v, want %v", heard, test.want)
+			}
+		})
+	}
+
+	listener.X = here.X
+	listener.LeaveChannel(CHANNELGLOBAL)
+	message := LogItem{Author: speaker.Username(), Message: "hi", Channel: CHANNELGLOBAL, Location: &here}
+	if _, heard := world.hearChat(listener, message, ChatChannels[CHANNELGLOBAL]); heard {
+		t.Fatal("heard global after leaving it")
+	}
+}
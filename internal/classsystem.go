@@ -32,6 +32,15 @@ const (
 	PRIMARYSKILLMASK      = byte(192)
 )
 
+// Stats every character starts out with, before their strengths are applied
+const (
+	baseHP         = 10
+	baseStatPoints = 2
+)
+
+// classRespecCost is what picking a new class costs for each level past the first
+const classRespecCost = 50
+
 // ClassInfo handles user/NPC class orientation
 type ClassInfo interface {
 	ClassInfo() byte
@@ -43,6 +52,15 @@ type ClassInfo interface {
 	SetSkills(byte, byte)
 }
 
+// RespecInfo handles a user going back through character setup to pick a new class
+type RespecInfo interface {
+	Respeccing() bool
+	ClassRespecPrice() uint64
+	StartRespec() error
+	CancelRespec()
+	FinishRespec() error
+}
+
 // GetSubTitles takes strengths and gives a class title
 func GetSubTitles(strengthPrimary, strengthSecondary, skillPrimary, skillSecondary byte) (string, string) {
 	strS, sklS := "Hippopotamus", "Spaghetti"
@@ -75,6 +93,322 @@ func GetTitle(strengthPrimary, strengthSecondary, skillPrimary, skillSecondary b
 	return title
 }
 
+// defaultAttacks returns the starting attacks for a character with the given strengths
+func defaultAttacks(primary, secondary byte) []*Attack {
+
+	var primaryattack Attack
+	var secondaryattack Attack
+	rockAttack := Attack{
+		Name:         "Rock user",
+		Accuracy:     100,
+		MP:           1,
+		AP:           1,
+		RP:           1,
+		Trample:      6,
+		Charge:       1,
+		UsesItems:    []string{"Shiny Rock"},
+		OutputsItems: []string{"Broken Rock"}}
+
+	switch primary {
+	case MELEEPRIMARY:
+		primaryattack = Attack{Name: "Punch",
+			Accuracy: 95,
+			MP:       0,
+			AP:       4,
+			RP:       0,
+			Charge:   2}
+
+		rockAttack.Name = "Smash Rock"
+		rockAttack.AP *= 2
+		rockAttack.Bonuses = "AP+25%AP"
+	case RANGEPRIMARY:
+		primaryattack = Attack{Name: "Dart",
+			Accuracy: 95,
+			MP:       0,
+			AP:       0,
+			RP:       4,
+			Charge:   2}
+
+		rockAttack.Name = "Throw Rock"
+		rockAttack.RP *= 2
+		rockAttack.Bonuses = "RP+25%RP"
+	case MAGICPRIMARY:
+		primaryattack = Attack{Name: "Mage push",
+			Accuracy: 95,
+			MP:       4,
+			AP:       0,
+			RP:       0,
+			Charge:   2}
+
+		rockAttack.Name = "Rock Bomb"
+		rockAttack.MP *= 2
+		rockAttack.Bonuses = "MP+25%MP"
+	}
+	switch secondary {
+	case MELEESECONDARY:
+		secondaryattack = Attack{Name: "Biff",
+			Accuracy: 95,
+			MP:       0,
+			AP:       2,
+			RP:       0,
+			Charge:   4}
+		if primary == MELEEPRIMARY {
+			secondaryattack.Charge = 1
+			secondaryattack.Trample = 1
+		} else if primary == RANGEPRIMARY {
+			secondaryattack.RP++
+		} else if primary == MAGICPRIMARY {
+			secondaryattack.MP++
+		}
+	case RANGESECONDARY:
+		secondaryattack = Attack{Name: "Toss",
+			Accuracy: 95,
+			MP:       0,
+			AP:       0,
+			RP:       2,
+			Charge:   4}
+		if primary == RANGEPRIMARY {
+			secondaryattack.Charge = 1
+			secondaryattack.Trample = 1
+		} else if primary == MELEEPRIMARY {
+			secondaryattack.AP++
+		} else if primary == MAGICPRIMARY {
+			secondaryattack.MP++
+		}
+	case MAGICSECONDARY:
+		secondaryattack = Attack{Name: "Crackle",
+			Accuracy: 95,
+			MP:       2,
+			AP:       0,
+			RP:       0,
+			Charge:   4}
+		if primary == MAGICPRIMARY {
+			secondaryattack.Charge = 1
+			secondaryattack.Trample = 1
+		} else if primary == RANGEPRIMARY {
+			secondaryattack.RP++
+		} else if primary == MELEEPRIMARY {
+			secondaryattack.AP++
+		}
+	}
+
+	attacks := []*Attack{&primaryattack, &secondaryattack, &rockAttack}
+
+	return attacks
+}
+
+// setupStatBonuses multiplies the max stats of a fresh character based on its strengths
+func setupStatBonuses(userData *UserData, primary, secondary byte) {
+	switch primary {
+	case MELEEPRIMARY:
+		userData.MaxAP *= 3
+	case RANGEPRIMARY:
+		userData.MaxRP *= 3
+	case MAGICPRIMARY:
+		userData.MaxMP *= 3
+	}
+	switch secondary {
+	case MELEESECONDARY:
+		userData.MaxAP *= 2
+	case RANGESECONDARY:
+		userData.MaxRP *= 2
+	case MAGICSECONDARY:
+		userData.MaxMP *= 2
+	}
+}
+
+// setClassStats works out a character's max stats from the base stats for their strengths and
+// level, so a new class doesn't compound the bonuses of the old one. Current stats are kept
+// within the new maximums.
+func setClassStats(userData *UserData, primary, secondary byte) {
+	userData.MaxHP, userData.MaxAP, userData.MaxRP, userData.MaxMP = baseHP, baseStatPoints, baseStatPoints, baseStatPoints
+	setupStatBonuses(userData, primary, secondary)
+
+	if userData.Level > 1 {
+		levels := userData.Level - 1
+		apbonus, rpbonus, mpbonus, hpbonus := levelUpBonuses(primary, secondary)
+
+		userData.MaxAP += apbonus * levels
+		userData.MaxRP += rpbonus * levels
+		userData.MaxMP += mpbonus * levels
+		userData.MaxHP += hpbonus * levels
+	}
+
+	if userData.HP > userData.MaxHP {
+		userData.HP = userData.MaxHP
+	}
+	if userData.AP > userData.MaxAP {
+		userData.AP = userData.MaxAP
+	}
+	if userData.RP > userData.MaxRP {
+		userData.RP = userData.MaxRP
+	}
+	if userData.MP > userData.MaxMP {
+		userData.MP = userData.MaxMP
+	}
+}
+
+// classAttacks is every attack a character knows: their class's own, along with any their skill
+// levels and talents have taught them
+func classAttacks(userData *UserData, primary, secondary byte) []*Attack {
+	attacks := defaultAttacks(primary, secondary)
+
+	for _, skill := range SkillNames {
+		for _, unlock := range skillUnlocks(skill, skillLevel(userData.SkillPoints[skill])) {
+			attack := unlock
+			attacks = append(attacks, &attack)
+		}
+	}
+
+	for _, id := range userData.Talents {
+		if talent, ok := talentByID(id); ok && talent.Attack != nil {
+			attack := *talent.Attack
+			attacks = append(attacks, &attack)
+		}
+	}
+
+	return attacks
+}
+
+// classSlots is every equipment slot a character has: their class's own and any from talents
+func classSlots(userData *UserData, primary, secondary byte) []*EquipmentSlotInfo {
+	slots := defaultSlots(primary, secondary)
+
+	for _, id := range userData.Talents {
+		if talent, ok := talentByID(id); ok && talent.Slot != nil {
+			slot := *talent.Slot
+			slots = append(slots, &slot)
+		}
+	}
+
+	return slots
+}
+
+// slotHolds checks whether one of a set of slots, by name, can take an item
+func slotHolds(slots []*EquipmentSlotInfo, name string, item *InventoryItem) bool {
+	for _, slot := range slots {
+		if slot.Name != name {
+			continue
+		}
+
+		for _, slotType := range slot.SlotTypes {
+			if slotType == item.SlotName() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// classRespecPrice is what picking a new class costs at a level
+func classRespecPrice(level uint64) uint64 {
+	if level <= 1 {
+		return 0
+	}
+
+	return classRespecCost * (level - 1)
+}
+
+// defaultSlots returns the equipment slots for a character with the given strengths
+func defaultSlots(primary, secondary byte) []*EquipmentSlotInfo {
+	weapon := EquipmentSlotInfo{
+		Name:      "Weapon",
+		SlotTypes: []string{}}
+	headwear := EquipmentSlotInfo{
+		Name:      "Headwear",
+		SlotTypes: []string{}}
+	armor := EquipmentSlotInfo{
+		Name:      "Armor",
+		SlotTypes: []string{}}
+	offhand := EquipmentSlotInfo{
+		Name:      "Offhand",
+		SlotTypes: []string{ITEMTYPESCROLL}}
+
+	if primary == MELEEPRIMARY || secondary == MELEESECONDARY {
+		offhand.SlotTypes = append(offhand.SlotTypes, ARMORSUBTYPESHIELD)
+	}
+
+	switch primary {
+	case MELEEPRIMARY:
+		headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHELM)
+		armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECHESTPLATE)
+
+		switch secondary {
+		case MELEESECONDARY:
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPESWORD, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEDAGGER)
+		case RANGESECONDARY:
+			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPECOWL)
+			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPELIGHTARMOR)
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPESWORD, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEJAVELIN)
+		case MAGICSECONDARY:
+			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHAT)
+			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECLOAK)
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPESWORD, WEAPONSUBTYPESPEAR, WEAPONSUBTYPESPEAR)
+		}
+	case RANGEPRIMARY:
+		headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPECOWL)
+
+		switch secondary {
+		case MELEESECONDARY:
+			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHELM)
+			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECHESTPLATE)
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEBOW, WEAPONSUBTYPEDART, WEAPONSUBTYPEJAVELIN)
+		case RANGESECONDARY:
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEBOW, WEAPONSUBTYPEJAVELIN, WEAPONSUBTYPESPEAR)
+		case MAGICSECONDARY:
+			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHAT)
+			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECLOAK)
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEBOW, WEAPONSUBTYPEDART, WEAPONSUBTYPEORB)
+		}
+	case MAGICPRIMARY:
+		headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHAT)
+		armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECLOAK)
+
+		switch secondary {
+		case MELEESECONDARY:
+			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPEHELM)
+			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPECHESTPLATE)
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEWAND, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEDAGGER)
+		case RANGESECONDARY:
+			headwear.SlotTypes = append(headwear.SlotTypes, ARMORSUBTYPECOWL)
+			armor.SlotTypes = append(armor.SlotTypes, ARMORSUBTYPELIGHTARMOR)
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEWAND, WEAPONSUBTYPESPEAR, WEAPONSUBTYPEORB)
+		case MAGICSECONDARY:
+			weapon.SlotTypes = append(weapon.SlotTypes, WEAPONSUBTYPEWAND, WEAPONSUBTYPEORB, WEAPONSUBTYPEDART)
+		}
+	}
+
+	slots := []*EquipmentSlotInfo{&weapon, &headwear, &armor, &offhand}
+
+	return slots
+}
+
+// levelUpBonuses returns how much AP, RP, MP and HP a character gains per level
+func levelUpBonuses(primary, secondary byte) (uint64, uint64, uint64, uint64) {
+	var apbonus, rpbonus, mpbonus, hpbonus uint64 = 1, 1, 1, 1
+
+	switch primary {
+	case MELEEPRIMARY:
+		apbonus += 2
+	case RANGEPRIMARY:
+		rpbonus += 2
+	case MAGICPRIMARY:
+		mpbonus += 2
+	}
+
+	switch secondary {
+	case MELEESECONDARY:
+		apbonus++
+	case RANGESECONDARY:
+		rpbonus++
+	case MAGICSECONDARY:
+		mpbonus++
+	}
+
+	return apbonus, rpbonus, mpbonus, hpbonus
+}
+
 func init() {
 	strengthClassNameMap = map[byte]string{
 		MELEEPRIMARY | MELEESECONDARY: "Warrior",
@@ -0,0 +1,146 @@
+package mud
+
+import "testing"
+
+func TestSetClassStats(t *testing.T) {
+	tests := []struct {
+		name                           string
+		primary, secondary             byte
+		level                          uint64
+		wantHP, wantAP, wantRP, wantMP uint64
+	}{
+		{"new warrior", MELEEPRIMARY, MELEESECONDARY, 1, 10, 12, 2, 2},
+		{"warrior", MELEEPRIMARY, MELEESECONDARY, 5, 14, 28, 6, 6},
+		{"paladin", MELEEPRIMARY, MAGICSECONDARY, 3, 12, 12, 4, 8},
+		{"new mage", MAGICPRIMARY, MAGICSECONDARY, 1, 10, 2, 2, 12},
+		{"ranger", RANGEPRIMARY, RANGESECONDARY, 10, 19, 11, 48, 11},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Whatever the old class built up is thrown away, not multiplied again
+			for _, before := range []uint64{0, 1000} {
+				userData := UserData{Level: test.level, MaxHP: before, MaxAP: before, MaxRP: before, MaxMP: before}
+				setClassStats(&userData, test.primary, test.secondary)
+
+				if userData.MaxHP != test.wantHP || userData.MaxAP != test.wantAP || userData.MaxRP != test.wantRP || userData.MaxMP != test.wantMP {
+					t.Fatalf("from %v, max HP/AP/RP/MP = %v/%v/%v/%v, want %v/%v/%v/%v", before,
+						userData.MaxHP, userData.MaxAP, userData.MaxRP, userData.MaxMP,
+						test.wantHP, test.wantAP, test.wantRP, test.wantMP)
+				}
+			}
+		})
+	}
+}
+
+func TestSetClassStatsKeepsStatsUnderMax(t *testing.T) {
+	userData := UserData{Level: 1, HP: 5, AP: 50, RP: 50, MP: 1}
+	setClassStats(&userData, MAGICPRIMARY, MAGICSECONDARY)
+
+	if userData.HP != 5 || userData.AP != 2 || userData.RP != 2 || userData.MP != 1 {
+		t.Fatalf("HP/AP/RP/MP = %v/%v/%v/%v, want 5/2/2/1", userData.HP, userData.AP, userData.RP, userData.MP)
+	}
+}
+
+func TestClassRespecPrice(t *testing.T) {
+	tests := []struct {
+		level uint64
+		want  uint64
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 50},
+		{5, 200},
+	}
+
+	for _, test := range tests {
+		if price := classRespecPrice(test.level); price != test.want {
+			t.Fatalf("classRespecPrice(%v) = %v, want %v", test.level, price, test.want)
+		}
+	}
+}
+
+func TestFinishRespec(t *testing.T) {
+	tests := []struct {
+		name               string
+		coins              uint64
+		primary, secondary byte
+		wantErr            bool
+		wantSwordEquipped  bool
+		wantTalents        bool
+		wantAP, wantMP     uint64
+	}{
+		{"sword still fits", 100, MELEEPRIMARY, MAGICSECONDARY, false, true, false, 12, 8},
+		{"sword doesn't fit", 100, MAGICPRIMARY, MAGICSECONDARY, false, false, false, 4, 20},
+		{"same strengths", 100, MELEEPRIMARY, RANGESECONDARY, false, true, true, 12, 4},
+		{"can't afford it", 99, MAGICPRIMARY, MAGICSECONDARY, true, true, true, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "fickle")
+			user.SetStrengths(MELEEPRIMARY, RANGESECONDARY)
+			user.Initialize(true)
+
+			user.Reload()
+			user.(*dbUser).UserData.Level = 3
+			user.Save()
+
+			if err := user.LearnTalent("iron-grip"); err != nil {
+				t.Fatal(err)
+			}
+
+			sword := giveTestItem(t, user, "Simple Sword")
+			user.PullInventoryItem(sword.ID)
+			if _, err := user.Equip("Weapon", sword); err != nil {
+				t.Fatal(err)
+			}
+
+			user.AddCoins(100)
+			if err := user.StartRespec(); err != nil {
+				t.Fatal(err)
+			}
+			spendCoins(t, world, "fickle", 100-test.coins)
+			user.SetStrengths(test.primary, test.secondary)
+
+			err := user.FinishRespec()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("FinishRespec() = %v, want error %v", err, test.wantErr)
+			}
+
+			user = world.GetUser("fickle")
+			userData := user.(*dbUser).UserData
+
+			if userData.Respec != test.wantErr {
+				t.Fatalf("still picking a class: %v", userData.Respec)
+			}
+			if equipped := user.EquipmentSlotItem("Weapon") != nil; equipped != test.wantSwordEquipped {
+				t.Fatalf("sword equipped: %v, want %v", equipped, test.wantSwordEquipped)
+			}
+			if carried := carriedItems(user.InventoryItems())["Simple Sword"] == 1; carried == test.wantSwordEquipped {
+				t.Fatalf("sword carried: %v", carried)
+			}
+			if knows := len(userData.Talents) > 0; knows != test.wantTalents {
+				t.Fatalf("knows talents %v, want any: %v", userData.Talents, test.wantTalents)
+			}
+
+			if test.wantErr {
+				if coins := user.Coins(); coins != test.coins {
+					t.Fatalf("left with %v coins, want %v", coins, test.coins)
+				}
+				return
+			}
+
+			if coins := user.Coins(); coins != 0 {
+				t.Fatalf("left with %v coins, want 0", coins)
+			}
+			if userData.MaxAP != test.wantAP || userData.MaxMP != test.wantMP {
+				t.Fatalf("max AP/MP = %v/%v, want %v/%v", userData.MaxAP, userData.MaxMP, test.wantAP, test.wantMP)
+			}
+			if holds := slotHolds(userData.Slots, "Weapon", sword); holds != test.wantSwordEquipped {
+				t.Fatalf("new Weapon slot holds a sword: %v", holds)
+			}
+		})
+	}
+}
@@ -0,0 +1,1591 @@
+package mud
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// gameCommand is a verb typed on the input line in command mode, like /look
+type gameCommand struct {
+	Name     string
+	Aliases  []string
+	Usage    string // Arguments after the name, like "<item> [slot]"
+	Help     string
+	MinArgs  int
+	Run      func(ctx *commandContext, args []string) error
+	Complete func(ctx *commandContext, args []string) []string // Candidates for the last argument
+}
+
+// commandContext is who is running a command and from where
+type commandContext struct {
+	builder WorldBuilder
+	user    User
+	screen  *sshScreen
+}
+
+// errNoMatch is returned by matchName when nothing matches at all, so callers can say what they were looking for
+var errNoMatch = errors.New("No match")
+
+var gameCommands map[string]*gameCommand
+var gameCommandList []*gameCommand
+
+// maxSteps stops /go from walking halfway across the world in one go
+const maxSteps = 20
+
+var directionNames = map[string]Direction{
+	"north": DIRECTIONNORTH, "n": DIRECTIONNORTH, "up": DIRECTIONNORTH,
+	"east": DIRECTIONEAST, "e": DIRECTIONEAST, "right": DIRECTIONEAST,
+	"south": DIRECTIONSOUTH, "s": DIRECTIONSOUTH, "down": DIRECTIONSOUTH,
+	"west": DIRECTIONWEST, "w": DIRECTIONWEST, "left": DIRECTIONWEST}
+
+var directionLabels = []string{"north", "east", "south", "west"}
+
+func registerCommand(command *gameCommand) {
+	gameCommandList = append(gameCommandList, command)
+	gameCommands[command.Name] = command
+
+	for _, alias := range command.Aliases {
+		gameCommands[alias] = command
+	}
+}
+
+func (ctx *commandContext) reply(format string, args ...interface{}) {
+	ctx.user.Log(LogItem{Message: fmt.Sprintf(format, args...), MessageType: MESSAGEACTION})
+}
+
+// splitCommandLine breaks a command line into words, keeping "quoted words" together. It
+// also reports whether the last word is still being typed, which is what tab completes.
+func splitCommandLine(line string) ([]string, bool, error) {
+	words := make([]string, 0)
+	word := strings.Builder{}
+	inWord, inQuote := false, false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			inWord = true
+		case unicode.IsSpace(r) && !inQuote:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	if inQuote {
+		return words, true, fmt.Errorf("Missing closing quote")
+	}
+
+	return words, inWord, nil
+}
+
+func quoteWord(word string) string {
+	if strings.IndexFunc(word, unicode.IsSpace) >= 0 {
+		return "\"" + word + "\""
+	}
+
+	return word
+}
+
+// matchName finds the one name a user meant: an exact match, or else the only one starting
+// with (or failing that, containing) what they typed. Case doesn't matter.
+func matchName(names []string, query string) (string, error) {
+	query = strings.ToLower(query)
+	tests := []func(string) bool{
+		func(name string) bool { return name == query },
+		func(name string) bool { return strings.HasPrefix(name, query) },
+		func(name string) bool { return strings.Contains(name, query) }}
+
+	for _, test := range tests {
+		found := make([]string, 0)
+		seen := make(map[string]bool)
+
+		for _, name := range names {
+			if test(strings.ToLower(name)) && !seen[name] {
+				found = append(found, name)
+				seen[name] = true
+			}
+		}
+
+		if len(found) == 1 {
+			return found[0], nil
+		} else if len(found) > 1 {
+			sort.Strings(found)
+			return "", fmt.Errorf("Which one? %v", strings.Join(found, ", "))
+		}
+	}
+
+	return "", errNoMatch
+}
+
+func runCommand(ctx *commandContext, line string) {
+	words, _, err := splitCommandLine(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+
+	if err != nil {
+		ctx.reply(err.Error())
+		return
+	} else if len(words) == 0 {
+		return
+	}
+
+	command, ok := gameCommands[strings.ToLower(words[0])]
+	if !ok {
+		ctx.reply("Unknown command /%v. Try /help.", words[0])
+		return
+	}
+
+	args := words[1:]
+	if len(args) < command.MinArgs {
+		ctx.reply("Usage: /%v %v", command.Name, command.Usage)
+		return
+	}
+
+	if err := command.Run(ctx, args); err != nil {
+		ctx.reply(err.Error())
+	}
+}
+
+// completeCommand fills in as much of the word being typed as it can, returning the new line
+// and, if there's more than one way to finish it, the choices
+func completeCommand(ctx *commandContext, line string) (string, []string) {
+	words, open, _ := splitCommandLine(line)
+
+	if !open {
+		words = append(words, "")
+	}
+
+	var candidates []string
+	if len(words) == 1 {
+		candidates = commandNames(ctx, nil)
+	} else {
+		command, ok := gameCommands[strings.ToLower(words[0])]
+		if !ok || command.Complete == nil {
+			return line, nil
+		}
+		candidates = command.Complete(ctx, words[1:])
+	}
+
+	partial := strings.ToLower(words[len(words)-1])
+	matches := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), partial) && !seen[candidate] {
+			matches = append(matches, candidate)
+			seen[candidate] = true
+		}
+	}
+	sort.Strings(matches)
+
+	if len(matches) == 0 {
+		return line, nil
+	}
+
+	head := ""
+	for _, word := range words[:len(words)-1] {
+		head += quoteWord(word) + " "
+	}
+
+	if len(matches) == 1 {
+		return head + quoteWord(matches[0]) + " ", nil
+	}
+
+	common := []rune(matches[0])
+	for _, match := range matches[1:] {
+		runes := []rune(match)
+		length := 0
+		for length < len(common) && length < len(runes) && unicode.ToLower(common[length]) == unicode.ToLower(runes[length]) {
+			length++
+		}
+		common = common[:length]
+	}
+
+	completed := string(common)
+	if strings.IndexFunc(completed, unicode.IsSpace) >= 0 {
+		completed = "\"" + completed
+	}
+
+	return head + completed, matches
+}
+
+// equipFromInventory moves an item from a user's inventory into a slot, putting whatever was
+// there back in the inventory (or on the ground if that's full)
+func equipFromInventory(user User, slot string, item *InventoryItem) error {
+	pulledItem := user.PullInventoryItem(item.ID)
+	if pulledItem == nil {
+		return fmt.Errorf("You don't have %v", item.Name)
+	}
+
+	unequippedItem, err := user.Equip(slot, pulledItem)
+	if unequippedItem != nil {
+		if !user.AddInventoryItem(unequippedItem) {
+			user.Cell().AddInventoryItem(unequippedItem)
+		}
+	}
+
+	return err
+}
+
+// dropFromInventory puts one of a user's items on the ground where they stand
+func dropFromInventory(builder WorldBuilder, user User, id string) *InventoryItem {
+	location := user.Location()
+	item := user.PullInventoryItem(id)
+
+	if item != nil && !builder.World().Cell(location.X, location.Y).AddInventoryItem(item) {
+		user.AddInventoryItem(item)
+		return nil
+	}
+
+	return item
+}
+
+func inventoryNames(user User) []string {
+	_, _, names := groupInventory(user.InventoryItems())
+	return names
+}
+
+// findInventoryItem finds one item a user is carrying by (part of) its name
+func findInventoryItem(user User, query string) (*InventoryItem, error) {
+	_, itemID, names := groupInventory(user.InventoryItems())
+
+	name, err := matchName(names, query)
+	if err == errNoMatch {
+		return nil, fmt.Errorf("You aren't carrying %v", query)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user.InventoryItem(itemID[name]), nil
+}
+
+// cellCreatures lists the creatures where the user is, numbered the same as on the character sheet
+func cellCreatures(ctx *commandContext) []*Creature {
+	location := ctx.user.Location()
+
+	return ctx.builder.World().Cell(location.X, location.Y).GetCreatures()
+}
+
+// findCreature finds a creature here by the number it has on the character sheet or its name
+func findCreature(ctx *commandContext, query string) (*Creature, error) {
+	creatures := cellCreatures(ctx)
+
+	if index, err := strconv.Atoi(query); err == nil {
+		if index < 1 || index > len(creatures) || creatures[index-1].HP == 0 {
+			return nil, fmt.Errorf("There's no creature %v here", index)
+		}
+
+		return creatures[index-1], nil
+	}
+
+	names := make([]string, 0, len(creatures))
+	for _, creature := range creatures {
+		if creature.HP > 0 {
+			names = append(names, creature.CreatureTypeStruct.Name)
+		}
+	}
+
+	name, err := matchName(names, query)
+	if err == errNoMatch {
+		return nil, fmt.Errorf("There's no %v here", query)
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, creature := range creatures {
+		if creature.HP > 0 && creature.CreatureTypeStruct.Name == name {
+			return creature, nil
+		}
+	}
+
+	return nil, fmt.Errorf("There's no %v here", query)
+}
+
+func creatureNames(ctx *commandContext, args []string) []string {
+	names := make([]string, 0)
+
+	for _, creature := range cellCreatures(ctx) {
+		if creature.HP > 0 {
+			names = append(names, creature.CreatureTypeStruct.Name)
+		}
+	}
+
+	return names
+}
+
+func onlineUsernames(ctx *commandContext, args []string) []string {
+	names := make([]string, 0)
+
+	for _, user := range ctx.builder.World().OnlineUsers() {
+		if user.Username() != ctx.user.Username() {
+			names = append(names, user.Username())
+		}
+	}
+
+	return names
+}
+
+func itemNames(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	return inventoryNames(ctx.user)
+}
+
+func helpCommand(ctx *commandContext, args []string) error {
+	if len(args) > 0 {
+		command, ok := gameCommands[strings.ToLower(strings.TrimPrefix(args[0], "/"))]
+		if !ok {
+			return fmt.Errorf("Unknown command /%v", args[0])
+		}
+
+		ctx.reply("/%v %v: %v", command.Name, command.Usage, command.Help)
+		if len(command.Aliases) > 0 {
+			ctx.reply("Also /%v", strings.Join(command.Aliases, ", /"))
+		}
+
+		return nil
+	}
+
+	names := make([]string, 0, len(gameCommandList))
+	for _, command := range gameCommandList {
+		names = append(names, "/"+command.Name)
+	}
+
+	ctx.reply("Commands: %v", strings.Join(names, " "))
+	ctx.reply("/help <command> for more, tab completes")
+
+	return nil
+}
+
+func lookCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+	location := user.Location()
+	world := ctx.builder.World()
+
+	ctx.reply("%v (%v, %v)", user.LocationName(), location.X, location.Y)
+
+	exits := make([]string, 0)
+	for _, label := range directionLabels {
+		if canStep(world, *location, directionNames[label]) {
+			exits = append(exits, label)
+		}
+	}
+	if len(exits) > 0 {
+		ctx.reply("Exits: %v", strings.Join(exits, ", "))
+	}
+
+	for index, creature := range cellCreatures(ctx) {
+		if creature.HP == 0 {
+			continue
+		} else if creature.CreatureTypeStruct.Hostile {
+			ctx.reply("%v. %v (%v/%v)", index+1, creature.CreatureTypeStruct.Name, creature.HP, creature.CreatureTypeStruct.MaxHP)
+		} else {
+			ctx.reply("%v. %v", index+1, creature.CreatureTypeStruct.Name)
+		}
+	}
+
+	itemCount, _, keyList := groupInventory(world.Cell(location.X, location.Y).InventoryItems())
+	if len(keyList) > 0 {
+		items := make([]string, 0, len(keyList))
+		for _, name := range keyList {
+			items = append(items, fmt.Sprintf("%v x%v", name, itemCount[name]))
+		}
+		ctx.reply("On the ground: %v", strings.Join(items, ", "))
+	}
+
+	others := make([]string, 0)
+	for _, other := range world.OnlineUsers() {
+		if other.Username() != user.Username() && *other.Location() == *location {
+			others = append(others, other.Username())
+		}
+	}
+	if len(others) > 0 {
+		ctx.reply("Also here: %v", strings.Join(others, ", "))
+	}
+
+	return nil
+}
+
+// replyLines logs the plain text a non-interactive SSH command would print
+func (ctx *commandContext) replyLines(text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		ctx.reply("%v", line)
+	}
+}
+
+func whoCommand(ctx *commandContext, args []string) error {
+	_, text, err := sshWhoCommand(ctx.builder, ctx.user, args)
+	if err == nil {
+		ctx.replyLines(text)
+	}
+	return err
+}
+
+func statsCommand(ctx *commandContext, args []string) error {
+	_, text, err := sshStatusCommand(ctx.builder, ctx.user, args)
+	if err == nil {
+		ctx.replyLines(text)
+	}
+	return err
+}
+
+func whisperCommand(ctx *commandContext, args []string) error {
+	name, err := matchName(onlineUsernames(ctx, nil), args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("%v isn't online", args[0])
+	} else if err != nil {
+		return err
+	}
+
+	_, err = sendChat(ctx.builder, ctx.user, CHANNELWHISPER, name, strings.Join(args[1:], " "))
+	return err
+}
+
+// speakCommand makes a command that speaks on one channel
+func speakCommand(channel string) func(ctx *commandContext, args []string) error {
+	return func(ctx *commandContext, args []string) error {
+		_, err := sendChat(ctx.builder, ctx.user, channel, "", strings.Join(args, " "))
+		return err
+	}
+}
+
+func channelCommand(ctx *commandContext, args []string) error {
+	if len(args) == 0 {
+		listening := make([]string, 0)
+		for _, name := range channelNames(ctx.user) {
+			if !ctx.user.InChannel(name) {
+				name += " (left)"
+			}
+			listening = append(listening, name)
+		}
+
+		if ctx.screen != nil {
+			ctx.reply("Chatting on %v", ctx.screen.ChatChannel())
+		}
+		ctx.reply("Channels: %v", strings.Join(listening, ", "))
+
+		return nil
+	}
+
+	name, err := matchName(channelNames(ctx.user), args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("Can't chat on %v", args[0])
+	} else if err != nil {
+		return err
+	} else if !ctx.user.InChannel(name) {
+		return fmt.Errorf("You've left %v; /join %v to use it again", name, name)
+	}
+
+	if ctx.screen != nil {
+		ctx.screen.SetChatChannel(name)
+	}
+	ctx.reply("Chatting on %v", name)
+
+	return nil
+}
+
+func joinCommand(ctx *commandContext, args []string) error {
+	name, err := matchName(channelNames(ctx.user), args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("No such channel %v", args[0])
+	} else if err != nil {
+		return err
+	}
+
+	if err := ctx.user.JoinChannel(name); err != nil {
+		return err
+	}
+
+	ctx.reply("Joined %v", name)
+	return nil
+}
+
+func leaveCommand(ctx *commandContext, args []string) error {
+	name, err := matchName(channelNames(ctx.user), args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("No such channel %v", args[0])
+	} else if err != nil {
+		return err
+	}
+
+	if err := ctx.user.LeaveChannel(name); err != nil {
+		return err
+	}
+
+	ctx.reply("Left %v", name)
+
+	return nil
+}
+
+func channelComplete(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	return channelNames(ctx.user)
+}
+
+// partyVerbs are what /party can do, besides showing the party
+var partyVerbs = []string{"invite", "accept", "decline", "leave", "kick", "lead", "loot"}
+
+// partyVerbArguments are the /party verbs that need something after them
+var partyVerbArguments = map[string]string{"invite": "<user>", "kick": "<member>", "lead": "<member>", "loot": "<mode>"}
+
+func partyCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+
+	if len(args) == 0 {
+		if party, ok := user.Party(); ok {
+			ctx.reply("Party: %v", party.String())
+			if len(party.Invited) > 0 {
+				ctx.reply("Invited: %v", strings.Join(party.Invited, ", "))
+			}
+		} else {
+			ctx.reply("You're not in a party; /party invite <user> to start one")
+		}
+
+		for _, party := range user.PartyInvitations() {
+			ctx.reply("%v invited you to their party", party.Leader)
+		}
+
+		return nil
+	}
+
+	verb, err := matchName(partyVerbs, args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("Usage: /party %v", gameCommands["party"].Usage)
+	} else if err != nil {
+		return err
+	}
+
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+	} else if argument, ok := partyVerbArguments[verb]; ok {
+		return fmt.Errorf("Usage: /party %v %v", verb, argument)
+	}
+
+	switch verb {
+	case "invite":
+		invitee, err := matchName(onlineUsernames(ctx, nil), name)
+		if err == errNoMatch {
+			return fmt.Errorf("%v isn't online", name)
+		} else if err != nil {
+			return err
+		} else if err := user.InviteToParty(invitee); err != nil {
+			return err
+		}
+		ctx.reply("Invited %v", invitee)
+	case "accept":
+		return user.JoinParty(name)
+	case "decline":
+		if err := user.DeclineParty(name); err != nil {
+			return err
+		}
+		ctx.reply("Declined")
+	case "leave":
+		return user.LeaveParty()
+	case "kick", "lead":
+		party, ok := user.Party()
+		if !ok {
+			return fmt.Errorf("You're not in a party")
+		}
+
+		member, err := matchName(party.Members, name)
+		if err == errNoMatch {
+			return fmt.Errorf("%v isn't in the party", name)
+		} else if err != nil {
+			return err
+		}
+
+		if verb == "kick" {
+			return user.KickFromParty(member)
+		}
+		return user.SetPartyLeader(member)
+	case "loot":
+		mode, err := matchName(LootModes, name)
+		if err == errNoMatch {
+			return fmt.Errorf("Loot can be %v", strings.Join(LootModes, ", "))
+		} else if err != nil {
+			return err
+		}
+		return user.SetLootMode(mode)
+	}
+
+	return nil
+}
+
+func partyComplete(ctx *commandContext, args []string) []string {
+	if len(args) == 1 {
+		return partyVerbs
+	} else if len(args) > 2 {
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "invite":
+		return onlineUsernames(ctx, nil)
+	case "accept", "decline":
+		leaders := make([]string, 0)
+		for _, party := range ctx.user.PartyInvitations() {
+			leaders = append(leaders, party.Leader)
+		}
+		return leaders
+	case "kick", "lead":
+		if party, ok := ctx.user.Party(); ok {
+			return party.Members
+		}
+	case "loot":
+		return LootModes
+	}
+
+	return nil
+}
+
+func pvpCommand(ctx *commandContext, args []string) error {
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "on", "yes", "true":
+			if err := ctx.user.SetPvP(true); err != nil {
+				return err
+			}
+		case "off", "no", "false":
+			if err := ctx.user.SetPvP(false); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Usage: /pvp %v", gameCommands["pvp"].Usage)
+		}
+	}
+
+	ctx.reply("%v", pvpSummary(ctx.user))
+	if isPvPZone(ctx.builder.World(), *ctx.user.Location()) {
+		ctx.reply("Anyone can fight anyone here")
+	}
+
+	return nil
+}
+
+func duelCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+
+	if len(args) == 0 {
+		duel, ok := user.Duel()
+		if !ok {
+			ctx.reply("You're not in a duel; /duel <user> to challenge someone")
+		} else if duel.Accepted {
+			ctx.reply("Dueling %v", duel.Other(user.Username()))
+		} else if duel.Challenger == user.Username() {
+			ctx.reply("Waiting for %v to accept your challenge", duel.Opponent)
+		} else {
+			ctx.reply("%v challenged you; /duel accept or /duel decline", duel.Challenger)
+		}
+
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "accept":
+		return user.AcceptDuel()
+	case "decline", "yield", "cancel":
+		duel, err := user.EndDuel()
+		if err != nil {
+			return err
+		}
+
+		other := ctx.builder.GetUser(duel.Other(user.Username()))
+		if duel.Accepted {
+			// Giving up counts as losing
+			user.RecordPvP(false, true)
+			other.RecordPvP(true, true)
+			ctx.builder.Chat(LogItem{Message: fmt.Sprintf("%v yielded to %v", user.Username(), other.Username()), MessageType: MESSAGEACTIVITY, Location: user.Location()})
+		} else {
+			other.Log(LogItem{Message: fmt.Sprintf("%v called off the duel", user.Username()), MessageType: MESSAGEACTIVITY})
+			ctx.reply("Called off the duel with %v", other.Username())
+		}
+
+		return nil
+	}
+
+	name, err := matchName(onlineUsernames(ctx, nil), args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("%v isn't online", args[0])
+	} else if err != nil {
+		return err
+	} else if err := user.ChallengeToDuel(name); err != nil {
+		return err
+	}
+
+	ctx.reply("Challenged %v to a duel", name)
+
+	return nil
+}
+
+func duelComplete(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	return append([]string{"accept", "decline", "yield"}, onlineUsernames(ctx, nil)...)
+}
+
+func dropCommand(ctx *commandContext, args []string) error {
+	item, err := findInventoryItem(ctx.user, args[0])
+	if err != nil {
+		return err
+	}
+
+	count := 1
+	if len(args) > 1 {
+		if strings.ToLower(args[1]) == "all" {
+			count = -1
+		} else if count, err = strconv.Atoi(args[1]); err != nil || count < 1 {
+			return fmt.Errorf("Usage: /drop <item> [count|all]")
+		}
+	}
+
+	dropped := 0
+	for _, carried := range ctx.user.InventoryItems() {
+		if dropped == count {
+			break
+		} else if carried.Name != item.Name {
+			continue
+		}
+
+		if dropFromInventory(ctx.builder, ctx.user, carried.ID) == nil {
+			break
+		}
+		dropped++
+	}
+
+	if dropped == 0 {
+		return fmt.Errorf("Can't drop %v here", item.Name)
+	}
+
+	ctx.reply("Dropped %v x%v", item.Name, dropped)
+	return nil
+}
+
+func equipCommand(ctx *commandContext, args []string) error {
+	item, err := findInventoryItem(ctx.user, args[0])
+	if err != nil {
+		return err
+	}
+
+	slots := ctx.user.EquippableSlots(item)
+	if len(slots) == 0 {
+		return fmt.Errorf("Can't equip %v", item.Name)
+	}
+
+	slot := slots[0]
+	if len(args) > 1 {
+		if slot, err = matchName(slots, args[1]); err == errNoMatch {
+			return fmt.Errorf("%v goes in %v", item.Name, strings.Join(slots, " or "))
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := equipFromInventory(ctx.user, slot, item); err != nil {
+		return err
+	}
+
+	ctx.reply("Equipped %v in %v", item.Name, slot)
+	return nil
+}
+
+func equipComplete(ctx *commandContext, args []string) []string {
+	if len(args) <= 1 {
+		return inventoryNames(ctx.user)
+	} else if len(args) == 2 {
+		item, err := findInventoryItem(ctx.user, args[0])
+		if err == nil {
+			return ctx.user.EquippableSlots(item)
+		}
+	}
+
+	return nil
+}
+
+func useCommand(ctx *commandContext, args []string) error {
+	item, err := findInventoryItem(ctx.user, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	return ctx.user.Use(item)
+}
+
+// recipeNames lists every recipe by name, for matching and tab completion
+func recipeNames() []string {
+	names := make([]string, 0, len(RecipeTypes))
+
+	for _, recipe := range recipeList() {
+		names = append(names, recipe.Name)
+	}
+
+	return names
+}
+
+func craftCommand(ctx *commandContext, args []string) error {
+	if len(args) == 0 {
+		ready := make([]string, 0)
+		for _, recipe := range recipeList() {
+			if canCraft(ctx.user, &recipe) == nil {
+				ready = append(ready, recipe.Name)
+			}
+		}
+
+		if len(ready) == 0 {
+			ctx.reply("You can't make anything here with what you're carrying")
+		} else {
+			ctx.reply("You can make: %v", strings.Join(ready, ", "))
+		}
+
+		return nil
+	}
+
+	query := strings.Join(args, " ")
+	name, err := matchName(recipeNames(), query)
+	if err == errNoMatch {
+		return fmt.Errorf("No recipe for %v", query)
+	} else if err != nil {
+		return err
+	}
+
+	for _, recipe := range RecipeTypes {
+		if recipe.Name == name {
+			return ctx.user.Craft(recipe.ID)
+		}
+	}
+
+	return nil
+}
+
+func craftComplete(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	return recipeNames()
+}
+
+// vendorFor finds the vendor to trade with: whoever the shop panel is open on, or else the
+// first vendor here
+func vendorFor(ctx *commandContext) (*Creature, Shop, error) {
+	if ctx.screen != nil && ctx.screen.shopActive {
+		return vendorHere(ctx.user, ctx.screen.shoppingAt)
+	}
+
+	for _, creature := range cellCreatures(ctx) {
+		if shop, ok := creature.Shop(); ok && creature.HP > 0 {
+			return creature, shop, nil
+		}
+	}
+
+	return nil, Shop{}, fmt.Errorf("There's nobody here to trade with")
+}
+
+func buyCommand(ctx *commandContext, args []string) error {
+	vendor, shop, err := vendorFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		stock := ctx.builder.World().ShopStock(vendor)
+		lines := []string{fmt.Sprintf("%v sells:", vendor.CreatureTypeStruct.Name)}
+		for _, selling := range shop.Sells {
+			if count, limited := stock[selling.Item]; limited {
+				lines = append(lines, fmt.Sprintf("  %v: %v (%v left)", selling.Item, coinString(selling.Price), count))
+			} else {
+				lines = append(lines, fmt.Sprintf("  %v: %v", selling.Item, coinString(selling.Price)))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("You have %v", coinString(ctx.user.Coins())))
+		ctx.replyLines(strings.Join(lines, "\n"))
+
+		return nil
+	}
+
+	query := strings.Join(args, " ")
+	name, err := matchName(shopItemNames(shop), query)
+	if err == errNoMatch {
+		return fmt.Errorf("%v doesn't sell %v", vendor.CreatureTypeStruct.Name, query)
+	} else if err != nil {
+		return err
+	}
+
+	return ctx.user.Buy(vendor.ID, name)
+}
+
+func buyComplete(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	if _, shop, err := vendorFor(ctx); err == nil {
+		return shopItemNames(shop)
+	}
+
+	return nil
+}
+
+// shopItemNames lists what a shop sells by name
+func shopItemNames(shop Shop) []string {
+	names := make([]string, 0, len(shop.Sells))
+
+	for _, selling := range shop.Sells {
+		names = append(names, selling.Item)
+	}
+
+	return names
+}
+
+func sellCommand(ctx *commandContext, args []string) error {
+	vendor, _, err := vendorFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	item, err := findInventoryItem(ctx.user, args[0])
+	if err != nil {
+		return err
+	}
+
+	count := 1
+	if len(args) > 1 {
+		if strings.ToLower(args[1]) == "all" {
+			count = -1
+		} else if count, err = strconv.Atoi(args[1]); err != nil || count < 1 {
+			return fmt.Errorf("Usage: /sell <item> [count|all]")
+		}
+	}
+
+	sold := 0
+	for _, carried := range ctx.user.InventoryItems() {
+		if sold == count {
+			break
+		} else if carried.Name != item.Name {
+			continue
+		}
+
+		if err := ctx.user.Sell(vendor.ID, carried.ID); err != nil {
+			if sold == 0 {
+				return err
+			}
+			break
+		}
+		sold++
+	}
+
+	return nil
+}
+
+// offeredItems lists the items a user has put up in their trade
+func offeredItems(user User, offer TradeOffer) []*InventoryItem {
+	offered := make([]*InventoryItem, 0, len(offer.Items))
+
+	for _, item := range user.InventoryItems() {
+		if offer.Offering(item.ID) {
+			offered = append(offered, item)
+		}
+	}
+
+	return offered
+}
+
+func tradeCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+
+	if len(args) == 0 {
+		trade, ok := user.Trade()
+		if !ok {
+			ctx.reply("You're not trading; /trade <user> to ask someone")
+		} else if !trade.Accepted && trade.Requester == user.Username() {
+			ctx.reply("Waiting for %v to agree to trade", trade.Partner)
+		} else if !trade.Accepted {
+			ctx.reply("%v wants to trade; /trade accept or /trade cancel", trade.Requester)
+		} else {
+			other := ctx.builder.GetUser(trade.Other(user.Username()))
+			for _, trader := range []User{user, other} {
+				offer := trade.Offer(trader.Username())
+				confirmed := ""
+				if offer.Confirmed {
+					confirmed = " (confirmed)"
+				}
+				ctx.reply("%v offers %v%v", trader.Username(), describeOffer(offer, trader.InventoryItems()), confirmed)
+			}
+		}
+
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "accept":
+		if err := user.AcceptTrade(); err != nil {
+			return err
+		}
+
+		if ctx.screen != nil {
+			ctx.screen.openTrade()
+		}
+
+		return nil
+	case "cancel", "decline":
+		trade, err := user.CancelTrade()
+		if err != nil {
+			return err
+		}
+
+		other := trade.Other(user.Username())
+		ctx.builder.GetUser(other).Log(LogItem{Message: fmt.Sprintf("%v called off the trade", user.Username()), MessageType: MESSAGEACTIVITY})
+		ctx.reply("Called off the trade with %v", other)
+
+		return nil
+	case "confirm":
+		return user.ConfirmTrade()
+	case "coins":
+		if len(args) < 2 {
+			return fmt.Errorf("Usage: /trade coins <amount>")
+		}
+
+		coins, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Usage: /trade coins <amount>")
+		}
+
+		return user.OfferCoins(coins)
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf("Usage: /trade add <item> [count|all]")
+		}
+
+		item, err := findInventoryItem(user, args[1])
+		if err != nil {
+			return err
+		}
+
+		count := 1
+		if len(args) > 2 {
+			if strings.ToLower(args[2]) == "all" {
+				count = -1
+			} else if count, err = strconv.Atoi(args[2]); err != nil || count < 1 {
+				return fmt.Errorf("Usage: /trade add <item> [count|all]")
+			}
+		}
+
+		trade, _ := user.Trade()
+		offer := trade.Offer(user.Username())
+		added := 0
+		for _, carried := range user.InventoryItems() {
+			if added == count {
+				break
+			} else if carried.Name != item.Name || offer.Offering(carried.ID) {
+				continue
+			}
+
+			if err := user.AddToTrade(carried.ID); err != nil {
+				return err
+			}
+			added++
+		}
+
+		if added == 0 {
+			return fmt.Errorf("You've already offered every %v you have", item.Name)
+		}
+
+		return nil
+	case "remove":
+		if len(args) < 2 {
+			return fmt.Errorf("Usage: /trade remove <item>")
+		}
+
+		trade, _ := user.Trade()
+		_, itemID, names := groupInventory(offeredItems(user, trade.Offer(user.Username())))
+		name, err := matchName(names, args[1])
+		if err == errNoMatch {
+			return fmt.Errorf("You haven't offered %v", args[1])
+		} else if err != nil {
+			return err
+		}
+
+		return user.RemoveFromTrade(itemID[name])
+	}
+
+	name, err := matchName(onlineUsernames(ctx, nil), args[0])
+	if err == errNoMatch {
+		return fmt.Errorf("%v isn't online", args[0])
+	} else if err != nil {
+		return err
+	} else if err := openTrade(user, name); err != nil {
+		return err
+	}
+
+	if ctx.screen != nil {
+		ctx.screen.openTrade()
+	}
+
+	if trade, ok := user.Trade(); ok && trade.Accepted {
+		ctx.reply("Trading with %v", name)
+	} else {
+		ctx.reply("Asked %v to trade", name)
+	}
+
+	return nil
+}
+
+func tradeComplete(ctx *commandContext, args []string) []string {
+	if len(args) <= 1 {
+		return append([]string{"accept", "add", "remove", "coins", "confirm", "cancel"}, onlineUsernames(ctx, nil)...)
+	} else if len(args) > 2 {
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		return inventoryNames(ctx.user)
+	case "remove":
+		trade, _ := ctx.user.Trade()
+		_, _, names := groupInventory(offeredItems(ctx.user, trade.Offer(ctx.user.Username())))
+		return names
+	}
+
+	return nil
+}
+
+// bankNames lists what's in a user's bank by name
+func bankNames(user User) []string {
+	_, _, names := groupInventory(user.BankItems())
+	return names
+}
+
+// moveItems deposits or withdraws up to count items with a name, -1 meaning all of them. It
+// returns how many moved.
+func moveItems(items []*InventoryItem, name string, count int, move func(string) error) (int, error) {
+	moved := 0
+
+	for _, item := range items {
+		if moved == count {
+			break
+		} else if item.Name != name {
+			continue
+		}
+
+		if err := move(item.ID); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+
+	return moved, nil
+}
+
+func bankCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+
+	if err := atBank(user); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		banked := user.BankItems()
+		ctx.reply("Your bank holds %v/%v items", len(banked), user.BankCapacity())
+
+		itemCount, _, names := groupInventory(banked)
+		for _, name := range names {
+			ctx.reply("  %v x%v", name, itemCount[name])
+		}
+
+		return nil
+	}
+
+	action := strings.ToLower(args[0])
+	switch action {
+	case "upgrade":
+		return user.UpgradeBank()
+	case "deposit", "withdraw":
+		if len(args) < 2 {
+			return fmt.Errorf("Usage: /bank %v <item> [count|all]", action)
+		}
+	default:
+		return fmt.Errorf("Usage: /bank [deposit|withdraw|upgrade]")
+	}
+
+	count := 1
+	if len(args) > 2 {
+		var err error
+		if strings.ToLower(args[2]) == "all" {
+			count = -1
+		} else if count, err = strconv.Atoi(args[2]); err != nil || count < 1 {
+			return fmt.Errorf("Usage: /bank %v <item> [count|all]", action)
+		}
+	}
+
+	items, move, names := user.InventoryItems(), user.Deposit, inventoryNames(user)
+	if action == "withdraw" {
+		items, move, names = user.BankItems(), user.Withdraw, bankNames(user)
+	}
+
+	name, err := matchName(names, args[1])
+	if err == errNoMatch && action == "withdraw" {
+		return fmt.Errorf("There's no %v in your bank", args[1])
+	} else if err == errNoMatch {
+		return fmt.Errorf("You aren't carrying %v", args[1])
+	} else if err != nil {
+		return err
+	}
+
+	moved, err := moveItems(items, name, count, move)
+	if moved == 0 {
+		return err
+	}
+
+	if action == "withdraw" {
+		ctx.reply("Withdrew %v x%v", name, moved)
+	} else {
+		ctx.reply("Deposited %v x%v", name, moved)
+	}
+
+	return err
+}
+
+func bankComplete(ctx *commandContext, args []string) []string {
+	if len(args) <= 1 {
+		return []string{"deposit", "withdraw", "upgrade"}
+	} else if len(args) > 2 {
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "deposit":
+		return inventoryNames(ctx.user)
+	case "withdraw":
+		return bankNames(ctx.user)
+	}
+
+	return nil
+}
+
+func classCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+
+	if len(args) == 0 {
+		ctx.reply("You're a %v (%v)", user.Title(), strengthClass(user))
+		if price := user.ClassRespecPrice(); price > 0 {
+			ctx.reply("/class change picks a new class for %v", coinString(price))
+		} else {
+			ctx.reply("/class change picks a new class")
+		}
+
+		return nil
+	} else if strings.ToLower(args[0]) != "change" {
+		return fmt.Errorf("Usage: /class [change]")
+	}
+
+	if err := user.StartRespec(); err != nil {
+		return err
+	}
+
+	ctx.reply("Back to character setup...")
+	return nil
+}
+
+// talentNames lists the talents in the user's tree their class can learn
+func talentNames(user User) []string {
+	names := make([]string, 0)
+
+	if tree, ok := talentTree(user); ok {
+		for _, talent := range talentList(user, tree) {
+			names = append(names, talent.Name)
+		}
+	}
+
+	return names
+}
+
+func talentsCommand(ctx *commandContext, args []string) error {
+	user := ctx.user
+
+	tree, ok := talentTree(user)
+	if !ok {
+		return fmt.Errorf("You have no talents to learn")
+	}
+
+	if len(args) == 0 {
+		ctx.reply("%v: %v to spend", tree.Name, talentPointString(user.TalentPoints()))
+
+		for _, talent := range talentList(user, tree) {
+			status := ""
+			if knowsTalent(user.Talents(), talent.ID) {
+				status = " (learned)"
+			} else if canLearnTalent(user, &talent) == nil {
+				status = " (ready)"
+			}
+			ctx.reply("  %v%v: %v", talent.Name, status, strings.Join(talentDetails(&talent), "; "))
+		}
+
+		if len(user.Talents()) > 0 {
+			ctx.reply("/talents respec forgets them all for %v", coinString(user.RespecPrice()))
+		}
+
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "respec":
+		return user.RespecTalents()
+	case "learn":
+		if len(args) < 2 {
+			return fmt.Errorf("Usage: /talents learn <talent>")
+		}
+	default:
+		return fmt.Errorf("Usage: /talents [learn <talent>|respec]")
+	}
+
+	query := strings.Join(args[1:], " ")
+	name, err := matchName(talentNames(user), query)
+	if err == errNoMatch {
+		return fmt.Errorf("There's no %v talent for you", query)
+	} else if err != nil {
+		return err
+	}
+
+	for _, talent := range talentList(user, tree) {
+		if talent.Name == name {
+			return user.LearnTalent(talent.ID)
+		}
+	}
+
+	return nil
+}
+
+func talentsComplete(ctx *commandContext, args []string) []string {
+	if len(args) <= 1 {
+		return []string{"learn", "respec"}
+	} else if strings.ToLower(args[0]) == "learn" {
+		return talentNames(ctx.user)
+	}
+
+	return nil
+}
+
+// playersHere lists the other users in the same cell
+func playersHere(ctx *commandContext) []User {
+	players := make([]User, 0)
+
+	for _, other := range ctx.builder.World().OnlineUsers() {
+		if other.Username() != ctx.user.Username() && *other.Location() == *ctx.user.Location() {
+			players = append(players, other)
+		}
+	}
+
+	return players
+}
+
+// findTarget finds a creature here, or failing that a player here, to attack
+func findTarget(ctx *commandContext, query string) (interface{}, string, error) {
+	creature, err := findCreature(ctx, query)
+	if err == nil {
+		if ctx.screen != nil {
+			ctx.screen.selectedCreature = creature.ID
+			ctx.screen.selectedUser = ""
+		}
+
+		return creature, creature.CreatureTypeStruct.Name, nil
+	}
+
+	players := playersHere(ctx)
+	names := make([]string, 0, len(players))
+	for _, player := range players {
+		names = append(names, player.Username())
+	}
+
+	name, playerErr := matchName(names, query)
+	if playerErr == errNoMatch {
+		return nil, "", err
+	} else if playerErr != nil {
+		return nil, "", playerErr
+	}
+
+	for _, player := range players {
+		if player.Username() != name {
+			continue
+		} else if err := canFight(ctx.builder.World(), ctx.user, player); err != nil {
+			return nil, "", err
+		}
+
+		if ctx.screen != nil {
+			ctx.screen.selectedUser = name
+			ctx.screen.selectedCreature = ""
+		}
+
+		return player, name, nil
+	}
+
+	return nil, "", err
+}
+
+func attackCommand(ctx *commandContext, args []string) error {
+	target, targetName, err := findTarget(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	attacks := ctx.user.Attacks()
+	names := make([]string, 0, len(attacks))
+	for _, attack := range attacks {
+		names = append(names, attack.Attack.Name)
+	}
+
+	name, err := matchName(names, strings.Join(args[1:], " "))
+	if err == errNoMatch {
+		return fmt.Errorf("You don't know an attack called %v", strings.Join(args[1:], " "))
+	} else if err != nil {
+		return err
+	}
+
+	for _, attack := range attacks {
+		if attack.Attack.Name != name {
+			continue
+		}
+
+		charge, _ := ctx.user.Charge()
+		if charge < attack.Attack.Charge {
+			return fmt.Errorf("%v isn't charged yet (%v/%v)", name, charge, attack.Attack.Charge)
+		}
+
+		musteredAttack := ctx.user.MusterAttack(name)
+		if musteredAttack == nil {
+			return fmt.Errorf("Not enough AP/RP/MP for %v", name)
+		}
+
+		ctx.reply("Attacking %v with %v", targetName, name)
+		ctx.builder.Attack(ctx.user, target, musteredAttack)
+		break
+	}
+
+	return nil
+}
+
+func attackComplete(ctx *commandContext, args []string) []string {
+	if len(args) <= 1 {
+		names := creatureNames(ctx, args)
+		for _, player := range playersHere(ctx) {
+			names = append(names, player.Username())
+		}
+		return names
+	}
+
+	names := make([]string, 0)
+	for _, attack := range ctx.user.Attacks() {
+		names = append(names, attack.Attack.Name)
+	}
+
+	return names
+}
+
+func goCommand(ctx *commandContext, args []string) error {
+	direction, ok := directionNames[strings.ToLower(args[0])]
+	if !ok {
+		return fmt.Errorf("Which way is %v? Try north, south, east or west", args[0])
+	}
+
+	steps := 1
+	if len(args) > 1 {
+		var err error
+		if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+			return fmt.Errorf("Usage: /go <direction> [steps]")
+		} else if steps > maxSteps {
+			steps = maxSteps
+		}
+	}
+
+	moves := map[Direction]func(User){
+		DIRECTIONNORTH: ctx.builder.MoveUserNorth,
+		DIRECTIONEAST:  ctx.builder.MoveUserEast,
+		DIRECTIONSOUTH: ctx.builder.MoveUserSouth,
+		DIRECTIONWEST:  ctx.builder.MoveUserWest}
+
+	for step := 0; step < steps; step++ {
+		before := *ctx.user.Location()
+		moves[direction](ctx.user)
+
+		if *ctx.user.Location() == before {
+			return fmt.Errorf("Can't go any further %v", directionLabels[direction])
+		} else if ctx.user.HP() == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+func goComplete(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	return directionLabels
+}
+
+func commandNames(ctx *commandContext, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	names := make([]string, 0, len(gameCommands))
+	for name := range gameCommands {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func init() {
+	gameCommands = make(map[string]*gameCommand)
+	gameCommandList = make([]*gameCommand, 0)
+
+	registerCommand(&gameCommand{Name: "help", Aliases: []string{"?"}, Usage: "[command]", Help: "List commands, or explain one", Run: helpCommand, Complete: commandNames})
+	registerCommand(&gameCommand{Name: "look", Aliases: []string{"l"}, Help: "Describe where you are and what's here", Run: lookCommand})
+	registerCommand(&gameCommand{Name: "who", Help: "List who's online", Run: whoCommand})
+	registerCommand(&gameCommand{Name: "whisper", Aliases: []string{"w", "tell"}, Usage: "<user> <message>", Help: "Say something only one user hears", MinArgs: 2, Run: whisperCommand, Complete: onlineUsernames})
+	registerCommand(&gameCommand{Name: "say", Aliases: []string{"'"}, Usage: "<message>", Help: "Speak to anyone nearby", MinArgs: 1, Run: speakCommand(CHANNELSAY)})
+	registerCommand(&gameCommand{Name: "shout", Aliases: []string{"yell"}, Usage: "<message>", Help: "Speak to anyone in earshot, further than /say carries", MinArgs: 1, Run: speakCommand(CHANNELSHOUT)})
+	registerCommand(&gameCommand{Name: "global", Aliases: []string{"g"}, Usage: "<message>", Help: "Speak to everyone online", MinArgs: 1, Run: speakCommand(CHANNELGLOBAL)})
+	registerCommand(&gameCommand{Name: "region", Aliases: []string{"r"}, Usage: "<message>", Help: "Speak to everyone in this region", MinArgs: 1, Run: speakCommand(CHANNELREGION)})
+	registerCommand(&gameCommand{Name: "p", Usage: "<message>", Help: "Speak to your party", MinArgs: 1, Run: speakCommand(CHANNELPARTY)})
+	registerCommand(&gameCommand{Name: "admin", Usage: "<message>", Help: "Speak to the other admins", MinArgs: 1, Run: speakCommand(CHANNELADMIN)})
+	registerCommand(&gameCommand{Name: "channel", Aliases: []string{"channels", "ch"}, Usage: "[channel]", Help: "Pick the channel chat goes to, or list channels", Run: channelCommand, Complete: channelComplete})
+	registerCommand(&gameCommand{Name: "join", Usage: "<channel>", Help: "Listen to a channel you left", MinArgs: 1, Run: joinCommand, Complete: channelComplete})
+	registerCommand(&gameCommand{Name: "leave", Usage: "<channel>", Help: "Stop listening to a channel", MinArgs: 1, Run: leaveCommand, Complete: channelComplete})
+	registerCommand(&gameCommand{Name: "party", Aliases: []string{"group"}, Usage: "[invite|accept|decline|leave|kick|lead|loot] [user|mode]", Help: "Show your party, or invite, answer invitations, leave, or as leader kick, hand over the lead or set the loot mode", Run: partyCommand, Complete: partyComplete})
+	registerCommand(&gameCommand{Name: "pvp", Usage: "[on|off]", Help: "Show or change whether you'll fight other players", Run: pvpCommand})
+	registerCommand(&gameCommand{Name: "duel", Usage: "[user|accept|decline|yield]", Help: "Challenge someone to a duel, answer a challenge, or give up", Run: duelCommand, Complete: duelComplete})
+	registerCommand(&gameCommand{Name: "drop", Usage: "<item> [count|all]", Help: "Drop items on the ground", MinArgs: 1, Run: dropCommand, Complete: itemNames})
+	registerCommand(&gameCommand{Name: "equip", Aliases: []string{"wear", "wield"}, Usage: "<item> [slot]", Help: "Equip an item from your inventory", MinArgs: 1, Run: equipCommand, Complete: equipComplete})
+	registerCommand(&gameCommand{Name: "use", Usage: "<item>", Help: "Use a potion, scroll or other item", MinArgs: 1, Run: useCommand, Complete: itemNames})
+	registerCommand(&gameCommand{Name: "craft", Aliases: []string{"make"}, Usage: "[recipe]", Help: "Make something from a recipe, or list what you can make here", Run: craftCommand, Complete: craftComplete})
+	registerCommand(&gameCommand{Name: "buy", Aliases: []string{"shop"}, Usage: "[item]", Help: "Buy something from a vendor here, or list what they sell", Run: buyCommand, Complete: buyComplete})
+	registerCommand(&gameCommand{Name: "sell", Usage: "<item> [count|all]", Help: "Sell something you're carrying to a vendor here", MinArgs: 1, Run: sellCommand, Complete: itemNames})
+	registerCommand(&gameCommand{Name: "trade", Usage: "[user|accept|add|remove|coins|confirm|cancel]", Help: "Trade items and coin with someone here, or show the trade you're in", Run: tradeCommand, Complete: tradeComplete})
+	registerCommand(&gameCommand{Name: "bank", Aliases: []string{"stash"}, Usage: "[deposit|withdraw|upgrade] [item] [count|all]", Help: "Show your bank, move items in and out of it, or buy more room; only at a bank", Run: bankCommand, Complete: bankComplete})
+	registerCommand(&gameCommand{Name: "class", Usage: "[change]", Help: "Show your class, or go back to character setup to pick a new one", Run: classCommand})
+	registerCommand(&gameCommand{Name: "talents", Aliases: []string{"talent"}, Usage: "[learn <talent>|respec]", Help: "Show your talent tree, spend talent points, or pay to forget your talents and start over", Run: talentsCommand, Complete: talentsComplete})
+	registerCommand(&gameCommand{Name: "attack", Aliases: []string{"a", "kill"}, Usage: "<creature> <attack>", Help: "Attack a creature or player here, by name or number", MinArgs: 2, Run: attackCommand, Complete: attackComplete})
+	registerCommand(&gameCommand{Name: "go", Aliases: []string{"walk"}, Usage: "<direction> [steps]", Help: "Walk north, south, east or west", MinArgs: 1, Run: goCommand, Complete: goComplete})
+	registerCommand(&gameCommand{Name: "stats", Aliases: []string{"sheet", "score"}, Help: "Show your stats", Run: statsCommand})
+}
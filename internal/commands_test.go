@@ -0,0 +1,109 @@
+package mud
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		want     []string
+		wantOpen bool
+		wantErr  bool
+	}{
+		{"", []string{}, false, false},
+		{"look", []string{"look"}, true, false},
+		{"look ", []string{"look"}, false, false},
+		{"equip  \"Iron Sword\" hand", []string{"equip", "Iron Sword", "hand"}, true, false},
+		{"equip \"Iron", []string{"equip", "Iron"}, true, true},
+		{"say \"\"", []string{"say", ""}, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			words, open, err := splitCommandLine(test.line)
+			if (err != nil) != test.wantErr || open != test.wantOpen {
+				t.Fatalf("splitCommandLine(%q) = %q, %v, %v", test.line, words, open, err)
+			}
+			if strings.Join(words, "|") != strings.Join(test.want, "|") || len(words) != len(test.want) {
+				t.Fatalf("splitCommandLine(%q) = %q, want %q", test.line, words, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchName(t *testing.T) {
+	names := []string{"Iron Sword", "Iron Shield", "Healing Potion", "Sword"}
+
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"sword", "Sword", false},
+		{"heal", "Healing Potion", false},
+		{"potion", "Healing Potion", false},
+		{"iron", "", true},
+		{"axe", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := matchName(names, test.query)
+			if got != test.want || (err != nil) != test.wantErr {
+				t.Fatalf("matchName(%v) = %v, %v, want %v", test.query, got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	world := newTestWorld(t)
+	ctx := &commandContext{builder: NewWorldBuilder(world), user: newTestUser(t, world, "typist")}
+
+	tests := []struct {
+		line        string
+		want        string
+		wantChoices bool
+	}{
+		{"loo", "look ", false},
+		{"xyzzy", "xyzzy", false},
+		{"go no", "go north ", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			got, choices := completeCommand(ctx, test.line)
+			if got != test.want || (len(choices) > 0) != test.wantChoices {
+				t.Fatalf("completeCommand(%q) = %q, %v, want %q", test.line, got, choices, test.want)
+			}
+		})
+	}
+}
+
+func TestRunCommandReplies(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "typist")
+	ctx := &commandContext{builder: NewWorldBuilder(world), user: user}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"/xyzzy", "Unknown command /xyzzy"},
+		{"/drop", "Usage: /drop"},
+		{"/say \"unfinished", "Missing closing quote"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			runCommand(ctx, test.line)
+
+			log := user.GetLog()
+			if len(log) == 0 || !strings.HasPrefix(log[0].Message, test.want) {
+				t.Fatalf("runCommand(%q) replied %+v, want %q", test.line, log, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RecipeTypes is a mapping of string IDs to crafting recipes
+var RecipeTypes map[string]Recipe
+
+// Recipe turns some items into others
+type Recipe struct {
+	ID          string   `json:"-"`
+	Name        string   `json:""`
+	Description string   `json:",omitempty"`
+	Inputs      []string `json:""`           // Names of items in items.json used up, repeated to need more than one
+	Outputs     []string `json:""`           // Names of items in items.json made
+	Skill       string   `json:",omitempty"` // Cunning, Orderly or Creative; the user needs it as their primary or secondary skill
+	Stations    []string `json:",omitempty"` // IDs of terrain the user has to be standing on, like a fairy circle; empty means anywhere
+	XP          uint64   `json:",omitempty"`
+}
+
+// CraftingInfo handles making things out of what a user is carrying
+type CraftingInfo interface {
+	Craft(string) error
+}
+
+// Needs counts how many of each item the recipe uses up
+func (recipe *Recipe) Needs() map[string]int {
+	needs := make(map[string]int)
+
+	for _, input := range recipe.Inputs {
+		needs[input]++
+	}
+
+	return needs
+}
+
+// AtStation checks whether a cell's terrain is somewhere the recipe can be made
+func (recipe *Recipe) AtStation(cellInfo *CellInfo) bool {
+	if len(recipe.Stations) == 0 {
+		return true
+	} else if cellInfo == nil {
+		return false
+	}
+
+	for _, station := range recipe.Stations {
+		if cellInfo.TerrainID == station {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placeholderRE matches where a terrain name has its region's name filled in
+var placeholderRE = regexp.MustCompile(`(\s+of)?\s*%s\s*`)
+
+// stationNames describes where a recipe can be made, going by the terrain names
+func (recipe *Recipe) stationNames() string {
+	names := make([]string, 0, len(recipe.Stations))
+
+	for _, station := range recipe.Stations {
+		name := station
+		if terrain, ok := CellTypes[station]; ok && terrain.Name != "" {
+			name = strings.TrimSpace(placeholderRE.ReplaceAllString(terrain.Name, " "))
+		}
+		names = append(names, name)
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// itemList describes a list of item names, counting up repeats
+func itemList(names []string) string {
+	counts := make(map[string]int)
+	order := make([]string, 0)
+
+	for _, name := range names {
+		if counts[name] == 0 {
+			order = append(order, name)
+		}
+		counts[name]++
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, name := range order {
+		if counts[name] > 1 {
+			parts = append(parts, fmt.Sprintf("%v x%v", name, counts[name]))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// canCraft says why a user can't make a recipe right now, if they can't
+func canCraft(user User, recipe *Recipe) error {
+	if !hasSkill(user, recipe.Skill) {
+		return fmt.Errorf("%v takes a %v character", recipe.Name, recipe.Skill)
+	} else if !recipe.AtStation(user.Cell().CellInfo()) {
+		return fmt.Errorf("%v has to be made at %v", recipe.Name, recipe.stationNames())
+	}
+
+	carrying := carriedItems(user.InventoryItems())
+	needs := recipe.Needs()
+	for _, input := range recipe.Inputs {
+		if need := needs[input]; carrying[input] < need {
+			return fmt.Errorf("%v needs %v x%v; you have %v", recipe.Name, input, need, carrying[input])
+		}
+	}
+
+	return nil
+}
+
+// recipeList is every recipe sorted by name, for the crafting panel and tab completion
+func recipeList() []Recipe {
+	recipes := make([]Recipe, 0, len(RecipeTypes))
+
+	for _, recipe := range RecipeTypes {
+		recipes = append(recipes, recipe)
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].Name < recipes[j].Name })
+
+	return recipes
+}
+
+func loadRecipeTypes(recipeInfoFile string) {
+	data, err := ioutil.ReadFile(recipeInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &RecipeTypes)
+	}
+
+	for k, v := range RecipeTypes {
+		v.ID = k
+		RecipeTypes[k] = v
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", recipeInfoFile, err)
+	}
+}
+
+func init() {
+	RecipeTypes = make(map[string]Recipe)
+}
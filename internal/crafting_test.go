@@ -0,0 +1,70 @@
+package mud
+
+import "testing"
+
+func TestCraft(t *testing.T) {
+	tests := []struct {
+		name       string
+		recipe     string
+		primary    byte
+		carrying   []string
+		wantErr    bool
+		wantOutput string
+	}{
+		{"makes it", "bone-meal", ORDERLYPRIMARY, []string{"Skull"}, false, "Bone Meal"},
+		{"missing inputs", "whetstone", CUNNINGPRIMARY, []string{"Broken Rock"}, true, ""},
+		{"wrong skill", "whetstone", ORDERLYPRIMARY, []string{"Broken Rock", "Broken Rock"}, true, ""},
+		{"away from the station", "keen-sword", CUNNINGPRIMARY, []string{"Simple Sword", "Whetstone"}, true, ""},
+		{"no such recipe", "perpetual-motion", CUNNINGPRIMARY, nil, true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "crafter")
+			user.SetSkills(test.primary, 0)
+			for _, name := range test.carrying {
+				giveTestItem(t, user, name)
+			}
+
+			err := user.Craft(test.recipe)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Craft(%v) = %v, want error %v", test.recipe, err, test.wantErr)
+			}
+
+			carrying := carriedItems(user.InventoryItems())
+			if test.wantErr {
+				if len(user.InventoryItems()) != len(test.carrying) {
+					t.Fatalf("failed craft left %v, want %v untouched", carrying, test.carrying)
+				}
+				return
+			}
+
+			for _, input := range test.carrying {
+				if carrying[input] != 0 {
+					t.Fatalf("still carrying %v after crafting", input)
+				}
+			}
+			if made := carrying[test.wantOutput]; made != 2 && made != 4 {
+				t.Fatalf("made %v %v, want one or two batches of 2", made, test.wantOutput)
+			}
+		})
+	}
+}
+
+func TestItemList(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"Skull"}, "Skull"},
+		{[]string{"Bone Meal", "Skull", "Bone Meal"}, "Bone Meal x2, Skull"},
+	}
+
+	for _, test := range tests {
+		if got := itemList(test.names); got != test.want {
+			t.Fatalf("itemList(%v) = %q, want %q", test.names, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package mud
+
+import (
+	"fmt"
+	"log"
+)
+
+// creatureWakeRadius is how far from an online user creatures keep acting, so they can
+// wander into view and give chase
+const creatureWakeRadius = 8
+
+// distance is the straight-line distance between two points, rounded down
+func distance(p, q Point) uint {
+	v := p.Vector(q)
+	return v.Magnitude()
+}
+
+// distanceSquared is for comparing distances without rounding
+func distanceSquared(p, q Point) int {
+	v := p.Vector(q)
+	return v.X*v.X + v.Y*v.Y
+}
+
+// directionsToward lists the cardinal steps that follow a Bresenham line from one point
+// to another, best first
+func directionsToward(from, to Point) []Direction {
+	if from == to {
+		return nil
+	}
+
+	line := make([]Point, 0)
+	from.Bresenham(to, func(p Point) error {
+		line = append(line, p)
+		return nil
+	})
+
+	// Bresenham walks left to right, so the line may need turning around
+	if len(line) < 2 {
+		return nil
+	} else if line[0] != from {
+		for i, j := 0, len(line)-1; i < j; i, j = i+1, j-1 {
+			line[i], line[j] = line[j], line[i]
+		}
+	}
+
+	step := from.Vector(line[1])
+	whole := from.Vector(to)
+	xStep, yStep := Vector{X: sign(step.X)}, Vector{Y: sign(step.Y)}
+
+	directions := make([]Direction, 0, 2)
+	if step.X != 0 && step.Y != 0 && abs(whole.Y) > abs(whole.X) {
+		xStep, yStep = yStep, xStep
+	}
+
+	for _, v := range []Vector{xStep, yStep} {
+		if d, ok := DirectionForVector[v]; ok {
+			directions = append(directions, d)
+		}
+	}
+
+	return directions
+}
+
+// directionsAway lists the cardinal steps that put more distance between a point and a threat, best first
+func directionsAway(from, threat Point) []Direction {
+	directions := make([]Direction, 0, 4)
+	current := distanceSquared(from, threat)
+
+	for _, d := range []Direction{DIRECTIONNORTH, DIRECTIONEAST, DIRECTIONSOUTH, DIRECTIONWEST} {
+		if distanceSquared(from.Neighbor(d), threat) > current {
+			directions = append(directions, d)
+		}
+	}
+
+	// Prefer whichever step gains the most ground
+	for i := 1; i < len(directions); i++ {
+		for j := i; j > 0 && distanceSquared(from.Neighbor(directions[j]), threat) > distanceSquared(from.Neighbor(directions[j-1]), threat); j-- {
+			directions[j], directions[j-1] = directions[j-1], directions[j]
+		}
+	}
+
+	return directions
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func sign(i int) int {
+	if i < 0 {
+		return -1
+	} else if i > 0 {
+		return 1
+	}
+	return 0
+}
+
+// canStep checks whether something can walk one cell over, stopping at walls and blocked exits
+func canStep(w World, from Point, d Direction) bool {
+	here := w.CellAtPoint(from).CellInfo()
+	if here == nil || here.ExitBlocks&BitForDirection[d] != 0 {
+		return false
+	}
+
+	there := w.CellAtPoint(from.Neighbor(d)).CellInfo()
+	if there == nil || there.TerrainData.Blocking || there.ExitBlocks&BitForDirection[Opposite(d)] != 0 {
+		return false
+	}
+
+	return true
+}
+
+// creatureBehave lets a creature in an active cell flee, chase or wander. It returns true if
+// the creature moved, which uses up its turn.
+func (w *dbWorld) creatureBehave(cell *recentCellInfo, creature *Creature, users []User, now int64) bool {
+	behavior := creature.CreatureTypeStruct.Behavior
+
+	if behavior.Leash == 0 {
+		return false
+	}
+
+	interval := behavior.MoveEvery
+	if interval <= 0 {
+		interval = 1
+	}
+	if effectsSlow(creature.Effects, now) {
+		interval *= 2
+	}
+
+	if now-cell.lastCreatureMove[creature.ID] < interval {
+		return false
+	}
+
+	here := creature.Location()
+	home := creature.Home()
+
+	var nearest User
+	nearestDistance := uint(0)
+	for _, user := range users {
+		if user.HP() == 0 {
+			continue
+		}
+
+		// Cunning users can get closer before anything notices them
+		userDistance := distance(here, *user.Location())
+		if userDistance+stealthRange(user) <= behavior.Aggro && (nearest == nil || userDistance < nearestDistance) {
+			nearest = user
+			nearestDistance = userDistance
+		}
+	}
+
+	var directions []Direction
+	message := ""
+
+	if nearest != nil && float32(creature.HP) < behavior.Flee*float32(creature.CreatureTypeStruct.MaxHP) {
+		directions = directionsAway(here, *nearest.Location())
+		message = "Flees!"
+	} else if nearest != nil && creature.CreatureTypeStruct.Hostile && behavior.Aggro > 0 {
+		if nearestDistance == 0 {
+			// Already face to face; stay and fight
+			return false
+		}
+
+		directions = directionsToward(here, *nearest.Location())
+		message = fmt.Sprintf("Closes in on %v!", nearest.Username())
+	} else if distance(here, home) > behavior.Leash {
+		directions = directionsToward(here, home)
+	} else if rng := regionRand(w, fmt.Sprintf("wander:%v:%v", creature.ID, now), here); rng.Float32() < behavior.Wander {
+		for _, i := range rng.Perm(4) {
+			directions = append(directions, Direction(i))
+		}
+	}
+
+	for _, d := range directions {
+		to := here.Neighbor(d)
+		toHome := distance(to, home)
+
+		// Past the leash it can only head back
+		if toHome > behavior.Leash && toHome >= distance(here, home) {
+			continue
+		}
+
+		if !canStep(w, here, d) {
+			continue
+		}
+
+		if !w.moveCreature(cell, creature, to, now) {
+			return false
+		}
+
+		if len(message) > 0 {
+			for _, location := range []Point{here, to} {
+				place := location
+				w.Chat(LogItem{Author: creature.CreatureTypeStruct.Name, Message: message, MessageType: MESSAGEACTIVITY, Location: &place})
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// moveCreature moves a creature one cell over, in the database and in the active cell cache
+func (w *dbWorld) moveCreature(cell *recentCellInfo, creature *Creature, to Point, now int64) bool {
+	from := creature.Location()
+
+	err := w.store.Update(func(tx Tx) error {
+		creatures := creatureRepo(tx)
+
+		if err := creatures.Unplace(from, creature.ID); err != nil {
+			return err
+		}
+
+		moved := *creature
+		moved.X, moved.Y = to.X, to.Y
+
+		if err := creatures.Put(&moved); err != nil {
+			return err
+		}
+
+		return creatures.Place(to, creature.ID)
+	})
+
+	if err != nil {
+		log.Printf("Can't move creature %v: %v", creature.ID, err)
+		return false
+	}
+
+	creature.X, creature.Y = to.X, to.Y
+
+	remaining := make([]*Creature, 0, len(cell.creatures))
+	for _, other := range cell.creatures {
+		if other.ID != creature.ID {
+			remaining = append(remaining, other)
+		}
+	}
+	cell.creatures = remaining
+
+	lastAction, hasLastAction := cell.lastCreatureAction[creature.ID]
+	delete(cell.lastCreatureAction, creature.ID)
+	delete(cell.desiredCreatureCharge, creature.ID)
+	delete(cell.lastCreatureMove, creature.ID)
+
+	if record, ok := w.activeCellCache.Load(string(to.Bytes())); ok {
+		if destination, ok := record.(*recentCellInfo); ok {
+			creatures := make([]*Creature, 0, len(destination.creatures)+1)
+			destination.creatures = append(append(creatures, destination.creatures...), creature)
+			destination.lastCreatureMove[creature.ID] = now
+			if hasLastAction {
+				destination.lastCreatureAction[creature.ID] = lastAction
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,69 @@
+package mud
+
+import "testing"
+
+func TestDirectionsToward(t *testing.T) {
+	from := Point{X: 10, Y: 10}
+
+	tests := []struct {
+		name string
+		to   Point
+		want []Direction
+	}{
+		{"same cell", from, nil},
+		{"straight north", Point{X: 10, Y: 5}, []Direction{DIRECTIONNORTH}},
+		{"straight east", Point{X: 15, Y: 10}, []Direction{DIRECTIONEAST}},
+		{"mostly north", Point{X: 12, Y: 4}, []Direction{DIRECTIONNORTH, DIRECTIONEAST}},
+		{"shallow slope west", Point{X: 4, Y: 12}, []Direction{DIRECTIONWEST}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := directionsToward(from, test.to)
+			if !sameDirections(got, test.want) {
+				t.Fatalf("directionsToward(%v, %v) = %v, want %v", from, test.to, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDirectionsAway(t *testing.T) {
+	from := Point{X: 10, Y: 10}
+
+	tests := []struct {
+		name   string
+		threat Point
+		best   Direction
+		never  Direction
+	}{
+		{"threat to the south", Point{X: 10, Y: 12}, DIRECTIONNORTH, DIRECTIONSOUTH},
+		{"threat to the west", Point{X: 7, Y: 10}, DIRECTIONEAST, DIRECTIONWEST},
+		{"threat mostly north", Point{X: 11, Y: 5}, DIRECTIONSOUTH, DIRECTIONNORTH},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := directionsAway(from, test.threat)
+			if len(got) == 0 || got[0] != test.best {
+				t.Fatalf("directionsAway(%v, %v) = %v, want %v first", from, test.threat, got, test.best)
+			}
+			for _, d := range got {
+				if d == test.never {
+					t.Fatalf("directionsAway(%v, %v) = %v, which steps toward the threat", from, test.threat, got)
+				}
+			}
+		})
+	}
+}
+
+func sameDirections(a, b []Direction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"time"
 )
 
 // CreatureTypes is a mapping of string IDs to creature types
@@ -16,35 +17,89 @@ type CreatureSpawn struct {
 	Cluster     float32 `json:""` // 0-1.0
 }
 
+// CreatureBehavior describes how a creature moves around on its own. A creature with no
+// Leash stays in the cell it spawned in.
+type CreatureBehavior struct {
+	Wander    float32 `json:",omitempty"` // 0-1.0 chance of taking an idle step when it can move
+	Leash     uint    `json:",omitempty"` // How far from its spawn point it will go
+	Aggro     uint    `json:",omitempty"` // How close a user has to be before it notices them
+	Flee      float32 `json:",omitempty"` // 0-1.0 fraction of max HP below which it runs from users it notices
+	MoveEvery int64   `json:",omitempty"` // Seconds between steps, 0 means every second
+}
+
 // CreatureType is the type of creature (Hostile: true is monster, false is NPC)
 type CreatureType struct {
-	ID        string     `json:"-"`
-	Name      string     `json:""`
-	Hostile   bool       `json:""`
-	MaxHP     uint64     `json:""`
-	MaxMP     uint64     `json:""`
-	MaxAP     uint64     `json:""`
-	MaxRP     uint64     `json:""`
-	Attacks   []Attack   `json:""`
-	ItemDrops []ItemDrop `json:""` // List of items and probabilities of them appearing in each terrain type
+	ID             string           `json:"-"`
+	Name           string           `json:""`
+	Hostile        bool             `json:""`
+	MaxHP          uint64           `json:""`
+	MaxMP          uint64           `json:""`
+	MaxAP          uint64           `json:""`
+	MaxRP          uint64           `json:""`
+	Attacks        []Attack         `json:""`
+	CounterAttacks []Attack         `json:",omitempty"` // Tried in order when the creature takes damage
+	ItemDrops      []ItemDrop       `json:""`           // List of items and probabilities of them appearing in each terrain type
+	Behavior       CreatureBehavior `json:",omitempty"`
+	Dialogue       string           `json:",omitempty"` // ID of the NPC's conversation in dialogue.json
+	Boss           *BossInfo        `json:",omitempty"` // Phases and unique drops, for bosses
+	Shop           string           `json:",omitempty"` // ID of the NPC's shop in shops.json
+	Coins          uint64           `json:",omitempty"` // Most coins it drops when killed; at least half that
+	Banker         bool             `json:",omitempty"` // Users can get to their bank wherever the NPC is
 }
 
 // Creature is an instance of a Creature
 type Creature struct {
-	ID                 string       `json:""`
-	CreatureType       string       `json:""`
-	X                  uint32       `json:""`
-	Y                  uint32       `json:""`
-	HP                 uint64       `json:""`
-	AP                 uint64       `json:""`
-	RP                 uint64       `json:""`
-	MP                 uint64       `json:""`
-	CreatureTypeStruct CreatureType `json:"-"`
-	Charge             int64        `json:"-"`
+	ID                 string            `json:""`
+	CreatureType       string            `json:""`
+	X                  uint32            `json:""`
+	Y                  uint32            `json:""`
+	SpawnX             uint32            `json:",omitempty"`
+	SpawnY             uint32            `json:",omitempty"`
+	HP                 uint64            `json:""`
+	AP                 uint64            `json:""`
+	RP                 uint64            `json:""`
+	MP                 uint64            `json:""`
+	CounterCooldowns   map[string]int64  `json:",omitempty"` // Counterattack name -> unix time it's usable again
+	Countered          int64             `json:",omitempty"` // Unix time it last spent its charge on a counterattack
+	Effects            []ActiveEffect    `json:",omitempty"`
+	Damage             map[string]uint64 `json:",omitempty"` // Username -> damage they've done, for sharing out XP
+	Region             uint64            `json:",omitempty"` // ID of the region it spawned in, for capping respawns
+	Phase              int               `json:",omitempty"` // Index of the boss phase it's reached
+	CreatureTypeStruct CreatureType      `json:"-"`
+	Charge             int64             `json:"-"`
 	maxCharge          int64
 	world              World
 }
 
+// Location is the cell the creature is in
+func (creature *Creature) Location() Point {
+	return Point{X: creature.X, Y: creature.Y}
+}
+
+// recordDamage notes damage a user did, up to what the creature had left to lose
+func (creature *Creature) recordDamage(username string, damage uint64) {
+	if damage > creature.HP {
+		damage = creature.HP
+	}
+
+	if damage == 0 {
+		return
+	} else if creature.Damage == nil {
+		creature.Damage = make(map[string]uint64)
+	}
+
+	creature.Damage[username] += damage
+}
+
+// Home is the point the creature spawned at, which it won't stray too far from
+func (creature *Creature) Home() Point {
+	if creature.SpawnX == 0 && creature.SpawnY == 0 {
+		return creature.Location()
+	}
+
+	return Point{X: creature.SpawnX, Y: creature.SpawnY}
+}
+
 // StatPoints is for StatPointable
 func (creature *Creature) StatPoints() StatPoints {
 	return StatPoints{
@@ -61,6 +116,72 @@ func (creature *Creature) FullStatPoints() FullStatPoints {
 		Trample:    0}
 }
 
+// AddEffect puts a status effect on the creature
+func (creature *Creature) AddEffect(id string) {
+	creature.Effects = addEffect(creature.Effects, id, time.Now().Unix())
+}
+
+// AbsorbDamage lets the creature's shields soak up damage, returning what gets through
+func (creature *Creature) AbsorbDamage(damage uint64) uint64 {
+	creature.Effects, damage = absorbDamage(creature.Effects, damage)
+	return damage
+}
+
+// ChargePoints recovers a point of AP, RP and MP, the way users do every tick
+func (creature *Creature) ChargePoints() {
+	creature.AP = restorePoints(creature.AP, creature.CreatureTypeStruct.MaxAP, 1)
+	creature.RP = restorePoints(creature.RP, creature.CreatureTypeStruct.MaxRP, 1)
+	creature.MP = restorePoints(creature.MP, creature.CreatureTypeStruct.MaxMP, 1)
+}
+
+func (creature *Creature) canAffordAttack(attack *Attack) bool {
+	return creature.AP >= attack.AP && creature.RP >= attack.RP && creature.MP >= attack.MP
+}
+
+// MusterCounterAttack picks a counterattack to answer a hit with, if one is ready, affordable
+// and triggers. Like a user's, it spends the points and the creature's charge.
+func (creature *Creature) MusterCounterAttack() *Attack {
+	if creature.HP == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+
+	for _, counter := range creature.CreatureTypeStruct.CounterAttacks {
+		if creature.CounterCooldowns[counter.Name] > now {
+			continue
+		}
+
+		if creature.Charge < counter.Charge || !creature.canAffordAttack(&counter) {
+			continue
+		}
+
+		if !counter.counterTriggers() {
+			continue
+		}
+
+		creature.AP -= counter.AP
+		creature.RP -= counter.RP
+		creature.MP -= counter.MP
+		creature.Charge = 0
+		creature.Countered = now
+
+		if counter.Cooldown > 0 {
+			if creature.CounterCooldowns == nil {
+				creature.CounterCooldowns = make(map[string]int64)
+			}
+			creature.CounterCooldowns[counter.Name] = now + counter.Cooldown
+		}
+
+		attack := counter.ApplyBonuses(creature)
+		attack.IsCounter = true
+
+		return &attack
+	}
+
+	return nil
+}
+
 // CreatureList represents the creatures in a DB
 type CreatureList struct {
 	CreatureIDs []string `json:""`
@@ -0,0 +1,97 @@
+package mud
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreatureMusterCounterAttack(t *testing.T) {
+	parry := Attack{Name: "Parry", Accuracy: 100, AP: 2, RP: 1, Charge: 3}
+
+	tests := []struct {
+		name     string
+		ap       uint64
+		charge   int64
+		cooldown int64
+		want     bool
+	}{
+		{"ready", 5, 3, 0, true},
+		{"too few points", 1, 3, 0, false},
+		{"not charged", 5, 2, 0, false},
+		{"cooling down", 5, 3, time.Now().Unix() + 60, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			creature := &Creature{
+				HP:               10,
+				AP:               test.ap,
+				RP:               5,
+				Charge:           test.charge,
+				CounterCooldowns: map[string]int64{parry.Name: test.cooldown},
+				CreatureTypeStruct: CreatureType{
+					MaxAP:          5,
+					MaxRP:          5,
+					CounterAttacks: []Attack{parry},
+				},
+			}
+
+			counter := creature.MusterCounterAttack()
+			if (counter != nil) != test.want {
+				t.Fatalf("MusterCounterAttack() = %v, want a counter %v", counter, test.want)
+			}
+
+			if !test.want {
+				if creature.AP != test.ap || creature.RP != 5 {
+					t.Fatalf("spent AP %v, RP %v on a counter that didn't happen", test.ap-creature.AP, 5-creature.RP)
+				}
+			} else if creature.AP != test.ap-parry.AP || creature.RP != 5-parry.RP || creature.Charge != 0 {
+				t.Fatalf("left AP %v, RP %v, charge %v after countering", creature.AP, creature.RP, creature.Charge)
+			}
+		})
+	}
+}
+
+func TestCounterAttackSpendsCharge(t *testing.T) {
+	CreatureTypes["parrier"] = CreatureType{
+		ID:             "parrier",
+		Name:           "Parrier",
+		Hostile:        true,
+		MaxHP:          1000,
+		MaxAP:          30,
+		MaxRP:          30,
+		MaxMP:          30,
+		Attacks:        []Attack{{Name: "Jab", Accuracy: 100, AP: 1, Charge: 10}},
+		CounterAttacks: []Attack{{Name: "Parry"}},
+	}
+	t.Cleanup(func() { delete(CreatureTypes, "parrier") })
+
+	world := newTestWorld(t)
+	user := newTestCharacter(t, world, "fencer")
+	cell := world.CellAtPoint(*user.Location())
+	cell.AddStockCreature("parrier")
+	user.MarkActive()
+
+	creature := cell.GetCreatures()[0]
+	record, _ := world.activeCellCache.Load(string(user.Location().Bytes()))
+	recent := record.(*recentCellInfo)
+
+	// Let it build up some charge, then spend it all blocking a hit
+	recent.lastCreatureAction[creature.ID] = time.Now().Unix() - 5
+	world.updateActivatedCells()
+	if creature.Charge == 0 {
+		t.Fatal("no charge built up")
+	}
+
+	world.Attack(user, creature, &Attack{Name: "Thrust", Accuracy: 100, AP: 50})
+	world.updateActivatedCells()
+
+	for _, creature := range cell.GetCreatures() {
+		if creature.Charge != 0 {
+			t.Fatalf("charge is %v after countering", creature.Charge)
+		}
+		if creature.HP != 1000 {
+			t.Fatalf("took %v damage through the counter", 1000-creature.HP)
+		}
+	}
+}
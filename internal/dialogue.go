@@ -0,0 +1,175 @@
+package mud
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+)
+
+// DialogueTrees is a mapping of string IDs to NPC conversations
+var DialogueTrees map[string]DialogueTree
+
+// DialogueTree is a conversation with an NPC, starting at its Start node
+type DialogueTree struct {
+	ID    string                  `json:"-"`
+	Start string                  `json:""`
+	Nodes map[string]DialogueNode `json:""`
+}
+
+// DialogueNode is one thing an NPC says and the replies the user can pick from
+type DialogueNode struct {
+	Text     string           `json:""`
+	Branches []DialogueOption `json:",omitempty"` // Checked in order on arrival; the first that applies jumps straight to its Next
+	Options  []DialogueOption `json:",omitempty"` // No options ends the conversation
+}
+
+// DialogueOption is a reply (or branch) that's only offered if its condition holds
+type DialogueOption struct {
+	Text       string            `json:",omitempty"`
+	Next       string            `json:",omitempty"` // Node to go to, empty ends the conversation
+	StartQuest string            `json:",omitempty"` // ID of quest in quests.json given to the user on picking this
+	Condition  DialogueCondition `json:",omitempty"`
+}
+
+// DialogueCondition limits a dialogue option to some users; empty fields always pass
+type DialogueCondition struct {
+	Classes    []string `json:",omitempty"` // Any of the user's strength class, skill class or full title
+	MinLevel   uint64   `json:",omitempty"`
+	MaxLevel   uint64   `json:",omitempty"`
+	HasItems   []string `json:",omitempty"` // Names of items the user has to be carrying
+	LacksItems []string `json:",omitempty"` // Names of items the user can't be carrying
+	NewQuests  []string `json:",omitempty"` // Quests the user hasn't started yet
+	OnQuests   []string `json:",omitempty"` // Quests the user is partway through
+	DoneQuests []string `json:",omitempty"` // Quests the user has finished
+}
+
+// Met checks whether a user passes a condition
+func (condition *DialogueCondition) Met(user User) bool {
+	if len(condition.Classes) > 0 {
+		st1, st2 := user.Strengths()
+		sk1, sk2 := user.Skills()
+		strengthName, skillName := GetSubTitles(st1, st2, sk1, sk2)
+		title := user.Title()
+
+		found := false
+		for _, class := range condition.Classes {
+			if class == strengthName || class == skillName || class == title {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	level := user.Level()
+	if (condition.MinLevel > 0 && level < condition.MinLevel) || (condition.MaxLevel > 0 && level > condition.MaxLevel) {
+		return false
+	}
+
+	if len(condition.HasItems) > 0 || len(condition.LacksItems) > 0 {
+		carrying := carriedItems(user.InventoryItems())
+
+		for _, name := range condition.HasItems {
+			if carrying[name] == 0 {
+				return false
+			}
+			carrying[name]--
+		}
+
+		for _, name := range condition.LacksItems {
+			if carrying[name] > 0 {
+				return false
+			}
+		}
+	}
+
+	if len(condition.NewQuests) > 0 || len(condition.OnQuests) > 0 || len(condition.DoneQuests) > 0 {
+		active, done := questStates(user.Quests())
+
+		for _, id := range condition.NewQuests {
+			if active[id] || done[id] {
+				return false
+			}
+		}
+
+		for _, id := range condition.OnQuests {
+			if !active[id] {
+				return false
+			}
+		}
+
+		for _, id := range condition.DoneQuests {
+			if !done[id] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Node finds where a conversation ends up when it arrives at a node, following any branches
+// that apply to the user. It returns false if there's no such node.
+func (tree *DialogueTree) Node(id string, user User) (string, DialogueNode, bool) {
+	visited := make(map[string]bool)
+
+	for {
+		node, ok := tree.Nodes[id]
+
+		if !ok || visited[id] {
+			return id, node, ok
+		}
+		visited[id] = true
+
+		next := ""
+		for _, branch := range node.Branches {
+			if branch.Condition.Met(user) {
+				next = branch.Next
+				break
+			}
+		}
+
+		if next == "" {
+			return id, node, true
+		}
+
+		id = next
+	}
+}
+
+// AvailableOptions lists the replies a user can pick at this node
+func (node *DialogueNode) AvailableOptions(user User) []DialogueOption {
+	options := make([]DialogueOption, 0, len(node.Options))
+
+	for _, option := range node.Options {
+		if option.Condition.Met(user) {
+			options = append(options, option)
+		}
+	}
+
+	return options
+}
+
+func loadDialogueTrees(dialogueInfoFile string) {
+	data, err := ioutil.ReadFile(dialogueInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &DialogueTrees)
+	}
+
+	for k, v := range DialogueTrees {
+		v.ID = k
+		DialogueTrees[k] = v
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", dialogueInfoFile, err)
+	}
+}
+
+func init() {
+	DialogueTrees = make(map[string]DialogueTree)
+}
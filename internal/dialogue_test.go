@@ -0,0 +1,70 @@
+package mud
+
+import "testing"
+
+func TestDialogueConditionMet(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "talker")
+	giveTestItem(t, user, "Healing Potion")
+	user.StartQuest("rat-problem")
+
+	tests := []struct {
+		name      string
+		condition DialogueCondition
+		want      bool
+	}{
+		{"empty", DialogueCondition{}, true},
+		{"level too low", DialogueCondition{MinLevel: user.Level() + 1}, false},
+		{"level in range", DialogueCondition{MinLevel: user.Level(), MaxLevel: user.Level()}, true},
+		{"carrying", DialogueCondition{HasItems: []string{"Healing Potion"}}, true},
+		{"carrying one, needs two", DialogueCondition{HasItems: []string{"Healing Potion", "Healing Potion"}}, false},
+		{"lacks what they carry", DialogueCondition{LacksItems: []string{"Healing Potion"}}, false},
+		{"on the quest", DialogueCondition{OnQuests: []string{"rat-problem"}}, true},
+		{"quest isn't new", DialogueCondition{NewQuests: []string{"rat-problem"}}, false},
+		{"quest not done", DialogueCondition{DoneQuests: []string{"rat-problem"}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.condition.Met(user); got != test.want {
+				t.Fatalf("Met() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDialogueTreeNode(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "talker")
+
+	tree := DialogueTree{
+		Start: "hello",
+		Nodes: map[string]DialogueNode{
+			"hello": {Text: "Hello", Branches: []DialogueOption{
+				{Next: "veteran", Condition: DialogueCondition{MinLevel: 50}},
+				{Next: "stranger"},
+			}},
+			"stranger": {Text: "Who are you?", Branches: []DialogueOption{{Next: "hello"}}},
+			"veteran":  {Text: "Welcome back"},
+		},
+	}
+
+	tests := []struct {
+		start  string
+		wantID string
+		wantOK bool
+	}{
+		{"veteran", "veteran", true},
+		{"hello", "hello", true}, // Loops back round, so stops where it's been before
+		{"missing", "missing", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.start, func(t *testing.T) {
+			id, _, ok := tree.Node(test.start, user)
+			if id != test.wantID || ok != test.wantOK {
+				t.Fatalf("Node(%v) = %v, %v, want %v, %v", test.start, id, ok, test.wantID, test.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+)
+
+// EffectTypes is a mapping of string IDs to status effect types
+var EffectTypes map[string]EffectType
+
+// EffectType is a status effect an attack can leave on its target (poison, stun, etc.)
+type EffectType struct {
+	ID       string `json:"-"`
+	Name     string `json:""`
+	Duration int64  `json:""`           // In seconds
+	Interval int64  `json:",omitempty"` // Seconds between HP ticks, 0 means every second
+	HP       int64  `json:",omitempty"` // HP gained each tick, negative for damage over time
+	Shield   uint64 `json:",omitempty"` // Damage soaked up before HP is touched
+	Stun     bool   `json:",omitempty"` // Charge doesn't build while stunned
+	Slow     bool   `json:",omitempty"` // Charge builds at half speed
+	Self     bool   `json:",omitempty"` // Attacks put it on the attacker rather than the target
+	Bonuses  string `json:",omitempty"` // Bonus string applied to attacks while it lasts
+}
+
+// ActiveEffect is an instance of an EffectType on a user or creature
+type ActiveEffect struct {
+	ID       string `json:""`
+	Expires  int64  `json:""`           // Unix time it wears off
+	NextTick int64  `json:""`           // Unix time HP is next adjusted
+	Shield   uint64 `json:",omitempty"` // Shield left before it breaks
+	Bonuses  string `json:",omitempty"` // Bonus string applied to attacks while it lasts
+}
+
+// EffectInfo handles status effects on a user
+type EffectInfo interface {
+	Effects() []ActiveEffect
+	AddEffect(string)
+	AbsorbDamage(uint64) uint64
+	TickEffects()
+}
+
+// EffectType looks up the definition of an active effect
+func (effect *ActiveEffect) EffectType() EffectType {
+	effectType, ok := EffectTypes[effect.ID]
+
+	if !ok {
+		effectType.ID = effect.ID
+		effectType.Name = effect.ID
+		effectType.Bonuses = effect.Bonuses
+	}
+
+	return effectType
+}
+
+// Remaining is how many seconds are left on an effect
+func (effect *ActiveEffect) Remaining(now int64) int64 {
+	if effect.Expires <= now {
+		return 0
+	}
+
+	return effect.Expires - now
+}
+
+func (effect *ActiveEffect) String(now int64) string {
+	effectType := effect.EffectType()
+
+	if effect.Shield > 0 {
+		return fmt.Sprintf("%v (%v) %vs", effectType.Name, effect.Shield, effect.Remaining(now))
+	}
+
+	return fmt.Sprintf("%v %vs", effectType.Name, effect.Remaining(now))
+}
+
+// addEffect starts an effect, or restarts its clock if it's already running (effects don't stack)
+func addEffect(effects []ActiveEffect, id string, now int64) []ActiveEffect {
+	effectType, ok := EffectTypes[id]
+
+	if !ok {
+		log.Printf("Unknown effect %v", id)
+		return effects
+	}
+
+	effect := ActiveEffect{
+		ID:       id,
+		Expires:  now + effectType.Duration,
+		NextTick: now + effectType.Interval,
+		Shield:   effectType.Shield,
+		Bonuses:  effectType.Bonuses}
+
+	return putEffect(effects, effect)
+}
+
+// addBonusEffect starts a one-off effect that only carries a bonus string, like a potion's
+func addBonusEffect(effects []ActiveEffect, name, bonuses string, duration, now int64) []ActiveEffect {
+	return putEffect(effects, ActiveEffect{
+		ID:       name,
+		Expires:  now + duration,
+		NextTick: now,
+		Bonuses:  bonuses})
+}
+
+// putEffect adds an effect, replacing any running effect with the same ID
+func putEffect(effects []ActiveEffect, effect ActiveEffect) []ActiveEffect {
+	for index, active := range effects {
+		if active.ID == effect.ID {
+			effect.NextTick = active.NextTick
+			effects[index] = effect
+			return effects
+		}
+	}
+
+	return append(effects, effect)
+}
+
+// tickEffects drops expired effects and adds up HP changes that are due, returning the
+// effects still running, the HP change, and the names of any that wore off
+func tickEffects(effects []ActiveEffect, now int64) ([]ActiveEffect, int64, []string) {
+	remaining := make([]ActiveEffect, 0, len(effects))
+	expired := make([]string, 0)
+	hp := int64(0)
+
+	for _, effect := range effects {
+		effectType := effect.EffectType()
+
+		if effectType.HP != 0 && effect.NextTick <= now {
+			hp += effectType.HP
+			interval := effectType.Interval
+			if interval <= 0 {
+				interval = 1
+			}
+			effect.NextTick = now + interval
+		}
+
+		if effect.Expires <= now {
+			expired = append(expired, effectType.Name)
+		} else {
+			remaining = append(remaining, effect)
+		}
+	}
+
+	return remaining, hp, expired
+}
+
+// absorbDamage lets any shields soak up damage, returning what gets through
+func absorbDamage(effects []ActiveEffect, damage uint64) ([]ActiveEffect, uint64) {
+	remaining := make([]ActiveEffect, 0, len(effects))
+
+	for _, effect := range effects {
+		if effect.Shield > 0 && damage > 0 {
+			if effect.Shield > damage {
+				effect.Shield -= damage
+				damage = 0
+			} else {
+				damage -= effect.Shield
+				continue
+			}
+		}
+
+		remaining = append(remaining, effect)
+	}
+
+	return remaining, damage
+}
+
+// effectsStun checks if any running effect stops charge from building
+func effectsStun(effects []ActiveEffect, now int64) bool {
+	for _, effect := range effects {
+		if effect.Expires > now && effect.EffectType().Stun {
+			return true
+		}
+	}
+
+	return false
+}
+
+// effectsSlow checks if any running effect halves the charge rate
+func effectsSlow(effects []ActiveEffect, now int64) bool {
+	for _, effect := range effects {
+		if effect.Expires > now && effect.EffectType().Slow {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitEffects sorts an attack's effects into those that land on its target and those
+// that go on whoever used it
+func splitEffects(ids []string) ([]string, []string) {
+	target, self := make([]string, 0, len(ids)), make([]string, 0)
+
+	for _, id := range ids {
+		if EffectTypes[id].Self {
+			self = append(self, id)
+		} else {
+			target = append(target, id)
+		}
+	}
+
+	return target, self
+}
+
+// effectBonuses applies the bonus strings of every running effect to an attack
+func effectBonuses(effects []ActiveEffect, attack Attack, now int64) Attack {
+	for _, effect := range effects {
+		if effect.Expires <= now || effect.Bonuses == "" {
+			continue
+		}
+
+		atkSP := attack.FullStatPoints()
+		boosted := ApplyBonuses(&atkSP, nil, effect.Bonuses)
+		attack.AP, attack.RP, attack.MP, attack.Trample = boosted.AP, boosted.RP, boosted.MP, boosted.Trample
+	}
+
+	return attack
+}
+
+// effectNames gives the display names of a list of effect IDs
+func effectNames(ids []string) []string {
+	names := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		if effectType, ok := EffectTypes[id]; ok {
+			names = append(names, effectType.Name)
+		}
+	}
+
+	return names
+}
+
+// applyHPChange adds a (possibly negative) HP change, keeping it between 0 and max
+func applyHPChange(hp, maxhp uint64, change int64) uint64 {
+	if change == 0 {
+		return hp
+	} else if change < 0 {
+		if uint64(-change) >= hp {
+			return 0
+		}
+
+		return hp - uint64(-change)
+	}
+
+	if hp+uint64(change) > maxhp {
+		if hp > maxhp {
+			return hp
+		}
+		return maxhp
+	}
+
+	return hp + uint64(change)
+}
+
+func loadEffectTypes(effectInfoFile string) {
+	data, err := ioutil.ReadFile(effectInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &EffectTypes)
+	}
+
+	for k, v := range EffectTypes {
+		v.ID = k
+		EffectTypes[k] = v
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", effectInfoFile, err)
+	}
+}
+
+func init() {
+	EffectTypes = make(map[string]EffectType)
+}
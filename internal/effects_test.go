@@ -0,0 +1,54 @@
+package mud
+
+import "testing"
+
+func TestSplitEffects(t *testing.T) {
+	tests := []struct {
+		name         string
+		effects      []string
+		target, self int
+	}{
+		{"none", nil, 0, 0},
+		{"harmful", []string{"poison", "stun"}, 2, 0},
+		{"beneficial", []string{"regen", "shield"}, 0, 2},
+		{"both", []string{"bleed", "regen"}, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, self := splitEffects(test.effects)
+			if len(target) != test.target || len(self) != test.self {
+				t.Fatalf("splitEffects(%v) = %v, %v", test.effects, target, self)
+			}
+		})
+	}
+}
+
+func TestAttackPutsBeneficialEffectsOnAttacker(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestCharacter(t, world, "hunter")
+	cell := world.CellAtPoint(*user.Location())
+	cell.AddStockCreature("rat")
+	user.MarkActive()
+	rat := cell.GetCreatures()[0]
+	rat.HP = 1000
+
+	world.Attack(user, rat, &Attack{Name: "Leech", Accuracy: 100, AP: 1, Effects: []string{"poison", "regen"}})
+
+	has := func(effects []ActiveEffect, id string) bool {
+		for _, effect := range effects {
+			if effect.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	user.Reload()
+	if !has(user.Effects(), "regen") || has(user.Effects(), "poison") {
+		t.Fatalf("attacker has %+v, want only regen", user.Effects())
+	}
+	if !has(rat.Effects, "poison") || has(rat.Effects, "regen") {
+		t.Fatalf("rat has %+v, want only poison", rat.Effects)
+	}
+}
@@ -62,13 +62,29 @@ const (
 
 // InventoryItem is a droppable item for an inventory
 type InventoryItem struct {
-	ID             string   `json:""`
-	Name           string   `json:""`
-	Type           string   `json:""`
-	Description    string   `json:""`
-	Subtype        string   `json:",omitempty"` // For weapons and artifacts
-	Attacks        []Attack `json:",omitempty"` // For weapons and spells
-	CounterAttacks []Attack `json:",omitempty"` // For scrolls and spells with counterattack effects
+	ID             string      `json:""`
+	Name           string      `json:""`
+	Type           string      `json:""`
+	Description    string      `json:""`
+	Subtype        string      `json:",omitempty"` // For weapons and artifacts
+	Attacks        []Attack    `json:",omitempty"` // For weapons and spells
+	CounterAttacks []Attack    `json:",omitempty"` // For scrolls and spells with counterattack effects
+	Use            *ItemUse    `json:",omitempty"` // For potions and scrolls that are used up
+	Defense        *StatPoints `json:",omitempty"` // For armor: added to the wearer's stats when they're hit
+	DefenseBonuses string      `json:",omitempty"` // For armor: bonus string worked out from the wearer's stats and added to their defense
+}
+
+// ItemUse describes what happens when a consumable item is used
+type ItemUse struct {
+	HP       uint64   `json:",omitempty"` // Restored, up to the max
+	AP       uint64   `json:",omitempty"`
+	RP       uint64   `json:",omitempty"`
+	MP       uint64   `json:",omitempty"`
+	XP       uint64   `json:",omitempty"`
+	Teleport bool     `json:",omitempty"` // Sends the user back to their spawn point
+	Effects  []string `json:",omitempty"` // Status effects from effects.json put on the user
+	Bonuses  string   `json:",omitempty"` // Bonus string applied to the user's attacks while it lasts
+	Duration int64    `json:",omitempty"` // In seconds, for Bonuses
 }
 
 // SlotName is the places a potential item can be equipped
@@ -86,6 +102,7 @@ type InventoryInfo interface {
 	AddInventoryItem(*InventoryItem) bool
 	InventoryItem(string) *InventoryItem
 	PullInventoryItem(string) *InventoryItem
+	Use(*InventoryItem) error
 }
 
 func loadItemTypes(itemInfoFile string) {
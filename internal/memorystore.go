@@ -0,0 +1,222 @@
+package mud
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// memoryStore is a Store kept entirely in RAM. Writes are undone if an Update fails, so it
+// gives the same all-or-nothing guarantees as bbolt without ever touching the disk.
+type memoryStore struct {
+	mutex   sync.RWMutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryTx struct {
+	store    *memoryStore
+	writable bool
+	undo     []func()
+}
+
+type memoryBucket struct {
+	keys     []string
+	values   map[string][]byte
+	sequence uint64
+}
+
+type memoryBucketHandle struct {
+	bucket *memoryBucket
+	tx     *memoryTx
+}
+
+// NewMemoryStore creates an empty Store that lives only as long as the process
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *memoryStore) View(fn func(Tx) error) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return fn(&memoryTx{store: s})
+}
+
+func (s *memoryStore) Update(fn func(Tx) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tx := &memoryTx{store: s, writable: true}
+	err := fn(tx)
+
+	if err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+	}
+
+	return err
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (t *memoryTx) Bucket(name string) Bucket {
+	bucket, ok := t.store.buckets[name]
+
+	if !ok {
+		return nil
+	}
+
+	return &memoryBucketHandle{bucket: bucket, tx: t}
+}
+
+func (t *memoryTx) CreateBucketIfNotExists(name string) (Bucket, error) {
+	if !t.writable {
+		return nil, errReadOnlyTx
+	}
+
+	bucket, ok := t.store.buckets[name]
+
+	if !ok {
+		bucket = &memoryBucket{keys: make([]string, 0), values: make(map[string][]byte)}
+		t.store.buckets[name] = bucket
+		t.undo = append(t.undo, func() { delete(t.store.buckets, name) })
+	}
+
+	return &memoryBucketHandle{bucket: bucket, tx: t}, nil
+}
+
+func (b *memoryBucket) index(key string) int {
+	return sort.SearchStrings(b.keys, key)
+}
+
+func (b *memoryBucket) set(key string, value []byte) {
+	if _, ok := b.values[key]; !ok {
+		i := b.index(key)
+		b.keys = append(b.keys, "")
+		copy(b.keys[i+1:], b.keys[i:])
+		b.keys[i] = key
+	}
+
+	b.values[key] = value
+}
+
+func (b *memoryBucket) unset(key string) {
+	if _, ok := b.values[key]; ok {
+		i := b.index(key)
+		b.keys = append(b.keys[:i], b.keys[i+1:]...)
+		delete(b.values, key)
+	}
+}
+
+func (h *memoryBucketHandle) remember(key string) {
+	oldValue, existed := h.bucket.values[key]
+	bucket := h.bucket
+
+	h.tx.undo = append(h.tx.undo, func() {
+		if existed {
+			bucket.set(key, oldValue)
+		} else {
+			bucket.unset(key)
+		}
+	})
+}
+
+// cloneBytes copies a stored value so callers can't change what's in the store through it
+func cloneBytes(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+
+	valueCopy := make([]byte, len(value))
+	copy(valueCopy, value)
+
+	return valueCopy
+}
+
+func (h *memoryBucketHandle) Get(key []byte) []byte {
+	return cloneBytes(h.bucket.values[string(key)])
+}
+
+func (h *memoryBucketHandle) Put(key, value []byte) error {
+	if !h.tx.writable {
+		return errReadOnlyTx
+	}
+
+	h.remember(string(key))
+	h.bucket.set(string(key), cloneBytes(value))
+
+	return nil
+}
+
+func (h *memoryBucketHandle) Delete(key []byte) error {
+	if !h.tx.writable {
+		return errReadOnlyTx
+	}
+
+	h.remember(string(key))
+	h.bucket.unset(string(key))
+
+	return nil
+}
+
+func (h *memoryBucketHandle) ForEach(fn func(k, v []byte) error) error {
+	keys := make([]string, len(h.bucket.keys))
+	copy(keys, h.bucket.keys)
+
+	for _, key := range keys {
+		value, ok := h.bucket.values[key]
+
+		if !ok {
+			continue
+		}
+
+		if err := fn([]byte(key), cloneBytes(value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *memoryBucketHandle) Range(min, max []byte, visitor func(k, v []byte) error) error {
+	keys := make([]string, 0)
+
+	for i := h.bucket.index(string(min)); i < len(h.bucket.keys); i++ {
+		if bytes.Compare([]byte(h.bucket.keys[i]), max) > 0 {
+			break
+		}
+
+		keys = append(keys, h.bucket.keys[i])
+	}
+
+	for _, key := range keys {
+		value, ok := h.bucket.values[key]
+
+		if !ok {
+			continue
+		}
+
+		if err := visitor([]byte(key), cloneBytes(value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *memoryBucketHandle) NextSequence() (uint64, error) {
+	if !h.tx.writable {
+		return 0, errReadOnlyTx
+	}
+
+	bucket := h.bucket
+	oldSequence := bucket.sequence
+	h.tx.undo = append(h.tx.undo, func() { bucket.sequence = oldSequence })
+
+	bucket.sequence++
+
+	return bucket.sequence, nil
+}
@@ -0,0 +1,192 @@
+package mud
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+
+	store := NewMemoryStore()
+	err := store.Update(func(tx Tx) error {
+		_, err := tx.CreateBucketIfNotExists("things")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func storedValue(store Store, key string) []byte {
+	var value []byte
+
+	store.View(func(tx Tx) error {
+		value = tx.Bucket("things").Get([]byte(key))
+		return nil
+	})
+
+	return value
+}
+
+func TestMemoryStoreRollback(t *testing.T) {
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name  string
+		write func(Bucket) error
+		want  map[string]string
+	}{
+		{"put", func(b Bucket) error { return b.Put([]byte("b"), []byte("2")) }, map[string]string{"a": "1", "b": "2"}},
+		{"put then fail", func(b Bucket) error {
+			b.Put([]byte("b"), []byte("2"))
+			return failed
+		}, map[string]string{"a": "1"}},
+		{"overwrite then fail", func(b Bucket) error {
+			b.Put([]byte("a"), []byte("9"))
+			return failed
+		}, map[string]string{"a": "1"}},
+		{"delete then fail", func(b Bucket) error {
+			b.Delete([]byte("a"))
+			return failed
+		}, map[string]string{"a": "1"}},
+		{"overwrite twice then fail", func(b Bucket) error {
+			b.Put([]byte("a"), []byte("8"))
+			b.Put([]byte("a"), []byte("9"))
+			b.Delete([]byte("a"))
+			return failed
+		}, map[string]string{"a": "1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newTestStore(t)
+			store.Update(func(tx Tx) error { return tx.Bucket("things").Put([]byte("a"), []byte("1")) })
+
+			store.Update(func(tx Tx) error { return test.write(tx.Bucket("things")) })
+
+			got := make(map[string]string)
+			store.View(func(tx Tx) error {
+				return tx.Bucket("things").ForEach(func(k, v []byte) error {
+					got[string(k)] = string(v)
+					return nil
+				})
+			})
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for key, value := range test.want {
+				if got[key] != value {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreRollsBackSequences(t *testing.T) {
+	store := newTestStore(t)
+
+	store.Update(func(tx Tx) error {
+		tx.Bucket("things").NextSequence()
+		return errors.New("failed")
+	})
+
+	var sequence uint64
+	store.Update(func(tx Tx) error {
+		sequence, _ = tx.Bucket("things").NextSequence()
+		return nil
+	})
+
+	if sequence != 1 {
+		t.Fatalf("sequence = %v after a rolled back Update, want 1", sequence)
+	}
+}
+
+func TestMemoryStoreReadOnlyView(t *testing.T) {
+	store := newTestStore(t)
+
+	err := store.View(func(tx Tx) error {
+		return tx.Bucket("things").Put([]byte("a"), []byte("1"))
+	})
+
+	if err != errReadOnlyTx {
+		t.Fatalf("Put in a View returned %v, want %v", err, errReadOnlyTx)
+	}
+	if storedValue(store, "a") != nil {
+		t.Fatal("Put in a View was stored")
+	}
+}
+
+func TestMemoryStoreCopiesValues(t *testing.T) {
+	store := newTestStore(t)
+	value := []byte("kept")
+
+	store.Update(func(tx Tx) error { return tx.Bucket("things").Put([]byte("a"), value) })
+	value[0] = 'X'
+
+	got := storedValue(store, "a")
+	got[1] = 'X'
+
+	store.View(func(tx Tx) error {
+		return tx.Bucket("things").ForEach(func(k, v []byte) error {
+			v[2] = 'X'
+			return nil
+		})
+	})
+
+	if !bytes.Equal(storedValue(store, "a"), []byte("kept")) {
+		t.Fatalf("stored value changed to %q", storedValue(store, "a"))
+	}
+}
+
+func TestMemoryStoreRange(t *testing.T) {
+	store := newTestStore(t)
+	store.Update(func(tx Tx) error {
+		for _, key := range []string{"b2", "a1", "b1", "c1", "b3"} {
+			tx.Bucket("things").Put([]byte(key), []byte(key))
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		min, max string
+		stop     int
+		want     []string
+	}{
+		{"middle", "b1", "b3", 0, []string{"b1", "b2", "b3"}},
+		{"between keys", "a2", "b1z", 0, []string{"b1"}},
+		{"stopped early", "a1", "c1", 2, []string{"a1", "b1"}},
+		{"nothing", "d", "e", 0, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]string, 0)
+
+			store.View(func(tx Tx) error {
+				return tx.Bucket("things").Range([]byte(test.min), []byte(test.max), func(k, v []byte) error {
+					got = append(got, string(k))
+					if len(got) == test.stop {
+						return errStopRange
+					}
+					return nil
+				})
+			})
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package mud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Loot modes, deciding who gets what a creature drops when a party member kills it
+const (
+	LOOTFREEFORALL = "free-for-all" // Drops land on the ground for anyone to take
+	LOOTROUNDROBIN = "round-robin"  // Each drop goes to the next member standing there, in turn
+	LOOTLEADER     = "leader"       // Everything goes to the leader, if they're standing there
+)
+
+// LootModes lists the loot modes a party leader can pick from
+var LootModes = []string{LOOTFREEFORALL, LOOTROUNDROBIN, LOOTLEADER}
+
+// partyMaxSize is the most members a party can have
+const partyMaxSize = 6
+
+// partyAssistShare is the fraction of a party's kill XP shared evenly with every member standing
+// by, whether or not they landed a hit; the rest is split by damage done
+const partyAssistShare = 4
+
+// Party is a group of users who share XP and loot and have their own chat channel
+type Party struct {
+	ID         string   `json:""`
+	Leader     string   `json:""`
+	Members    []string `json:""` // Usernames in the order they joined, leader included
+	Invited    []string `json:",omitempty"`
+	LootMode   string   `json:",omitempty"` // One of LootModes, empty means free-for-all
+	NextLooter int      `json:",omitempty"` // Index into Members of who gets the next round-robin drop
+}
+
+// PartyInfo handles the party a user is in and the invitations they've had
+type PartyInfo interface {
+	Party() (Party, bool)
+	PartyInvitations() []Party
+	InviteToParty(string) error
+	JoinParty(string) error
+	DeclineParty(string) error
+	LeaveParty() error
+	KickFromParty(string) error
+	SetPartyLeader(string) error
+	SetLootMode(string) error
+}
+
+// HasMember checks whether a user is in the party
+func (party *Party) HasMember(username string) bool {
+	for _, member := range party.Members {
+		if member == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsInvited checks whether a user has an invitation to the party they haven't answered
+func (party *Party) IsInvited(username string) bool {
+	for _, invited := range party.Invited {
+		if invited == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Loot is the party's loot mode, defaulting to free-for-all
+func (party *Party) Loot() string {
+	if party.LootMode == "" {
+		return LOOTFREEFORALL
+	}
+
+	return party.LootMode
+}
+
+func (party *Party) removeInvite(username string) {
+	invited := make([]string, 0, len(party.Invited))
+	for _, name := range party.Invited {
+		if name != username {
+			invited = append(invited, name)
+		}
+	}
+	party.Invited = invited
+}
+
+// removeMember takes a user out of the party, handing leadership to whoever joined next if they led it
+func (party *Party) removeMember(username string) {
+	members := make([]string, 0, len(party.Members))
+	for index, name := range party.Members {
+		if name != username {
+			members = append(members, name)
+		} else if index < party.NextLooter {
+			party.NextLooter--
+		}
+	}
+	party.Members = members
+
+	if party.Leader == username && len(members) > 0 {
+		party.Leader = members[0]
+	}
+}
+
+// Looter picks who gets the next drop out of the members present, going by the loot mode.
+// Round-robin moves on to the next member each time.
+func (party *Party) Looter(present map[string]bool) (string, bool) {
+	switch party.Loot() {
+	case LOOTLEADER:
+		return party.Leader, present[party.Leader]
+	case LOOTROUNDROBIN:
+		for step := range party.Members {
+			index := (party.NextLooter + step) % len(party.Members)
+			if present[party.Members[index]] {
+				party.NextLooter = (index + 1) % len(party.Members)
+				return party.Members[index], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (party *Party) String() string {
+	members := make([]string, 0, len(party.Members))
+	for _, member := range party.Members {
+		if member == party.Leader {
+			member += " (leader)"
+		}
+		members = append(members, member)
+	}
+
+	return fmt.Sprintf("%v; loot is %v", strings.Join(members, ", "), party.Loot())
+}
+
+// splitKillXP shares out the XP for a kill. Everyone who hurt the creature gets XP in proportion
+// to the damage they did. Party members pool theirs, and part of the pool is shared evenly with
+// members standing by even if they never landed a hit. Parties maps usernames to their party;
+// present is who was in the cell. With no damage on record it's split evenly between everyone present.
+func splitKillXP(xp uint64, damage map[string]uint64, parties map[string]Party, present []string) map[string]uint64 {
+	awards := make(map[string]uint64)
+
+	total := uint64(0)
+	for _, dealt := range damage {
+		total += dealt
+	}
+
+	if total == 0 {
+		for _, username := range present {
+			awards[username] += xp / uint64(len(present))
+		}
+		return awards
+	}
+
+	contributors := make([]string, 0, len(damage))
+	for username := range damage {
+		contributors = append(contributors, username)
+	}
+	sort.Strings(contributors)
+
+	pools := make(map[string]uint64)
+	poolDamage := make(map[string]uint64)
+	for _, username := range contributors {
+		share := xp * damage[username] / total
+
+		if party, ok := parties[username]; ok {
+			pools[party.ID] += share
+			poolDamage[party.ID] += damage[username]
+		} else {
+			awards[username] += share
+		}
+	}
+
+	for partyID, pool := range pools {
+		standingBy := make([]string, 0)
+		for _, username := range present {
+			if party, ok := parties[username]; ok && party.ID == partyID && damage[username] == 0 {
+				standingBy = append(standingBy, username)
+			}
+		}
+		for _, username := range contributors {
+			if party, ok := parties[username]; ok && party.ID == partyID {
+				standingBy = append(standingBy, username)
+			}
+		}
+
+		assist := pool / partyAssistShare / uint64(len(standingBy))
+		for _, username := range standingBy {
+			awards[username] += assist
+		}
+
+		rest := pool - assist*uint64(len(standingBy))
+		for _, username := range contributors {
+			if party, ok := parties[username]; ok && party.ID == partyID {
+				awards[username] += rest * damage[username] / poolDamage[partyID]
+			}
+		}
+	}
+
+	return awards
+}
@@ -0,0 +1,123 @@
+package mud
+
+import "testing"
+
+func TestSplitKillXP(t *testing.T) {
+	party := Party{ID: "p", Leader: "ann", Members: []string{"ann", "bob"}}
+	inParty := map[string]Party{"ann": party, "bob": party}
+
+	tests := []struct {
+		name    string
+		damage  map[string]uint64
+		parties map[string]Party
+		present []string
+		want    map[string]uint64
+	}{
+		{"solo", map[string]uint64{"ann": 10}, nil, []string{"ann"}, map[string]uint64{"ann": 100}},
+		{"by damage", map[string]uint64{"ann": 3, "bob": 7}, nil, nil, map[string]uint64{"ann": 30, "bob": 70}},
+		{"nobody hit it", nil, nil, []string{"ann", "bob"}, map[string]uint64{"ann": 50, "bob": 50}},
+		{"party member standing by", map[string]uint64{"ann": 10}, inParty, []string{"ann", "bob"}, map[string]uint64{"ann": 88, "bob": 12}},
+		{"party member elsewhere", map[string]uint64{"ann": 10}, inParty, []string{"ann"}, map[string]uint64{"ann": 100}},
+		{"outsider helping a party", map[string]uint64{"ann": 5, "cat": 5}, inParty, []string{"ann", "bob", "cat"}, map[string]uint64{"ann": 44, "bob": 6, "cat": 50}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitKillXP(100, test.damage, test.parties, test.present)
+			if len(got) != len(test.want) {
+				t.Fatalf("splitKillXP() = %v, want %v", got, test.want)
+			}
+			for username, xp := range test.want {
+				if got[username] != xp {
+					t.Fatalf("splitKillXP() = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPartyLooter(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		present map[string]bool
+		want    []string
+	}{
+		{"free for all", LOOTFREEFORALL, map[string]bool{"ann": true, "bob": true}, []string{"", ""}},
+		{"leader present", LOOTLEADER, map[string]bool{"ann": true}, []string{"ann", "ann"}},
+		{"leader away", LOOTLEADER, map[string]bool{"bob": true}, []string{"", ""}},
+		{"round robin", LOOTROUNDROBIN, map[string]bool{"ann": true, "bob": true, "cat": true}, []string{"ann", "bob", "cat", "ann"}},
+		{"round robin skips who's away", LOOTROUNDROBIN, map[string]bool{"ann": true, "cat": true}, []string{"ann", "cat", "ann"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			party := Party{Leader: "ann", Members: []string{"ann", "bob", "cat"}, LootMode: test.mode}
+
+			for turn, want := range test.want {
+				got, ok := party.Looter(test.present)
+				if !ok {
+					got = ""
+				}
+				if got != want {
+					t.Fatalf("drop %v went to %q, want %q", turn+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPartyMembership(t *testing.T) {
+	world := newTestWorld(t)
+	ann := newTestUser(t, world, "ann")
+	bob := newTestUser(t, world, "bob")
+
+	if err := ann.InviteToParty("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.JoinParty("ann"); err != nil {
+		t.Fatal(err)
+	}
+
+	if party, ok := bob.Party(); !ok || !party.HasMember("ann") || !party.HasMember("bob") {
+		t.Fatalf("bob's party = %+v, %v", party, ok)
+	}
+
+	if err := ann.LeaveParty(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ann.Party(); ok {
+		t.Fatal("ann is still in a party after leaving")
+	}
+	if party, ok := bob.Party(); ok && party.Leader != "bob" {
+		t.Fatalf("bob's party is led by %v after ann left", party.Leader)
+	}
+}
+
+func TestKillRewardsOnce(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestCharacter(t, world, "hunter")
+	cell := world.CellAtPoint(*user.Location())
+	cell.AddStockCreature("rat")
+	user.MarkActive()
+
+	rat := cell.GetCreatures()[0]
+	stale := *rat
+	smite := &Attack{Name: "Smite", Accuracy: 100, AP: 1000}
+
+	world.Attack(user, rat, smite)
+	user.Reload()
+	xp := user.XP()
+
+	// Both the creature in hand and a copy from before it died are already dead
+	world.Attack(user, rat, smite)
+	world.Attack(user, &stale, smite)
+
+	user.Reload()
+	if user.XP() != xp {
+		t.Fatalf("XP went from %v to %v killing it again", xp, user.XP())
+	}
+	if creature := world.getCreature(rat.ID); creature == nil || creature.HP != 0 {
+		t.Fatalf("rat is %+v after dying", creature)
+	}
+}
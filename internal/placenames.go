@@ -7,64 +7,64 @@ import (
 
 var onsets, vowels, nucleae, codae, prefixes, middles, suffixes []string
 
-func randomOnset() string {
-	if rand.Int()%2 == 0 {
-		return randomVowel()
+func randomOnset(rng *rand.Rand) string {
+	if rng.Int()%2 == 0 {
+		return randomVowel(rng)
 	}
-	return onsets[rand.Int()%len(onsets)]
+	return onsets[rng.Int()%len(onsets)]
 }
 
-func randomNucleus() string {
-	return nucleae[rand.Int()%len(nucleae)]
+func randomNucleus(rng *rand.Rand) string {
+	return nucleae[rng.Int()%len(nucleae)]
 }
 
-func randomVowel() string {
-	return vowels[rand.Int()%len(vowels)]
+func randomVowel(rng *rand.Rand) string {
+	return vowels[rng.Int()%len(vowels)]
 }
 
-func randomCoda() string {
-	return codae[rand.Int()%len(codae)]
+func randomCoda(rng *rand.Rand) string {
+	return codae[rng.Int()%len(codae)]
 }
 
-func randomRhyme(inWord bool) string {
-	if inWord && rand.Int()%4 == 0 {
-		return randomNucleus()
-	} else if rand.Int()%4 == 0 {
-		return randomVowel() + randomCoda() + randomVowel()
+func randomRhyme(inWord bool, rng *rand.Rand) string {
+	if inWord && rng.Int()%4 == 0 {
+		return randomNucleus(rng)
+	} else if rng.Int()%4 == 0 {
+		return randomVowel(rng) + randomCoda(rng) + randomVowel(rng)
 	}
-	return randomVowel() + randomCoda()
+	return randomVowel(rng) + randomCoda(rng)
 }
 
-func randomName() string {
-	return prefixes[rand.Int()%len(prefixes)] + middles[rand.Int()%len(middles)] + suffixes[rand.Int()%len(suffixes)]
+func randomName(rng *rand.Rand) string {
+	return prefixes[rng.Int()%len(prefixes)] + middles[rng.Int()%len(middles)] + suffixes[rng.Int()%len(suffixes)]
 }
 
 // RandomPlaceName generates a random place name
-func randomPlaceName() string {
+func randomPlaceName(rng *rand.Rand) string {
 	name := ""
-	for w := 0; w < 1+rand.Int()%2; w++ {
+	for w := 0; w < 1+rng.Int()%2; w++ {
 		if len(name) > 0 {
 			name += " "
 		}
-		if rand.Int()%2 == 0 {
+		if rng.Int()%2 == 0 {
 			noPrefix := true
-			if rand.Int()%2 == 0 {
+			if rng.Int()%2 == 0 {
 				noPrefix = false
-				name += prefixes[rand.Int()%len(prefixes)]
+				name += prefixes[rng.Int()%len(prefixes)]
 			}
-			for i := 0; i < 1+rand.Int()%2; i++ {
-				name += randomOnset() + randomRhyme(i > 0)
+			for i := 0; i < 1+rng.Int()%2; i++ {
+				name += randomOnset(rng) + randomRhyme(i > 0, rng)
 			}
-			if rand.Int()%2 == 0 || noPrefix {
-				name += suffixes[rand.Int()%len(suffixes)]
+			if rng.Int()%2 == 0 || noPrefix {
+				name += suffixes[rng.Int()%len(suffixes)]
 			}
 		} else {
-			name += randomName()
+			name += randomName(rng)
 		}
 	}
 
 	if len(name) > 25 {
-		return randomPlaceName()
+		return randomPlaceName(rng)
 	}
 
 	return strings.Title(name)
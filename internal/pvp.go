@@ -0,0 +1,130 @@
+package mud
+
+import (
+	"fmt"
+	"time"
+)
+
+// pvpProtectionSeconds is how long other players can't touch you after you respawn
+const pvpProtectionSeconds = 60
+
+// pvpCombatSeconds is how long after a fight with another player you have to wait to turn PvP off
+const pvpCombatSeconds = 30
+
+// duelChallengeSeconds is how long a duel challenge stands before it lapses
+const duelChallengeSeconds = 60
+
+// duelSeconds is how long a duel can go on before it's called off
+const duelSeconds = 300
+
+// PvPStats counts a user's fights with other players
+type PvPStats struct {
+	Kills     uint64 `json:""`
+	Deaths    uint64 `json:""`
+	DuelsWon  uint64 `json:""`
+	DuelsLost uint64 `json:""`
+}
+
+// Duel is a challenge between two users to fight until one of them is nearly dead. Losing a
+// duel doesn't kill you.
+type Duel struct {
+	Challenger string `json:""`
+	Opponent   string `json:""`
+	Accepted   bool   `json:",omitempty"`
+	Started    int64  `json:""` // Unix time of the challenge, or of accepting it
+}
+
+// PvPInfo handles whether and how a user fights other players
+type PvPInfo interface {
+	PvP() bool
+	SetPvP(bool) error
+	PvPStats() PvPStats
+	ProtectedFor() int64
+	EnterPvPCombat()
+	RecordPvP(won bool, duel bool)
+
+	Duel() (Duel, bool)
+	ChallengeToDuel(string) error
+	AcceptDuel() error
+	EndDuel() (Duel, error)
+}
+
+// Other is whoever a user is dueling or being challenged by
+func (duel *Duel) Other(username string) string {
+	if duel.Challenger == username {
+		return duel.Opponent
+	}
+
+	return duel.Challenger
+}
+
+// Expired checks whether a challenge went unanswered or a duel went on too long
+func (duel *Duel) Expired(now int64) bool {
+	if duel.Accepted {
+		return now-duel.Started > duelSeconds
+	}
+
+	return now-duel.Started > duelChallengeSeconds
+}
+
+// Between checks whether the duel is an accepted one between two users
+func (duel *Duel) Between(first, second string) bool {
+	return duel.Accepted && ((duel.Challenger == first && duel.Opponent == second) || (duel.Challenger == second && duel.Opponent == first))
+}
+
+// isPvPZone checks whether a cell's terrain or biome lets anyone fight anyone, consent or not
+func isPvPZone(world World, location Point) bool {
+	cellInfo := world.CellAtPoint(location).CellInfo()
+
+	return cellInfo != nil && (cellInfo.TerrainData.PvP || cellInfo.BiomeData.PvP)
+}
+
+// dueling checks whether two users are in an accepted duel with each other
+func dueling(attacker, target User) bool {
+	duel, ok := attacker.Duel()
+
+	return ok && duel.Between(attacker.Username(), target.Username()) && !duel.Expired(time.Now().Unix())
+}
+
+// canFight says why one user can't attack another, if they can't. Duels and PvP zones don't
+// need either side to have turned PvP on; anywhere else both have to have.
+func canFight(world World, attacker, target User) error {
+	if attacker.Username() == target.Username() {
+		return fmt.Errorf("You can't attack yourself")
+	} else if *attacker.Location() != *target.Location() {
+		return fmt.Errorf("%v isn't here", target.Username())
+	} else if target.HP() == 0 {
+		return fmt.Errorf("%v is already dead", target.Username())
+	} else if dueling(attacker, target) {
+		return nil
+	} else if protected := target.ProtectedFor(); protected > 0 {
+		return fmt.Errorf("%v just respawned and is protected for %v more seconds", target.Username(), protected)
+	} else if party, ok := attacker.Party(); ok && party.HasMember(target.Username()) {
+		return fmt.Errorf("%v is in your party; challenge them to a /duel instead", target.Username())
+	} else if isPvPZone(world, *target.Location()) {
+		return nil
+	} else if !attacker.PvP() {
+		return fmt.Errorf("You haven't agreed to fight other players; /pvp on, or challenge them to a /duel")
+	} else if !target.PvP() {
+		return fmt.Errorf("%v hasn't agreed to fight other players", target.Username())
+	}
+
+	return nil
+}
+
+// pvpSummary is a one line account of a user's standing with other players, for the character sheet
+func pvpSummary(user User) string {
+	stats := user.PvPStats()
+
+	consent := "off"
+	if user.PvP() {
+		consent = "on"
+	}
+
+	summary := fmt.Sprintf("PvP %v  K/D %v/%v  Duels %v-%v", consent, stats.Kills, stats.Deaths, stats.DuelsWon, stats.DuelsLost)
+	if protected := user.ProtectedFor(); protected > 0 {
+		summary += fmt.Sprintf("  Protected %vs", protected)
+	}
+
+	return summary
+}
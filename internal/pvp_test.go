@@ -0,0 +1,74 @@
+package mud
+
+import "testing"
+
+func TestDuelExpired(t *testing.T) {
+	now := int64(10000)
+
+	tests := []struct {
+		name string
+		duel Duel
+		want bool
+	}{
+		{"fresh challenge", Duel{Started: now - 10}, false},
+		{"unanswered challenge", Duel{Started: now - duelChallengeSeconds - 1}, true},
+		{"accepted duel under way", Duel{Accepted: true, Started: now - duelChallengeSeconds - 1}, false},
+		{"duel dragging on", Duel{Accepted: true, Started: now - duelSeconds - 1}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.duel.Expired(now); got != test.want {
+				t.Fatalf("Expired() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanFight(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(ann, bob User)
+		wantErr bool
+	}{
+		{"neither agreed", func(ann, bob User) {}, true},
+		{"only the attacker agreed", func(ann, bob User) { ann.SetPvP(true) }, true},
+		{"both agreed", func(ann, bob User) { ann.SetPvP(true); bob.SetPvP(true) }, false},
+		{"dueling", func(ann, bob User) { ann.ChallengeToDuel("bob"); bob.AcceptDuel() }, false},
+		{"unaccepted duel", func(ann, bob User) { ann.ChallengeToDuel("bob") }, true},
+		{"partied up", func(ann, bob User) {
+			ann.SetPvP(true)
+			bob.SetPvP(true)
+			ann.InviteToParty("bob")
+			bob.JoinParty("ann")
+		}, true},
+		{"dead", func(ann, bob User) {
+			ann.SetPvP(true)
+			bob.SetPvP(true)
+			bob.Reload()
+			bob.SetHP(0)
+			bob.Save()
+		}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			ann := newTestUser(t, world, "ann")
+			bob := newTestUser(t, world, "bob")
+			test.setup(ann, bob)
+			ann.Reload()
+			bob.Reload()
+
+			if err := canFight(world, ann, bob); (err != nil) != test.wantErr {
+				t.Fatalf("canFight() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	world := newTestWorld(t)
+	ann := newTestUser(t, world, "ann")
+	if err := canFight(world, ann, ann); err == nil {
+		t.Fatal("canFight() lets a user attack themselves")
+	}
+}
@@ -0,0 +1,245 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// Kinds of quest objective, also used as the kind of event that advances them
+const (
+	QUESTKILL    = "Kill"
+	QUESTCOLLECT = "Collect"
+	QUESTREACH   = "Reach"
+	QUESTTALK    = "Talk"
+)
+
+// QuestTypes is a mapping of string IDs to quests
+var QuestTypes map[string]QuestType
+
+// QuestType is a quest a user can take on, usually from an NPC
+type QuestType struct {
+	ID          string           `json:"-"`
+	Name        string           `json:""`
+	Description string           `json:",omitempty"`
+	Objectives  []QuestObjective `json:""`
+	Reward      QuestReward      `json:",omitempty"`
+}
+
+// QuestObjective is one thing a quest asks for; exactly one of Kill, Collect, Reach or Talk is set
+type QuestObjective struct {
+	Kill    string `json:",omitempty"` // ID of creature in bestiary
+	Collect string `json:",omitempty"` // Name of item, handed over when the quest is done
+	Reach   string `json:",omitempty"` // Part of a region name, like "Castle"
+	Talk    string `json:",omitempty"` // ID of NPC in bestiary
+	Count   uint64 `json:",omitempty"` // How many to kill or collect, 0 means 1
+}
+
+// QuestReward is what a user gets for finishing a quest
+type QuestReward struct {
+	XP    uint64   `json:",omitempty"`
+	Items []string `json:",omitempty"` // IDs of items in items.json
+}
+
+// QuestProgress is how far a user has got on a quest
+type QuestProgress struct {
+	QuestID string   `json:""`
+	Counts  []uint64 `json:""` // Per objective, in the same order; Collect objectives count what's carried instead
+	Done    bool     `json:",omitempty"`
+}
+
+// QuestInfo handles quests on a user
+type QuestInfo interface {
+	Quests() []QuestProgress
+	StartQuest(string) error
+	QuestEvent(string, string)
+}
+
+// Kind says what sort of objective this is and what it's after
+func (objective *QuestObjective) Kind() (string, string) {
+	if objective.Kill != "" {
+		return QUESTKILL, objective.Kill
+	} else if objective.Collect != "" {
+		return QUESTCOLLECT, objective.Collect
+	} else if objective.Reach != "" {
+		return QUESTREACH, objective.Reach
+	}
+
+	return QUESTTALK, objective.Talk
+}
+
+// Needed is how many times the objective has to be met
+func (objective *QuestObjective) Needed() uint64 {
+	if objective.Count == 0 {
+		return 1
+	}
+
+	return objective.Count
+}
+
+// Matches checks whether an event counts toward the objective
+func (objective *QuestObjective) Matches(kind, subject string) bool {
+	objectiveKind, target := objective.Kind()
+
+	if objectiveKind != kind {
+		return false
+	} else if kind == QUESTREACH {
+		return strings.Contains(strings.ToLower(subject), strings.ToLower(target))
+	}
+
+	return subject == target
+}
+
+func (objective *QuestObjective) String() string {
+	kind, target := objective.Kind()
+
+	switch kind {
+	case QUESTKILL:
+		if creatureType, ok := CreatureTypes[target]; ok {
+			target = creatureType.Name
+		}
+	case QUESTREACH:
+		return fmt.Sprintf("Find %v", target)
+	case QUESTTALK:
+		if creatureType, ok := CreatureTypes[target]; ok {
+			target = creatureType.Name
+		}
+		return fmt.Sprintf("Talk to %v", target)
+	}
+
+	return fmt.Sprintf("%v %v", kind, target)
+}
+
+// QuestType looks up the definition of a quest in progress
+func (progress *QuestProgress) QuestType() (QuestType, bool) {
+	questType, ok := QuestTypes[progress.QuestID]
+	return questType, ok
+}
+
+// Count is how far along an objective is, given what the user is carrying
+func (progress *QuestProgress) Count(index int, carrying map[string]int) uint64 {
+	questType, ok := progress.QuestType()
+	if !ok || index >= len(questType.Objectives) {
+		return 0
+	}
+
+	objective := questType.Objectives[index]
+	count := uint64(0)
+
+	if kind, name := objective.Kind(); kind == QUESTCOLLECT {
+		count = uint64(carrying[name])
+	} else if index < len(progress.Counts) {
+		count = progress.Counts[index]
+	}
+
+	if count > objective.Needed() {
+		return objective.Needed()
+	}
+
+	return count
+}
+
+// Complete checks whether every objective is met
+func (progress *QuestProgress) Complete(carrying map[string]int) bool {
+	questType, ok := progress.QuestType()
+	if !ok || progress.Done {
+		return false
+	}
+
+	for index, objective := range questType.Objectives {
+		if progress.Count(index, carrying) < objective.Needed() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Record counts an event toward any objectives it matches, returning true if anything changed.
+// Talk objectives are for handing a quest in, so they only count once everything before them is met.
+func (progress *QuestProgress) Record(kind, subject string, carrying map[string]int) bool {
+	questType, ok := progress.QuestType()
+	if !ok || progress.Done || kind == QUESTCOLLECT {
+		// Collecting is counted from the inventory, the event is only a cue to check for completion
+		return false
+	}
+
+	for len(progress.Counts) < len(questType.Objectives) {
+		progress.Counts = append(progress.Counts, 0)
+	}
+
+	changed := false
+	for index, objective := range questType.Objectives {
+		if !objective.Matches(kind, subject) || progress.Counts[index] >= objective.Needed() {
+			continue
+		}
+
+		if kind == QUESTTALK {
+			ready := true
+			for previous := 0; previous < index; previous++ {
+				if progress.Count(previous, carrying) < questType.Objectives[previous].Needed() {
+					ready = false
+					break
+				}
+			}
+
+			if !ready {
+				continue
+			}
+		}
+
+		progress.Counts[index]++
+		changed = true
+	}
+
+	return changed
+}
+
+// carriedItems counts items by name
+func carriedItems(items []*InventoryItem) map[string]int {
+	carrying := make(map[string]int)
+
+	for _, item := range items {
+		carrying[item.Name]++
+	}
+
+	return carrying
+}
+
+// questStates sorts a user's quests into the ones in progress and the ones they've finished
+func questStates(quests []QuestProgress) (map[string]bool, map[string]bool) {
+	active, done := make(map[string]bool), make(map[string]bool)
+
+	for _, quest := range quests {
+		if quest.Done {
+			done[quest.QuestID] = true
+		} else {
+			active[quest.QuestID] = true
+		}
+	}
+
+	return active, done
+}
+
+func loadQuestTypes(questInfoFile string) {
+	data, err := ioutil.ReadFile(questInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &QuestTypes)
+	}
+
+	for k, v := range QuestTypes {
+		v.ID = k
+		QuestTypes[k] = v
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", questInfoFile, err)
+	}
+}
+
+func init() {
+	QuestTypes = make(map[string]QuestType)
+}
@@ -0,0 +1,129 @@
+package mud
+
+import "testing"
+
+func TestQuestProgressRecord(t *testing.T) {
+	type event struct{ kind, subject string }
+
+	tests := []struct {
+		name     string
+		events   []event
+		carrying map[string]int
+		want     []uint64
+	}{
+		{"kills count", []event{{QUESTKILL, "rat"}, {QUESTKILL, "rat"}}, nil, []uint64{2, 0}},
+		{"kills stop at the count", []event{{QUESTKILL, "rat"}, {QUESTKILL, "rat"}, {QUESTKILL, "rat"}, {QUESTKILL, "rat"}}, nil, []uint64{3, 0}},
+		{"other kills don't count", []event{{QUESTKILL, "goat"}}, nil, []uint64{0, 0}},
+		{"talking too early", []event{{QUESTKILL, "rat"}, {QUESTTALK, "hermit"}}, nil, []uint64{1, 0}},
+		{"talking once ready", []event{{QUESTKILL, "rat"}, {QUESTKILL, "rat"}, {QUESTKILL, "rat"}, {QUESTTALK, "hermit"}}, nil, []uint64{3, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			progress := QuestProgress{QuestID: "rat-problem"}
+			for _, e := range test.events {
+				progress.Record(e.kind, e.subject, test.carrying)
+			}
+
+			for index, want := range test.want {
+				if got := progress.Count(index, test.carrying); got != want {
+					t.Fatalf("objective %v has count %v, want %v", index, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQuestProgressComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress QuestProgress
+		carrying map[string]int
+		want     bool
+	}{
+		{"nothing done", QuestProgress{QuestID: "shiny-rocks"}, nil, false},
+		{"carrying enough, not handed in", QuestProgress{QuestID: "shiny-rocks"}, map[string]int{"Shiny Rock": 3}, false},
+		{"handed in", QuestProgress{QuestID: "shiny-rocks", Counts: []uint64{0, 1}}, map[string]int{"Shiny Rock": 3}, true},
+		{"handed in, then dropped the rocks", QuestProgress{QuestID: "shiny-rocks", Counts: []uint64{0, 1}}, map[string]int{"Shiny Rock": 2}, false},
+		{"already finished", QuestProgress{QuestID: "shiny-rocks", Counts: []uint64{0, 1}, Done: true}, map[string]int{"Shiny Rock": 3}, false},
+		{"no such quest", QuestProgress{QuestID: "missing"}, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.progress.Complete(test.carrying); got != test.want {
+				t.Fatalf("Complete() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQuestEventFinishesQuest(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "questor")
+
+	if err := user.StartQuest("shiny-rocks"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		giveTestItem(t, user, "Shiny Rock")
+	}
+	user.QuestEvent(QUESTTALK, "hermit")
+
+	quests := user.Quests()
+	if len(quests) != 1 || !quests[0].Done {
+		t.Fatalf("quests = %+v, want shiny-rocks done", quests)
+	}
+
+	carrying := carriedItems(user.InventoryItems())
+	if carrying["Shiny Rock"] != 0 || carrying["Scroll of Warding"] != 1 {
+		t.Fatalf("carrying %v after handing in, want the rocks swapped for the reward", carrying)
+	}
+
+	user.Reload()
+	if user.XP() == 0 {
+		t.Fatal("no XP for finishing the quest")
+	}
+}
+
+func TestFinishQuestNeedsTheItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		rocks    int
+		wantDone bool
+		wantLeft int
+	}{
+		{"all there", 4, true, 1},
+		{"one short", 2, false, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "questor")
+
+			if err := user.StartQuest("shiny-rocks"); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < test.rocks; i++ {
+				giveTestItem(t, user, "Shiny Rock")
+			}
+
+			// Called straight away, as if the rocks were dropped after the quest was checked
+			user.(*dbUser).finishQuest("shiny-rocks")
+
+			quests := user.Quests()
+			if len(quests) != 1 || quests[0].Done != test.wantDone {
+				t.Fatalf("quests = %+v, want done %v", quests, test.wantDone)
+			}
+			if left := carriedItems(user.InventoryItems())["Shiny Rock"]; left != test.wantLeft {
+				t.Fatalf("%v rocks left, want %v", left, test.wantLeft)
+			}
+
+			user.Reload()
+			if rewarded := user.XP() > 0; rewarded != test.wantDone {
+				t.Fatalf("got the XP: %v", rewarded)
+			}
+		})
+	}
+}
@@ -0,0 +1,1004 @@
+package mud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// terrainRepository stores CellInfo records keyed by Point
+type terrainRepository struct {
+	bucket Bucket
+}
+
+func terrainRepo(tx Tx) terrainRepository {
+	return terrainRepository{bucket: tx.Bucket("terrain")}
+}
+
+// Get fetches the raw stored CellInfo for a point
+func (r terrainRepository) Get(pt Point) (CellInfo, bool) {
+	var cellInfo CellInfo
+
+	record := r.bucket.Get(pt.Bytes())
+
+	if record == nil {
+		return cellInfo, false
+	}
+
+	return cellInfo, MSGUnpack(record, &cellInfo) == nil
+}
+
+// Put stores CellInfo for a point
+func (r terrainRepository) Put(pt Point, cellInfo *CellInfo) error {
+	bytes, err := MSGPack(cellInfo)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Put(pt.Bytes(), bytes)
+}
+
+// Delete clears the terrain at a point
+func (r terrainRepository) Delete(pt Point) error {
+	return r.bucket.Delete(pt.Bytes())
+}
+
+// creatureRepository stores Creatures by ID, plus the per-cell index of which creatures are where
+type creatureRepository struct {
+	creatures    Bucket
+	creatureList Bucket
+}
+
+func creatureRepo(tx Tx) creatureRepository {
+	return creatureRepository{creatures: tx.Bucket("creatures"), creatureList: tx.Bucket("creaturelist")}
+}
+
+func creatureIDBytes(id string) ([]byte, error) {
+	cID, err := uuid.Parse(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cID.MarshalBinary()
+}
+
+// Get loads a stored creature, or nil if there is none with that ID
+func (r creatureRepository) Get(id string) *Creature {
+	byteID, err := creatureIDBytes(id)
+
+	if err != nil {
+		return nil
+	}
+
+	recordBytes := r.creatures.Get(byteID)
+
+	if recordBytes == nil {
+		return nil
+	}
+
+	creature := &Creature{}
+	if MSGUnpack(recordBytes, creature) != nil {
+		return nil
+	}
+
+	return creature
+}
+
+// Put saves a creature record without touching the cell index
+func (r creatureRepository) Put(creature *Creature) error {
+	byteID, err := creatureIDBytes(creature.ID)
+
+	if err != nil {
+		return err
+	}
+
+	creatureBytes, err := MSGPack(creature)
+
+	if err != nil {
+		return err
+	}
+
+	return r.creatures.Put(byteID, creatureBytes)
+}
+
+// Delete removes a creature record without touching the cell index
+func (r creatureRepository) Delete(id string) error {
+	byteID, err := creatureIDBytes(id)
+
+	if err != nil {
+		return err
+	}
+
+	return r.creatures.Delete(byteID)
+}
+
+// Place adds a creature to the index for a cell
+func (r creatureRepository) Place(pt Point, id string) error {
+	byteID, err := creatureIDBytes(id)
+
+	if err != nil {
+		return err
+	}
+
+	return r.creatureList.Put(prefixedKey(pt.Bytes(), byteID), make([]byte, 0))
+}
+
+// Unplace removes a creature from the index for a cell
+func (r creatureRepository) Unplace(pt Point, id string) error {
+	byteID, err := creatureIDBytes(id)
+
+	if err != nil {
+		return err
+	}
+
+	return r.creatureList.Delete(prefixedKey(pt.Bytes(), byteID))
+}
+
+// IDsAt lists the IDs of every creature indexed in a cell
+func (r creatureRepository) IDsAt(pt Point) []string {
+	creatureList := make([]string, 0)
+
+	min, max := prefixRange(pt.Bytes())
+
+	r.creatureList.Range(min, max, func(k, v []byte) error {
+		buf := bytes.NewBuffer(k)
+		PointFromBuffer(buf)
+		var b byte
+		binary.Read(buf, binary.BigEndian, &b)
+
+		var creatureID uuid.UUID
+		binary.Read(buf, binary.BigEndian, &creatureID)
+
+		creatureList = append(creatureList, creatureID.String())
+
+		return nil
+	})
+
+	return creatureList
+}
+
+// itemRepository stores InventoryItems grouped under an owner key, such as a Point or a username
+type itemRepository struct {
+	bucket Bucket
+}
+
+func placeItemRepo(tx Tx) itemRepository {
+	return itemRepository{bucket: tx.Bucket("placeitems")}
+}
+
+func userItemRepo(tx Tx) itemRepository {
+	return itemRepository{bucket: tx.Bucket("userinventory")}
+}
+
+// bankRepo stores what users keep in their banks, the same way as what they carry
+func bankRepo(tx Tx) itemRepository {
+	return itemRepository{bucket: tx.Bucket("userbank")}
+}
+
+func itemKey(owner []byte, id string) ([]byte, error) {
+	itemID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	idBytes, err := itemID.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return prefixedKey(owner, idBytes), nil
+}
+
+// List returns every item the owner holds
+func (r itemRepository) List(owner []byte) []*InventoryItem {
+	items := make([]*InventoryItem, 0)
+
+	min, max := prefixRange(owner)
+
+	r.bucket.Range(min, max, func(k, v []byte) error {
+		var inventoryItem InventoryItem
+
+		err := MSGUnpack(v, &inventoryItem)
+
+		if err != nil {
+			return err
+		}
+
+		items = append(items, &inventoryItem)
+
+		return nil
+	})
+
+	return items
+}
+
+// Any reports whether the owner holds anything at all
+func (r itemRepository) Any(owner []byte) bool {
+	hasItems := false
+
+	min, max := prefixRange(owner)
+
+	r.bucket.Range(min, max, func(k, v []byte) error {
+		hasItems = true
+
+		return errStopRange
+	})
+
+	return hasItems
+}
+
+// Get fetches one of the owner's items by ID
+func (r itemRepository) Get(owner []byte, id string) *InventoryItem {
+	key, err := itemKey(owner, id)
+
+	if err != nil {
+		return nil
+	}
+
+	itemBytes := r.bucket.Get(key)
+
+	if itemBytes == nil {
+		return nil
+	}
+
+	var inventoryItem InventoryItem
+	if MSGUnpack(itemBytes, &inventoryItem) != nil {
+		return nil
+	}
+	inventoryItem.ID = id
+
+	return &inventoryItem
+}
+
+// Put stores an item for the owner; the item must already have an ID
+func (r itemRepository) Put(owner []byte, item *InventoryItem) error {
+	key, err := itemKey(owner, item.ID)
+
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := MSGPack(*item)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Put(key, dataBytes)
+}
+
+// Delete takes an item away from the owner
+func (r itemRepository) Delete(owner []byte, id string) error {
+	key, err := itemKey(owner, id)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Delete(key)
+}
+
+// equipmentRepository stores what each user has in each equipment slot
+type equipmentRepository struct {
+	bucket Bucket
+}
+
+func equipmentRepo(tx Tx) equipmentRepository {
+	return equipmentRepository{bucket: tx.Bucket("userequipment")}
+}
+
+// Get returns the item in a user's slot, or nil if it is empty
+func (r equipmentRepository) Get(username, slot string) *InventoryItem {
+	itemBytes := r.bucket.Get(prefixedKey([]byte(username), []byte(slot)))
+
+	if len(itemBytes) == 0 {
+		return nil
+	}
+
+	var inventoryItem InventoryItem
+	if MSGUnpack(itemBytes, &inventoryItem) != nil {
+		return nil
+	}
+
+	return &inventoryItem
+}
+
+// Put fills a user's slot; a nil item empties it
+func (r equipmentRepository) Put(username, slot string, item *InventoryItem) error {
+	var dataBytes []byte
+
+	if item != nil {
+		var err error
+		dataBytes, err = MSGPack(*item)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.bucket.Put(prefixedKey([]byte(username), []byte(slot)), dataBytes)
+}
+
+// userRepository stores UserData along with presence and last action timestamps
+type userRepository struct {
+	users          Bucket
+	onlineUsers    Bucket
+	lastUserAction Bucket
+}
+
+func userRepo(tx Tx) userRepository {
+	return userRepository{users: tx.Bucket("users"), onlineUsers: tx.Bucket("onlineusers"), lastUserAction: tx.Bucket("lastuseraction")}
+}
+
+// Get loads a user's data
+func (r userRepository) Get(username string) (UserData, bool) {
+	var userData UserData
+
+	record := r.users.Get([]byte(username))
+
+	if record == nil {
+		return userData, false
+	}
+
+	return userData, MSGUnpack(record, &userData) == nil
+}
+
+// Put saves a user's data
+func (r userRepository) Put(userData *UserData) error {
+	bytes, err := MSGPack(*userData)
+
+	if err != nil {
+		return err
+	}
+
+	return r.users.Put([]byte(userData.Username), bytes)
+}
+
+// MarkOnline records that a user was seen at a time
+func (r userRepository) MarkOnline(username string, when time.Time) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, when.UTC().Unix())
+
+	return r.onlineUsers.Put([]byte(username), buf.Bytes())
+}
+
+// Online returns when each user that's currently marked online was last seen
+func (r userRepository) Online() map[string]int64 {
+	lastSeen := make(map[string]int64)
+
+	r.onlineUsers.ForEach(func(k, v []byte) error {
+		var lastUpdate int64
+		buf := bytes.NewBuffer(v)
+		binary.Read(buf, binary.BigEndian, &lastUpdate)
+
+		lastSeen[string(k)] = lastUpdate
+
+		return nil
+	})
+
+	return lastSeen
+}
+
+// MarkOffline drops a user from the online list
+func (r userRepository) MarkOffline(username string) error {
+	return r.onlineUsers.Delete([]byte(username))
+}
+
+// Act records when a user last acted
+func (r userRepository) Act(username string, when time.Time) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, when.UTC().UnixNano())
+
+	return r.lastUserAction.Put([]byte(username), buf.Bytes())
+}
+
+// Delay moves a user's last action later, so charge builds as if less time had gone by.
+// It never moves it past now.
+func (r userRepository) Delay(username string, by time.Duration, now time.Time) error {
+	last, ok := r.LastAction(username)
+	if !ok {
+		return nil
+	}
+
+	later := time.Unix(0, last).Add(by)
+	if later.After(now) {
+		later = now
+	}
+
+	return r.Act(username, later)
+}
+
+// Delete removes a user's data, presence and last action
+func (r userRepository) Delete(username string) error {
+	if err := r.onlineUsers.Delete([]byte(username)); err != nil {
+		return err
+	}
+	if err := r.lastUserAction.Delete([]byte(username)); err != nil {
+		return err
+	}
+
+	return r.users.Delete([]byte(username))
+}
+
+// LastAction returns the nanosecond timestamp of a user's last action
+func (r userRepository) LastAction(username string) (int64, bool) {
+	stamp := r.lastUserAction.Get([]byte(username))
+	buf := bytes.NewBuffer(stamp)
+
+	var last int64
+
+	if binary.Read(buf, binary.BigEndian, &last) != nil {
+		return 0, false
+	}
+
+	return last, true
+}
+
+// logRepository stores each user's message log, newest first
+type logRepository struct {
+	bucket Bucket
+}
+
+func logRepo(tx Tx) logRepository {
+	return logRepository{bucket: tx.Bucket("userlog")}
+}
+
+// Append adds a message to the top of a user's log
+func (r logRepository) Append(username string, message LogItem, when time.Time) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, -when.UTC().UnixNano())
+
+	if message.Timestamp.IsZero() {
+		message.Timestamp = when.UTC()
+	}
+
+	messageBytes, err := MSGPack(message)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Put(prefixedKey([]byte(username), buf.Bytes()), messageBytes)
+}
+
+// Recent returns up to limit of a user's most recent messages
+func (r logRepository) Recent(username string, limit int) []LogItem {
+	logMessages := make([]LogItem, 0)
+
+	min, max := prefixRange([]byte(username))
+
+	r.bucket.Range(min, max, func(k, v []byte) error {
+		if len(logMessages) >= limit {
+			return errStopRange
+		}
+
+		var messageStruct LogItem
+
+		err := MSGUnpack(v, &messageStruct)
+
+		if err != nil {
+			return err
+		}
+
+		logMessages = append(logMessages, messageStruct)
+
+		return nil
+	})
+
+	return logMessages
+}
+
+// Since returns every message a user got after a time, newest first
+func (r logRepository) Since(username string, since time.Time) []LogItem {
+	logMessages := make([]LogItem, 0)
+	cutoff := since.UTC().UnixNano()
+
+	min, max := prefixRange([]byte(username))
+
+	r.bucket.Range(min, max, func(k, v []byte) error {
+		var negativeStamp int64
+		binary.Read(bytes.NewBuffer(k[len(k)-8:]), binary.BigEndian, &negativeStamp)
+
+		if -negativeStamp < cutoff {
+			return errStopRange
+		}
+
+		var messageStruct LogItem
+
+		err := MSGUnpack(v, &messageStruct)
+
+		if err != nil {
+			return err
+		}
+
+		logMessages = append(logMessages, messageStruct)
+
+		return nil
+	})
+
+	return logMessages
+}
+
+// placeNameRepository stores generated region names by sequential ID
+type placeNameRepository struct {
+	bucket Bucket
+}
+
+func placeNameRepo(tx Tx) placeNameRepository {
+	return placeNameRepository{bucket: tx.Bucket("placenames")}
+}
+
+func placeNameKey(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
+
+// NextID reserves the ID for a new place name
+func (r placeNameRepository) NextID() (uint64, error) {
+	return r.bucket.NextSequence()
+}
+
+// Put stores the name for a place ID
+func (r placeNameRepository) Put(id uint64, placeName string) error {
+	return r.bucket.Put(placeNameKey(id), []byte(placeName))
+}
+
+// Get looks up a place name by ID
+func (r placeNameRepository) Get(id uint64) (string, bool) {
+	record := r.bucket.Get(placeNameKey(id))
+
+	if record == nil {
+		return "", false
+	}
+
+	return string(record), true
+}
+
+// settingsRepository stores world-wide values that must survive restarts, like the seed
+type settingsRepository struct {
+	bucket Bucket
+}
+
+func settingsRepo(tx Tx) settingsRepository {
+	return settingsRepository{bucket: tx.Bucket("settings")}
+}
+
+// Seed returns the world seed, if one has been stored yet
+func (r settingsRepository) Seed() (int64, bool) {
+	buf := bytes.NewBuffer(r.bucket.Get([]byte("seed")))
+
+	var seed int64
+
+	if binary.Read(buf, binary.BigEndian, &seed) != nil {
+		return 0, false
+	}
+
+	return seed, true
+}
+
+// SetSeed stores the world seed
+func (r settingsRepository) SetSeed(seed int64) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, seed)
+
+	return r.bucket.Put([]byte("seed"), buf.Bytes())
+}
+
+// questRepository stores each user's progress on the quests they've started
+type questRepository struct {
+	bucket Bucket
+}
+
+func questRepo(tx Tx) questRepository {
+	return questRepository{bucket: tx.Bucket("userquests")}
+}
+
+// List returns every quest a user has started, finished or not
+func (r questRepository) List(username string) []QuestProgress {
+	quests := make([]QuestProgress, 0)
+
+	min, max := prefixRange([]byte(username))
+
+	r.bucket.Range(min, max, func(k, v []byte) error {
+		var progress QuestProgress
+
+		err := MSGUnpack(v, &progress)
+
+		if err != nil {
+			return err
+		}
+
+		quests = append(quests, progress)
+
+		return nil
+	})
+
+	return quests
+}
+
+// Get fetches a user's progress on one quest
+func (r questRepository) Get(username, questID string) (QuestProgress, bool) {
+	var progress QuestProgress
+
+	record := r.bucket.Get(prefixedKey([]byte(username), []byte(questID)))
+
+	if record == nil {
+		return progress, false
+	}
+
+	return progress, MSGUnpack(record, &progress) == nil
+}
+
+// Put saves a user's progress on a quest
+func (r questRepository) Put(username string, progress *QuestProgress) error {
+	bytes, err := MSGPack(*progress)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Put(prefixedKey([]byte(username), []byte(progress.QuestID)), bytes)
+}
+
+// partyRepository stores parties, along with an index of which party each user is in
+type partyRepository struct {
+	bucket  Bucket
+	members Bucket
+}
+
+func partyRepo(tx Tx) partyRepository {
+	return partyRepository{bucket: tx.Bucket("parties"), members: tx.Bucket("userparties")}
+}
+
+// Get fetches a party by ID
+func (r partyRepository) Get(id string) (Party, bool) {
+	var party Party
+
+	record := r.bucket.Get([]byte(id))
+
+	if record == nil {
+		return party, false
+	}
+
+	return party, MSGUnpack(record, &party) == nil
+}
+
+// ForUser fetches the party a user is in
+func (r partyRepository) ForUser(username string) (Party, bool) {
+	id := r.members.Get([]byte(username))
+
+	if id == nil {
+		return Party{}, false
+	}
+
+	return r.Get(string(id))
+}
+
+// Invitations lists the parties that have invited a user
+func (r partyRepository) Invitations(username string) []Party {
+	parties := make([]Party, 0)
+
+	r.bucket.ForEach(func(k, v []byte) error {
+		var party Party
+
+		if MSGUnpack(v, &party) == nil && party.IsInvited(username) {
+			parties = append(parties, party)
+		}
+
+		return nil
+	})
+
+	return parties
+}
+
+// Put saves a party and indexes its members
+func (r partyRepository) Put(party *Party) error {
+	bytes, err := MSGPack(*party)
+
+	if err != nil {
+		return err
+	}
+
+	for _, member := range party.Members {
+		if err := r.members.Put([]byte(member), []byte(party.ID)); err != nil {
+			return err
+		}
+	}
+
+	return r.bucket.Put([]byte(party.ID), bytes)
+}
+
+// Unindex forgets which party a user was in, for when they leave it
+func (r partyRepository) Unindex(username string) error {
+	return r.members.Delete([]byte(username))
+}
+
+// Delete removes a party and the index for whoever's still in it
+func (r partyRepository) Delete(party *Party) error {
+	for _, member := range party.Members {
+		if err := r.Unindex(member); err != nil {
+			return err
+		}
+	}
+
+	return r.bucket.Delete([]byte(party.ID))
+}
+
+// duelRepository stores duels and challenges under both users' names
+type duelRepository struct {
+	bucket Bucket
+}
+
+func duelRepo(tx Tx) duelRepository {
+	return duelRepository{bucket: tx.Bucket("duels")}
+}
+
+// Get fetches the duel or challenge a user is part of
+func (r duelRepository) Get(username string) (Duel, bool) {
+	var duel Duel
+
+	record := r.bucket.Get([]byte(username))
+
+	if record == nil {
+		return duel, false
+	}
+
+	return duel, MSGUnpack(record, &duel) == nil
+}
+
+// Put saves a duel for both users
+func (r duelRepository) Put(duel *Duel) error {
+	bytes, err := MSGPack(*duel)
+
+	if err != nil {
+		return err
+	}
+
+	if err := r.bucket.Put([]byte(duel.Challenger), bytes); err != nil {
+		return err
+	}
+
+	return r.bucket.Put([]byte(duel.Opponent), bytes)
+}
+
+// Delete removes a duel for both users
+func (r duelRepository) Delete(duel *Duel) error {
+	if err := r.bucket.Delete([]byte(duel.Challenger)); err != nil {
+		return err
+	}
+
+	return r.bucket.Delete([]byte(duel.Opponent))
+}
+
+// respawnRepository stores the respawn schedule, ordered by when each is due with an index by
+// cell, along with how many creatures are alive in each region
+type respawnRepository struct {
+	bucket  Bucket
+	cells   Bucket
+	regions Bucket
+}
+
+func respawnRepo(tx Tx) respawnRepository {
+	return respawnRepository{bucket: tx.Bucket("respawns"), cells: tx.Bucket("respawncells"), regions: tx.Bucket("regioncreatures")}
+}
+
+func respawnCellKey(location Point, kind byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(kind)
+	location.ToBytes(buf)
+
+	return buf.Bytes()
+}
+
+func respawnDueKey(due int64, cellKey []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, due)
+	buf.Write(cellKey)
+
+	return buf.Bytes()
+}
+
+// Schedule adds a respawn, unless the cell already has one of that kind waiting
+func (r respawnRepository) Schedule(respawn scheduledRespawn) error {
+	cellKey := respawnCellKey(respawn.Location, respawn.Kind)
+
+	if r.cells.Get(cellKey) != nil {
+		return nil
+	}
+
+	due := new(bytes.Buffer)
+	binary.Write(due, binary.BigEndian, respawn.Due)
+
+	if err := r.cells.Put(cellKey, due.Bytes()); err != nil {
+		return err
+	}
+
+	return r.bucket.Put(respawnDueKey(respawn.Due, cellKey), []byte{})
+}
+
+// Remove takes a respawn off the schedule
+func (r respawnRepository) Remove(respawn scheduledRespawn) error {
+	cellKey := respawnCellKey(respawn.Location, respawn.Kind)
+
+	if err := r.cells.Delete(cellKey); err != nil {
+		return err
+	}
+
+	return r.bucket.Delete(respawnDueKey(respawn.Due, cellKey))
+}
+
+// Due lists every respawn due by a given time, soonest first
+func (r respawnRepository) Due(now int64) []scheduledRespawn {
+	due := make([]scheduledRespawn, 0)
+
+	min := respawnDueKey(0, nil)
+	max := respawnDueKey(now+1, nil)
+
+	r.bucket.Range(min, max, func(k, v []byte) error {
+		buf := bytes.NewBuffer(k)
+
+		var respawn scheduledRespawn
+		binary.Read(buf, binary.BigEndian, &respawn.Due)
+		respawn.Kind, _ = buf.ReadByte()
+		respawn.Location = PointFromBuffer(buf)
+
+		due = append(due, respawn)
+
+		return nil
+	})
+
+	return due
+}
+
+func regionKey(region uint64) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, region)
+
+	return buf.Bytes()
+}
+
+// RegionCount is how many live creatures a region has
+func (r respawnRepository) RegionCount(region uint64) uint64 {
+	var count uint64
+
+	if binary.Read(bytes.NewBuffer(r.regions.Get(regionKey(region))), binary.BigEndian, &count) != nil {
+		return 0
+	}
+
+	return count
+}
+
+// AddToRegion changes a region's live creature count, never going below zero
+func (r respawnRepository) AddToRegion(region uint64, change int) error {
+	count := int64(r.RegionCount(region)) + int64(change)
+	if count < 0 {
+		count = 0
+	}
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint64(count))
+
+	return r.regions.Put(regionKey(region), buf.Bytes())
+}
+
+// shopRepository stores how much each vendor has left of what they sell
+type shopRepository struct {
+	bucket Bucket
+}
+
+func shopRepo(tx Tx) shopRepository {
+	return shopRepository{bucket: tx.Bucket("shopstock")}
+}
+
+// Get fetches a vendor's stock as of the last time it changed
+func (r shopRepository) Get(vendorID string) map[string]stockLevel {
+	stock := make(map[string]stockLevel)
+
+	record := r.bucket.Get([]byte(vendorID))
+
+	if record != nil {
+		MSGUnpack(record, &stock)
+	}
+
+	return stock
+}
+
+// Put saves a vendor's stock
+func (r shopRepository) Put(vendorID string, stock map[string]stockLevel) error {
+	bytes, err := MSGPack(stock)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Put([]byte(vendorID), bytes)
+}
+
+// Delete forgets a vendor's stock
+func (r shopRepository) Delete(vendorID string) error {
+	return r.bucket.Delete([]byte(vendorID))
+}
+
+// tradeRepository stores trades and trade requests under both users' names
+type tradeRepository struct {
+	bucket Bucket
+}
+
+func tradeRepo(tx Tx) tradeRepository {
+	return tradeRepository{bucket: tx.Bucket("trades")}
+}
+
+// Get fetches the trade or request a user is part of
+func (r tradeRepository) Get(username string) (Trade, bool) {
+	var trade Trade
+
+	record := r.bucket.Get([]byte(username))
+
+	if record == nil {
+		return trade, false
+	}
+
+	return trade, MSGUnpack(record, &trade) == nil
+}
+
+// Put saves a trade for both users
+func (r tradeRepository) Put(trade *Trade) error {
+	bytes, err := MSGPack(*trade)
+
+	if err != nil {
+		return err
+	}
+
+	if err := r.bucket.Put([]byte(trade.Requester), bytes); err != nil {
+		return err
+	}
+
+	return r.bucket.Put([]byte(trade.Partner), bytes)
+}
+
+// Delete removes a trade for both users
+func (r tradeRepository) Delete(trade *Trade) error {
+	if err := r.bucket.Delete([]byte(trade.Requester)); err != nil {
+		return err
+	}
+
+	return r.bucket.Delete([]byte(trade.Partner))
+}
+
+// accountRepository stores SSH accounts by the username they sign in with
+type accountRepository struct {
+	bucket Bucket
+}
+
+func accountRepo(tx Tx) accountRepository {
+	return accountRepository{bucket: tx.Bucket("accounts")}
+}
+
+// Get loads an account
+func (r accountRepository) Get(name string) (Account, bool) {
+	var account Account
+
+	record := r.bucket.Get([]byte(name))
+
+	if record == nil {
+		return account, false
+	}
+
+	return account, MSGUnpack(record, &account) == nil
+}
+
+// Put saves an account
+func (r accountRepository) Put(account *Account) error {
+	bytes, err := MSGPack(*account)
+
+	if err != nil {
+		return err
+	}
+
+	return r.bucket.Put([]byte(account.Name), bytes)
+}
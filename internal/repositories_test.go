@@ -0,0 +1,241 @@
+package mud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// inTestTx runs fn in a write transaction on a fresh in-memory store with every world bucket
+func inTestTx(t *testing.T, fn func(Tx)) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	err := store.Update(func(tx Tx) error {
+		for _, bucket := range storeBuckets {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		fn(tx)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTerrainRepository(t *testing.T) {
+	inTestTx(t, func(tx Tx) {
+		terrain := terrainRepo(tx)
+		here, there := Point{X: 10, Y: 20}, Point{X: 20, Y: 10}
+
+		terrain.Put(here, &CellInfo{TerrainID: "clearing", RegionNameID: 3})
+
+		if cellInfo, ok := terrain.Get(here); !ok || cellInfo.TerrainID != "clearing" || cellInfo.RegionNameID != 3 {
+			t.Fatalf("Get(here) = %+v, %v", cellInfo, ok)
+		}
+		if _, ok := terrain.Get(there); ok {
+			t.Fatal("Get(there) found terrain that was never put")
+		}
+
+		terrain.Delete(here)
+		if _, ok := terrain.Get(here); ok {
+			t.Fatal("Get(here) found deleted terrain")
+		}
+	})
+}
+
+func TestCreatureRepositoryIndex(t *testing.T) {
+	here, there := Point{X: 5, Y: 5}, Point{X: 5, Y: 6}
+	ids := []string{uuid.New().String(), uuid.New().String(), uuid.New().String()}
+
+	tests := []struct {
+		name     string
+		unplace  []string
+		wantHere int
+	}{
+		{"all placed", nil, 2},
+		{"one moved out", ids[:1], 1},
+		{"all moved out", ids[:2], 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inTestTx(t, func(tx Tx) {
+				creatures := creatureRepo(tx)
+
+				for i, id := range ids {
+					creatures.Put(&Creature{ID: id, CreatureType: "rat", HP: uint64(i + 1)})
+				}
+				creatures.Place(here, ids[0])
+				creatures.Place(here, ids[1])
+				creatures.Place(there, ids[2])
+
+				for _, id := range test.unplace {
+					creatures.Unplace(here, id)
+				}
+
+				if got := len(creatures.IDsAt(here)); got != test.wantHere {
+					t.Fatalf("%v creatures here, want %v", got, test.wantHere)
+				}
+				if got := creatures.IDsAt(there); len(got) != 1 || got[0] != ids[2] {
+					t.Fatalf("creatures there = %v, want [%v]", got, ids[2])
+				}
+				if creature := creatures.Get(ids[1]); creature == nil || creature.HP != 2 {
+					t.Fatalf("Get() = %+v", creature)
+				}
+			})
+		})
+	}
+}
+
+func TestItemRepositoryOwners(t *testing.T) {
+	inTestTx(t, func(tx Tx) {
+		items := userItemRepo(tx)
+
+		// "ann" is a prefix of "anna", which mustn't leak into ann's inventory
+		carried := map[string]int{"ann": 2, "anna": 3, "bob": 0}
+		for owner, count := range carried {
+			for i := 0; i < count; i++ {
+				items.Put([]byte(owner), &InventoryItem{ID: uuid.New().String(), Name: owner})
+			}
+		}
+
+		for owner, count := range carried {
+			list := items.List([]byte(owner))
+			if len(list) != count {
+				t.Fatalf("%v has %v items, want %v", owner, len(list), count)
+			}
+			for _, item := range list {
+				if item.Name != owner {
+					t.Fatalf("%v has %v's item", owner, item.Name)
+				}
+			}
+			if items.Any([]byte(owner)) != (count > 0) {
+				t.Fatalf("Any(%v) = %v", owner, !(count > 0))
+			}
+		}
+
+		item := items.List([]byte("anna"))[0]
+		items.Delete([]byte("anna"), item.ID)
+		if items.Get([]byte("anna"), item.ID) != nil {
+			t.Fatal("deleted item is still there")
+		}
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	inTestTx(t, func(tx Tx) {
+		users := userRepo(tx)
+		now := time.Now()
+
+		users.Put(&UserData{Username: "ann", HP: 7})
+		users.Act("ann", now)
+
+		if userData, ok := users.Get("ann"); !ok || userData.HP != 7 {
+			t.Fatalf("Get(ann) = %+v, %v", userData, ok)
+		}
+		if _, ok := users.Get("bob"); ok {
+			t.Fatal("Get(bob) found a user that was never put")
+		}
+		if last, ok := users.LastAction("ann"); !ok || last != now.UTC().UnixNano() {
+			t.Fatalf("LastAction(ann) = %v, %v", last, ok)
+		}
+		if _, ok := users.LastAction("bob"); ok {
+			t.Fatal("LastAction(bob) found an action that never happened")
+		}
+	})
+}
+
+func TestUserRepositoryDelay(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		since time.Duration
+		by    time.Duration
+		want  time.Time
+	}{
+		{"half a tick", 10 * time.Second, time.Second / 2, now.Add(-10*time.Second + time.Second/2)},
+		{"never past now", time.Second / 4, time.Second / 2, now},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inTestTx(t, func(tx Tx) {
+				users := userRepo(tx)
+				users.Act("ann", now.Add(-test.since))
+
+				if err := users.Delay("ann", test.by, now); err != nil {
+					t.Fatal(err)
+				}
+				if last, _ := users.LastAction("ann"); last != test.want.UnixNano() {
+					t.Fatalf("LastAction(ann) = %v, want %v", last, test.want.UnixNano())
+				}
+
+				users.Delay("bob", test.by, now)
+				if _, ok := users.LastAction("bob"); ok {
+					t.Fatal("Delay(bob) made up an action")
+				}
+			})
+		})
+	}
+}
+
+func TestLogRepositoryRecent(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{2, []string{"four", "three"}},
+		{10, []string{"four", "three", "two", "one"}},
+	}
+
+	for _, test := range tests {
+		inTestTx(t, func(tx Tx) {
+			logs := logRepo(tx)
+			start := time.Now()
+
+			for i, message := range []string{"one", "two", "three", "four"} {
+				logs.Append("ann", LogItem{Message: message}, start.Add(time.Duration(i)*time.Second))
+			}
+			logs.Append("anna", LogItem{Message: "not ann's"}, start.Add(time.Minute))
+
+			got := logs.Recent("ann", test.limit)
+			if len(got) != len(test.want) {
+				t.Fatalf("Recent(ann, %v) has %v messages, want %v", test.limit, len(got), len(test.want))
+			}
+			for i := range got {
+				if got[i].Message != test.want[i] {
+					t.Fatalf("Recent(ann, %v)[%v] = %v, want %v", test.limit, i, got[i].Message, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceNameRepository(t *testing.T) {
+	inTestTx(t, func(tx Tx) {
+		placeNames := placeNameRepo(tx)
+
+		first, _ := placeNames.NextID()
+		second, _ := placeNames.NextID()
+		placeNames.Put(first, "Dover")
+		placeNames.Put(second, "Lewes")
+
+		if first == second {
+			t.Fatalf("both names got ID %v", first)
+		}
+		if name, ok := placeNames.Get(second); !ok || name != "Lewes" {
+			t.Fatalf("Get(%v) = %v, %v", second, name, ok)
+		}
+		if _, ok := placeNames.Get(second + 1); ok {
+			t.Fatal("Get() found a name that was never put")
+		}
+	})
+}
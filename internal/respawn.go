@@ -0,0 +1,239 @@
+package mud
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Kinds of respawn a cell can have scheduled
+const (
+	RESPAWNCREATURES byte = 'c'
+	RESPAWNITEMS     byte = 'i'
+	RESPAWNBOSS      byte = 'b'
+)
+
+// RespawnConfig controls how cleared cells fill back up; anything left at 0 uses the default
+type RespawnConfig struct {
+	CreatureSeconds int64 `json:",omitempty"` // How long after a creature dies before the cell it spawned in rolls for new ones
+	ItemSeconds     int64 `json:",omitempty"` // How long after a cell's last item is taken before it rolls for new ones
+	RegionCap       int   `json:",omitempty"` // Most live creatures a region can hold before respawns there wait
+	BossSeconds     int64 `json:",omitempty"` // How long after a boss dies before it returns to its lair
+}
+
+const (
+	defaultCreatureRespawnSeconds = 300
+	defaultItemRespawnSeconds     = 900
+	defaultRegionCreatureCap      = 40
+	defaultBossRespawnSeconds     = 3600
+
+	// respawnRetrySeconds is how long a respawn waits when it can't happen yet, because someone's
+	// standing there or the region is full
+	respawnRetrySeconds = 60
+)
+
+// scheduledRespawn is a cell due to roll for creatures or items again
+type scheduledRespawn struct {
+	Location Point
+	Kind     byte
+	Due      int64
+}
+
+func (config RespawnConfig) creatureSeconds() int64 {
+	if config.CreatureSeconds <= 0 {
+		return defaultCreatureRespawnSeconds
+	}
+
+	return config.CreatureSeconds
+}
+
+func (config RespawnConfig) itemSeconds() int64 {
+	if config.ItemSeconds <= 0 {
+		return defaultItemRespawnSeconds
+	}
+
+	return config.ItemSeconds
+}
+
+func (config RespawnConfig) bossSeconds() int64 {
+	if config.BossSeconds <= 0 {
+		return defaultBossRespawnSeconds
+	}
+
+	return config.BossSeconds
+}
+
+func (config RespawnConfig) regionCap() int {
+	if config.RegionCap <= 0 {
+		return defaultRegionCreatureCap
+	}
+
+	return config.RegionCap
+}
+
+// ConfigureRespawns sets the cooldowns and caps used by the respawn scheduler
+func (w *dbWorld) ConfigureRespawns(config RespawnConfig) {
+	w.respawns = config
+}
+
+// scheduleRespawn sets a cell to roll for creatures or items again after the cooldown. A cell
+// that's already waiting keeps its timer.
+func (w *dbWorld) scheduleRespawn(location Point, kind byte) {
+	cellInfo := w.CellAtPoint(location).CellInfo()
+	if cellInfo == nil {
+		return
+	}
+
+	delay := w.respawns.itemSeconds()
+	switch kind {
+	case RESPAWNCREATURES:
+		if len(cellInfo.TerrainData.CreatureSpawns) == 0 {
+			return
+		}
+		delay = w.respawns.creatureSeconds()
+	case RESPAWNBOSS:
+		if lairBoss(cellInfo) == "" {
+			return
+		}
+		delay = w.respawns.bossSeconds()
+	default:
+		if len(cellInfo.TerrainData.ItemDrops) == 0 {
+			return
+		}
+	}
+
+	err := w.store.Update(func(tx Tx) error {
+		return respawnRepo(tx).Schedule(scheduledRespawn{Location: location, Kind: kind, Due: time.Now().Unix() + delay})
+	})
+
+	if err != nil {
+		log.Printf("Can't schedule respawn at %v: %v", location, err)
+	}
+}
+
+// runRespawns rolls every cell whose respawn has come due
+func (w *dbWorld) runRespawns() {
+	var due []scheduledRespawn
+	now := time.Now().Unix()
+
+	w.store.View(func(tx Tx) error {
+		due = respawnRepo(tx).Due(now)
+
+		return nil
+	})
+
+	for _, respawn := range due {
+		retry := int64(0)
+
+		switch respawn.Kind {
+		case RESPAWNCREATURES:
+			retry = w.respawnCreatures(respawn)
+		case RESPAWNITEMS:
+			retry = w.respawnItems(respawn)
+		case RESPAWNBOSS:
+			retry = w.respawnBoss(respawn)
+		}
+
+		w.store.Update(func(tx Tx) error {
+			respawns := respawnRepo(tx)
+
+			if err := respawns.Remove(respawn); err != nil || retry == 0 {
+				return err
+			}
+
+			respawn.Due = now + retry
+			return respawns.Schedule(respawn)
+		})
+	}
+}
+
+// respawnCreatures re-rolls a cell's creature spawns, as long as everything that spawned there
+// before is dead. It returns how long to wait before trying again, or 0 if it's done.
+func (w *dbWorld) respawnCreatures(respawn scheduledRespawn) int64 {
+	cell := &dbCell{w: w, x: respawn.Location.X, y: respawn.Location.Y}
+	cellInfo := cell.CellInfo()
+
+	if cellInfo == nil {
+		return 0
+	}
+
+	for _, creature := range cell.GetCreatures() {
+		if creature.HP > 0 {
+			// Still occupied; the next death schedules another try
+			return 0
+		}
+	}
+
+	if len(w.usersInCell(respawn.Location)) > 0 {
+		return respawnRetrySeconds
+	}
+
+	room := w.respawns.regionCap() - int(w.regionCreatureCount(cellInfo.RegionNameID))
+	if room <= 0 {
+		return respawnRetrySeconds
+	}
+
+	rng := regionRand(w, fmt.Sprintf("respawn:%v", respawn.Due), respawn.Location)
+	if cell.spawnCreatures(cellInfo.TerrainData.CreatureSpawns, rng, room) == 0 {
+		// Nothing turned up this time; roll again after another cooldown
+		return w.respawns.creatureSeconds()
+	}
+
+	return 0
+}
+
+// respawnItems re-rolls a cell's item drops if nothing has been left lying there since. It
+// returns how long to wait before trying again, or 0 if it's done.
+func (w *dbWorld) respawnItems(respawn scheduledRespawn) int64 {
+	cell := &dbCell{w: w, x: respawn.Location.X, y: respawn.Location.Y}
+	cellInfo := cell.CellInfo()
+
+	if cellInfo == nil || cell.HasInventoryItems() {
+		return 0
+	}
+
+	rng := regionRand(w, fmt.Sprintf("respawn:%v", respawn.Due), respawn.Location)
+	if cell.dropItems(cellInfo.TerrainData.ItemDrops, rng) == 0 {
+		return w.respawns.itemSeconds()
+	}
+
+	return 0
+}
+
+// respawnBoss brings a lair's boss back once nobody is standing in it. It returns how long to
+// wait before trying again, or 0 if it's done.
+func (w *dbWorld) respawnBoss(respawn scheduledRespawn) int64 {
+	cell := &dbCell{w: w, x: respawn.Location.X, y: respawn.Location.Y}
+	boss := lairBoss(cell.CellInfo())
+
+	if boss == "" {
+		return 0
+	}
+
+	for _, creature := range cell.GetCreatures() {
+		if creature.CreatureType == boss && creature.HP > 0 {
+			return 0
+		}
+	}
+
+	if len(w.usersInCell(respawn.Location)) > 0 {
+		return respawnRetrySeconds
+	}
+
+	cell.AddStockCreature(boss)
+
+	return 0
+}
+
+// regionCreatureCount is how many creatures are alive in a region
+func (w *dbWorld) regionCreatureCount(region uint64) uint64 {
+	count := uint64(0)
+
+	w.store.View(func(tx Tx) error {
+		count = respawnRepo(tx).RegionCount(region)
+
+		return nil
+	})
+
+	return count
+}
@@ -0,0 +1,76 @@
+package mud
+
+import "testing"
+
+// testDen registers a terrain that's laid down empty, then turns out to always spawn a cluster of rats
+func testDen(t *testing.T) {
+	t.Helper()
+
+	CellTypes["test-den"] = CellTerrain{ID: "test-den", Name: "Den of %v"}
+	t.Cleanup(func() { delete(CellTypes, "test-den") })
+}
+
+func TestRespawnCreaturesRegionCap(t *testing.T) {
+	const region = 9999
+
+	tests := []struct {
+		name      string
+		alive     int
+		occupied  bool
+		wantRetry int64
+		wantAdded int
+	}{
+		{"empty region", 0, false, 0, 3},
+		{"room for some", 3, false, 0, 2},
+		{"region full", 5, false, respawnRetrySeconds, 0},
+		{"someone standing there", 0, true, respawnRetrySeconds, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testDen(t)
+			world := newTestWorld(t)
+			world.ConfigureRespawns(RespawnConfig{RegionCap: 5})
+			user := newTestUser(t, world, "camper")
+
+			den := *user.Location()
+			if !test.occupied {
+				den.X += 50
+			}
+			world.CellAtPoint(den).SetCellInfo(&CellInfo{TerrainID: "test-den", RegionNameID: region})
+
+			spawns := CellTypes["test-den"]
+			spawns.CreatureSpawns = []CreatureSpawn{{Name: "rat", Probability: 1, Cluster: 3}}
+			CellTypes["test-den"] = spawns
+
+			world.store.Update(func(tx Tx) error {
+				return respawnRepo(tx).AddToRegion(region, test.alive)
+			})
+
+			retry := world.respawnCreatures(scheduledRespawn{Location: den, Kind: RESPAWNCREATURES})
+			if retry != test.wantRetry {
+				t.Fatalf("respawnCreatures() = %v, want %v", retry, test.wantRetry)
+			}
+
+			added := len((&dbCell{w: world, x: den.X, y: den.Y}).creatureList())
+			if added != test.wantAdded {
+				t.Fatalf("respawned %v creatures, want %v", added, test.wantAdded)
+			}
+			if count := world.regionCreatureCount(region); count != uint64(test.alive+added) {
+				t.Fatalf("region counts %v creatures, want %v", count, test.alive+added)
+			}
+		})
+	}
+}
+
+func TestRespawnConfigDefaults(t *testing.T) {
+	var config RespawnConfig
+	if config.creatureSeconds() != defaultCreatureRespawnSeconds || config.regionCap() != defaultRegionCreatureCap {
+		t.Fatalf("zero config gives %v seconds and a cap of %v", config.creatureSeconds(), config.regionCap())
+	}
+
+	config = RespawnConfig{CreatureSeconds: 5, RegionCap: 2}
+	if config.creatureSeconds() != 5 || config.regionCap() != 2 {
+		t.Fatalf("config gives %v seconds and a cap of %v", config.creatureSeconds(), config.regionCap())
+	}
+}
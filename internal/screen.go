@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/ahmetb/go-cursor"
@@ -22,11 +23,16 @@ type Screen interface {
 	InCommandMode() bool
 	HandleInputKey(string)
 	GetChat() string
+	ChatChannel() string
+	SetChatChannel(string)
+	RunCommand(string)
+	CompleteCommand()
 	ToggleInventory()
 	InventoryActive() bool
 	PreviousInventoryItem()
 	NextInventoryItem()
 	Render()
+	Redraw()
 	Reset()
 }
 
@@ -42,9 +48,25 @@ type sshScreen struct {
 	inputSticky      bool
 	inputText        string
 	commandMode      bool
+	chatChannel      string
 	inventoryActive  bool
+	questsActive     bool
+	craftingActive   bool
+	talentsActive    bool
+	shopActive       bool
+	bankActive       bool
+	tradeActive      bool
 	inventoryIndex   int
+	recipeIndex      int
+	talentIndex      int
+	shopIndex        int
+	bankIndex        int
+	tradeIndex       int
+	shoppingAt       string
 	selectedCreature string
+	selectedUser     string
+	talkingTo        string
+	dialogueNode     string
 }
 
 const allowMouseInputAndHideCursor string = "\x1b[?1003h\x1b[?25l"
@@ -103,6 +125,29 @@ func centerText(message, pad string, width int) string {
 	return fmt.Sprintf("%s%s%s", string([]rune(leftString)[0:left]), message, string([]rune(leftString)[0:right]))
 }
 
+// wrapText breaks a message into lines no wider than width, splitting on spaces
+func wrapText(message string, width int) []string {
+	lines := make([]string, 0)
+	line := ""
+
+	for _, word := range strings.Fields(message) {
+		if line == "" {
+			line = word
+		} else if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line += " " + word
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
 func groupInventory(items []*InventoryItem) (map[string]int, map[string]string, []string) {
 	itemCount := make(map[string]int)
 	itemID := make(map[string]string)
@@ -137,6 +182,14 @@ func (item *LogItem) SSHString(width int) string {
 	activityFunc := ansi.ColorFunc(fmt.Sprintf("230:%v", bgcolor))
 	switch item.MessageType {
 	case MESSAGECHAT:
+		if channel, ok := ChatChannels[item.Channel]; ok {
+			author := item.ChatAuthor()
+			channelFunc := ansi.ColorFunc(fmt.Sprintf("%v:%v", channel.Color, bgcolor))
+			channelBoldFunc := ansi.ColorFunc(fmt.Sprintf("%v+b:%v", channel.Color, bgcolor))
+
+			return channelBoldFunc(author) + channelFunc(": "+truncateRight(item.Message, width-(2+utf8.RuneCountInString(author))))
+		}
+
 		return boldFormatFunc(item.Author) + formatFunc(": "+truncateRight(item.Message, width-(2+utf8.RuneCountInString(item.Author))))
 	case MESSAGESYSTEM:
 		return systemFunc(centerText(item.Message, " ", width))
@@ -187,7 +240,7 @@ func (screen *sshScreen) renderMap() {
 		} else {
 			height = (height / 2) - 2
 		}
-		mapArray := interfaceTools.GetTerrainMap(location.X, location.Y, uint32(screen.screenSize.Width/2)-4, height)
+		mapArray := interfaceTools.GetTerrainMap(screen.user, location.X, location.Y, uint32(screen.screenSize.Width/2)-4, height)
 
 		for row := range mapArray {
 			rowText := cursor.MoveTo(2+row, 2)
@@ -224,7 +277,7 @@ func (screen *sshScreen) renderChatInput() {
 	fmtString := fmt.Sprintf("%%-%vs", inputWidth-7)
 
 	chatFunc := screen.colorFunc(fmt.Sprintf("231:%v", bgcolor))
-	chat := chatFunc("SAY▶ ")
+	chat := chatFunc(ChatChannels[screen.ChatChannel()].Prompt)
 	if screen.commandMode {
 		chat = chatFunc("CMD◊ ")
 	}
@@ -370,17 +423,35 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 	CRhiliteColor := screen.colorFunc(fmt.Sprintf("%v+b:255", bgcolor))
 
 	charge, maxcharge := screen.user.Charge()
+	defense := GetDefensePoints(screen.user)
 
 	infoLines := []string{
 		centerText(fmt.Sprintf("%v the %v", screen.user.Username(), screen.user.Title()), " ", width),
 		centerText(warning, "─", width),
 		truncateRight(fmt.Sprintf("%s (%v, %v)", screen.user.LocationName(), pos.X, pos.Y), width),
-		truncateRight(fmt.Sprintf("Charge: %v/%v", charge, maxcharge), width),
+		truncateRight(fmt.Sprintf("Level %v  Charge: %v/%v", screen.user.Level(), charge, maxcharge), width),
+		truncateRight(pvpSummary(screen.user), width),
 		screen.drawProgressMeter(screen.user.HP(), screen.user.MaxHP(), 196, bgcolor, 10) + fmtFunc(truncateRight(fmt.Sprintf(" HP: %v/%v", screen.user.HP(), screen.user.MaxHP()), width-10)),
 		screen.drawProgressMeter(screen.user.XP(), screen.user.XPToNextLevel(), 225, bgcolor, 10) + fmtFunc(truncateRight(fmt.Sprintf(" XP: %v/%v", screen.user.XP(), screen.user.XPToNextLevel()), width-10)),
 		screen.drawProgressMeter(screen.user.AP(), screen.user.MaxAP(), 208, bgcolor, 10) + fmtFunc(truncateRight(fmt.Sprintf(" AP: %v/%v", screen.user.AP(), screen.user.MaxAP()), width-10)),
 		screen.drawProgressMeter(screen.user.RP(), screen.user.MaxRP(), 117, bgcolor, 10) + fmtFunc(truncateRight(fmt.Sprintf(" RP: %v/%v", screen.user.RP(), screen.user.MaxRP()), width-10)),
-		screen.drawProgressMeter(screen.user.MP(), screen.user.MaxMP(), 76, bgcolor, 10) + fmtFunc(truncateRight(fmt.Sprintf(" MP: %v/%v", screen.user.MP(), screen.user.MaxMP()), width-10))}
+		screen.drawProgressMeter(screen.user.MP(), screen.user.MaxMP(), 76, bgcolor, 10) + fmtFunc(truncateRight(fmt.Sprintf(" MP: %v/%v", screen.user.MP(), screen.user.MaxMP()), width-10)),
+		truncateRight(fmt.Sprintf("Defense  AP:%v RP:%v MP:%v", defense.AP, defense.RP, defense.MP), width),
+		truncateRight(fmt.Sprintf("Coins: %v", screen.user.Coins()), width),
+		truncateRight(skillSummary(screen.user), width)}
+
+	effects := screen.user.Effects()
+
+	if len(effects) > 0 {
+		extraLines := []string{centerText(" Effects ", "─", width)}
+		now := time.Now().Unix()
+
+		for _, effect := range effects {
+			extraLines = append(extraLines, truncateRight(" "+effect.String(now), width))
+		}
+
+		infoLines = append(infoLines, extraLines...)
+	}
 
 	equipment := screen.user.Equipped()
 
@@ -444,6 +515,7 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 			} else if keyIndex < 10 {
 				screen.keyCodeMap[fmt.Sprintf("%v", keyIndex+1)] = func() {
 					screen.selectedCreature = cid
+					screen.selectedUser = ""
 				}
 
 				if firstID == "" {
@@ -461,6 +533,10 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 				creature.RP,
 				creature.MP), width-13) + chargeMeter
 
+			if !creature.CreatureTypeStruct.Hostile {
+				nameColumn = truncateRight(creature.CreatureTypeStruct.Name, width-3)
+			}
+
 			if screen.selectedCreature == creature.ID && creature.HP > 0 {
 				labelColumn = CRhiliteColor(labelColumn)
 				nameColumn = CRhiliteColor("▸" + nameColumn)
@@ -478,6 +554,59 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 		infoLines = append(infoLines, extraLines...)
 	}
 
+	players := make([]User, 0)
+	for _, other := range screen.builder.World().OnlineUsers() {
+		if other.Username() != screen.user.Username() && *other.Location() == *pos {
+			players = append(players, other)
+		}
+	}
+
+	var selectedUserItem User
+	if len(players) > 0 {
+		extraLines := []string{centerText(" Players ", "─", width)}
+
+		for index, player := range players {
+			keyIndex := len(creatures) + index
+			labelColumn := fmt.Sprintf("%2v", keyIndex+1)
+			username := player.Username()
+
+			if keyIndex < 10 {
+				screen.keyCodeMap[fmt.Sprintf("%v", keyIndex+1)] = func() {
+					screen.selectedUser = username
+					screen.selectedCreature = ""
+				}
+			}
+
+			standing := ""
+			if dueling(screen.user, player) {
+				standing = "  Dueling"
+			} else if canFight(screen.builder.World(), screen.user, player) == nil {
+				standing = "  PvP"
+			} else if player.ProtectedFor() > 0 {
+				standing = "  Protected"
+			}
+
+			nameColumn := truncateRight(fmt.Sprintf("%s (%v/%v)  Level %v%v", username, player.HP(), player.MaxHP(), player.Level(), standing), width-3)
+
+			if screen.selectedUser == username {
+				labelColumn = CRhiliteColor(labelColumn)
+				nameColumn = CRhiliteColor("▸" + nameColumn)
+				selectedUserItem = player
+			} else {
+				labelColumn = CRnumberColor(labelColumn)
+				nameColumn = CRitemColor(" " + nameColumn)
+			}
+
+			extraLines = append(extraLines, labelColumn+nameColumn)
+		}
+
+		infoLines = append(infoLines, extraLines...)
+	}
+
+	if selectedUserItem == nil {
+		screen.selectedUser = ""
+	}
+
 	// Unselect creature if it's not here
 	if !foundSelectedCreature {
 		if screen.selectedCreature != "" {
@@ -485,7 +614,23 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 		}
 	}
 
-	if hasCreatures {
+	if screen.talkingTo != "" && (selectedCreatureItem == nil || selectedCreatureItem.ID != screen.talkingTo) {
+		screen.talkingTo = ""
+	}
+
+	if selectedCreatureItem != nil && !selectedCreatureItem.CreatureTypeStruct.Hostile {
+		infoLines = append(infoLines, screen.renderConversation(selectedCreatureItem, &key, width, fmtFunc, CRnumberColor)...)
+
+		if _, ok := selectedCreatureItem.Shop(); ok && key <= 'Z' && !(screen.shopActive && screen.shoppingAt == selectedCreatureItem.ID) {
+			keyString := string(key)
+			vendorID := selectedCreatureItem.ID
+			screen.keyCodeMap[keyString] = func() {
+				screen.openShop(vendorID)
+			}
+			infoLines = append(infoLines, CRnumberColor(" "+keyString)+fmtFunc(truncateRight(fmt.Sprintf(" Trade with %v", selectedCreatureItem.CreatureTypeStruct.Name), width-2)))
+			key++
+		}
+	} else if hasCreatures || selectedUserItem != nil {
 		attacks := screen.user.Attacks()
 		if attacks != nil && len(attacks) > 0 {
 			extraLines := []string{centerText(" Attacks ", "─", width)}
@@ -493,7 +638,27 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 			for _, attack := range attacks {
 				attackkey := "  "
 				if key <= 'Z' {
-					if selectedCreatureItem == nil {
+					if selectedUserItem != nil {
+						keyString := string(key)
+						attackkey = fmt.Sprintf(" %v", keyString)
+
+						selu := selectedUserItem
+						sela := *attack.Attack
+						screen.keyCodeMap[keyString] = func() {
+							if err := canFight(screen.builder.World(), screen.user, selu); err != nil {
+								screen.user.Log(LogItem{Message: err.Error(), MessageType: MESSAGEACTION})
+								return
+							}
+
+							selattack := screen.user.MusterAttack(sela.Name)
+							if selattack != nil {
+								formatString := fmt.Sprintf("Attacking %v with %v", selu.Username(), sela.Name)
+								screen.user.Log(LogItem{Message: formatString,
+									MessageType: MESSAGEACTION})
+								screen.builder.Attack(screen.user, selu, selattack)
+							}
+						}
+					} else if selectedCreatureItem == nil {
 						attackkey = "◊◊"
 					} else {
 						keyString := string(key)
@@ -525,6 +690,21 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 
 			infoLines = append(infoLines, extraLines...)
 		}
+
+		if selectedUserItem != nil && key <= 'Z' && !screen.tradeActive {
+			keyString := string(key)
+			tradeWith := selectedUserItem.Username()
+			screen.keyCodeMap[keyString] = func() {
+				if err := openTrade(screen.user, tradeWith); err != nil {
+					screen.user.Log(LogItem{Message: err.Error(), MessageType: MESSAGEACTION})
+					return
+				}
+
+				screen.openTrade()
+			}
+			infoLines = append(infoLines, CRnumberColor(" "+keyString)+fmtFunc(truncateRight(" Trade with "+tradeWith, width-2)))
+			key++
+		}
 	}
 
 	items := cell.InventoryItems()
@@ -576,6 +756,93 @@ func (screen *sshScreen) renderCharacterSheet(slotKeys map[string]func()) {
 	screen.drawFill(x, lastLine+1, width, screen.screenSize.Height-(lastLine+2))
 }
 
+func (screen *sshScreen) renderConversation(npc *Creature, key *rune, width int, textColor, keyColor func(string) string) []string {
+	lines := []string{centerText(" Talk ", "─", width)}
+	tree, ok := DialogueTrees[npc.CreatureTypeStruct.Dialogue]
+
+	if !ok {
+		return append(lines, textColor(truncateRight(fmt.Sprintf(" %v has nothing to say.", npc.CreatureTypeStruct.Name), width)))
+	}
+
+	user := screen.user
+	npcName := npc.CreatureTypeStruct.Name
+	npcID := npc.ID
+	npcType := npc.CreatureType
+
+	goTo := func(nodeID string) {
+		nodeID, node, ok := tree.Node(nodeID, user)
+
+		if !ok {
+			screen.talkingTo = ""
+			return
+		}
+
+		screen.talkingTo = npcID
+		screen.dialogueNode = nodeID
+		user.Log(LogItem{Author: npcName, Message: node.Text, MessageType: MESSAGECHAT})
+	}
+
+	if screen.talkingTo != npc.ID {
+		if *key <= 'Z' {
+			keyString := string(*key)
+			screen.keyCodeMap[keyString] = func() {
+				user.QuestEvent(QUESTTALK, npcType)
+				goTo(tree.Start)
+			}
+			lines = append(lines, keyColor(" "+keyString)+textColor(truncateRight(fmt.Sprintf(" Talk to %v", npcName), width-2)))
+			*key++
+		}
+
+		return lines
+	}
+
+	nodeID, node, ok := tree.Node(screen.dialogueNode, user)
+	if !ok {
+		screen.talkingTo = ""
+		return lines
+	}
+	screen.dialogueNode = nodeID
+
+	for _, line := range wrapText(node.Text, width-2) {
+		lines = append(lines, textColor(" "+truncateRight(line, width-1)))
+	}
+
+	options := node.AvailableOptions(user)
+	if len(options) == 0 {
+		options = []DialogueOption{DialogueOption{Text: "Goodbye"}}
+	}
+
+	for _, option := range options {
+		if *key > 'Z' {
+			break
+		}
+
+		keyString := string(*key)
+		reply := option
+		screen.keyCodeMap[keyString] = func() {
+			user.Log(LogItem{Author: user.Username(), Message: reply.Text, MessageType: MESSAGECHAT})
+
+			if reply.StartQuest != "" {
+				if err := user.StartQuest(reply.StartQuest); err != nil {
+					user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+				}
+			}
+
+			if reply.Next == "" {
+				screen.talkingTo = ""
+				screen.dialogueNode = ""
+			} else {
+				goTo(reply.Next)
+			}
+		}
+
+		lines = append(lines, keyColor(" "+keyString)+textColor(truncateRight(" "+reply.Text, width-2)))
+		*key++
+	}
+
+	return lines
+}
+
 func (screen *sshScreen) renderInventory() map[string]func() {
 	slotCodeMap := make(map[string]func())
 	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
@@ -593,6 +860,7 @@ func (screen *sshScreen) renderInventory() map[string]func() {
 	screenWidth := screen.screenSize.Width/2 - 3
 
 	itemCount, itemID, keyList := groupInventory(screen.user.InventoryItems())
+	bankHere := atBank(screen.user) == nil
 
 	if screen.inventoryIndex >= len(keyList) {
 		screen.inventoryIndex = 0
@@ -629,18 +897,8 @@ ShowLines:
 					currentUser := user
 					slotName := slot
 					slotCodeMap[slotName] = func() {
-						pulledItem := currentUser.PullInventoryItem(newItem.ID)
-						if pulledItem != nil {
-							unequppedItem, err := currentUser.Equip(slotName, pulledItem)
-							if unequppedItem != nil {
-								if !currentUser.AddInventoryItem(unequppedItem) {
-									currentUser.Cell().AddInventoryItem(unequppedItem)
-								}
-							}
-
-							if err != nil {
-								user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
-							}
+						if err := equipFromInventory(currentUser, slotName, newItem); err != nil {
+							user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
 						}
 
 						screen.Render()
@@ -648,12 +906,23 @@ ShowLines:
 				}
 			}
 
-			screen.keyCodeMap["{"] = func() {
-				location := user.Location()
-				item := user.PullInventoryItem(itemIDToGet)
+			screen.keyCodeMap["}"] = func() {
+				item := user.InventoryItem(itemIDToGet)
 				if item != nil {
-					if !screen.builder.World().Cell(location.X, location.Y).AddInventoryItem(item) {
-						user.AddInventoryItem(item)
+					if err := user.Use(item); err != nil {
+						user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+					}
+				}
+			}
+
+			screen.keyCodeMap["{"] = func() {
+				dropFromInventory(screen.builder, user, itemIDToGet)
+			}
+
+			if bankHere {
+				screen.keyCodeMap["+"] = func() {
+					if err := user.Deposit(itemIDToGet); err != nil {
+						user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
 					}
 				}
 			}
@@ -669,17 +938,112 @@ ShowLines:
 		}
 	}
 
+	footer := "[: Prev ]: Next {: Drop }: Use"
+	if bankHere {
+		screen.keyCodeMap["="] = func() {
+			screen.bankActive = true
+		}
+		footer = "{: Drop }: Use +: Deposit =: Bank"
+	}
+
 	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-4-row)
 	io.WriteString(screen.session,
 		cursor.MoveTo(screen.screenSize.Height-3, screenX)+
 			keyFunc(
 				justifyRight(
-					"[: Prev ]: Next {: Drop",
+					footer,
 					screenWidth-1)))
 
 	return slotCodeMap
 }
 
+func (screen *sshScreen) renderBank() {
+	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
+	selectColor := screen.colorFunc(fmt.Sprintf("%v+b:255", bgcolor))
+	keyFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	titleFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+
+	y := screen.screenSize.Height
+	if y < 20 {
+		y = 5
+	} else {
+		y = (y / 2) - 2
+	}
+
+	screenX := 2
+	screenWidth := screen.screenSize.Width/2 - 3
+
+	user := screen.user
+	banked := user.BankItems()
+	itemCount, itemID, keyList := groupInventory(banked)
+
+	if screen.bankIndex >= len(keyList) {
+		screen.bankIndex = 0
+	} else if screen.bankIndex < 0 {
+		screen.bankIndex = len(keyList) - 1
+	}
+
+	lines := []string{
+		titleFunc(truncateRight(fmt.Sprintf("Bank: %v/%v items", len(banked), user.BankCapacity()), screenWidth-1)),
+		fmtFunc(truncateRight(fmt.Sprintf("/bank upgrade for %v more room: %v", bankUpgradeSlots, coinString(user.BankUpgradePrice())), screenWidth-1))}
+	selectedLine := 0
+
+	for index, itemName := range keyList {
+		countLine := fmt.Sprintf("x%v", itemCount[itemName])
+		line := truncateRight(itemName, screenWidth-1-utf8.RuneCountInString(countLine)) + countLine
+
+		if index != screen.bankIndex {
+			lines = append(lines, fmtFunc(line))
+			continue
+		}
+
+		selectedLine = len(lines)
+		lines = append(lines, selectColor(line))
+
+		itemIDToGet := itemID[itemName]
+		screen.keyCodeMap["+"] = func() {
+			if err := user.Withdraw(itemIDToGet); err != nil {
+				user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+			}
+		}
+	}
+
+	if len(keyList) == 0 {
+		lines = append(lines, fmtFunc(truncateRight("Your bank is empty.", screenWidth-1)))
+	}
+
+	screen.keyCodeMap["="] = func() {
+		screen.bankActive = false
+	}
+
+	row := y + 3
+	height := screen.screenSize.Height - 4 - row
+	offset := selectedLine - height/2
+	if offset > len(lines)-height {
+		offset = len(lines) - height
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	for _, line := range lines[offset:] {
+		if row > screen.screenSize.Height-4 {
+			break
+		}
+
+		io.WriteString(screen.session, cursor.MoveTo(row, screenX)+line)
+		row++
+	}
+
+	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-4-row)
+	io.WriteString(screen.session,
+		cursor.MoveTo(screen.screenSize.Height-3, screenX)+
+			keyFunc(
+				justifyRight(
+					"[: Prev ]: Next +: Withdraw =: Carried",
+					screenWidth-1)))
+}
+
 func (screen *sshScreen) renderLog() {
 	y := screen.screenSize.Height
 	if y < 20 {
@@ -704,6 +1068,632 @@ func (screen *sshScreen) renderLog() {
 	}
 }
 
+func (screen *sshScreen) renderQuestLog() {
+	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
+	titleFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	doneFunc := screen.colorFunc(fmt.Sprintf("243:%v", bgcolor))
+
+	y := screen.screenSize.Height
+	if y < 20 {
+		y = 5
+	} else {
+		y = (y / 2) - 2
+	}
+
+	screenX := 2
+	screenWidth := screen.screenSize.Width/2 - 3
+
+	lines := make([]string, 0)
+	quests := screen.user.Quests()
+	carrying := carriedItems(screen.user.InventoryItems())
+
+	for _, quest := range quests {
+		questType, ok := quest.QuestType()
+		if !ok || quest.Done {
+			continue
+		}
+
+		lines = append(lines, titleFunc(truncateRight(questType.Name, screenWidth-1)))
+		for _, line := range wrapText(questType.Description, screenWidth-2) {
+			lines = append(lines, fmtFunc(" "+truncateRight(line, screenWidth-2)))
+		}
+		for index, objective := range questType.Objectives {
+			progress := fmt.Sprintf("%v/%v", quest.Count(index, carrying), objective.Needed())
+			lines = append(lines, fmtFunc(" "+truncateRight(objective.String(), screenWidth-2-utf8.RuneCountInString(progress))+progress))
+		}
+	}
+
+	for _, quest := range quests {
+		if questType, ok := quest.QuestType(); ok && quest.Done {
+			lines = append(lines, doneFunc(truncateRight(questType.Name+" (done)", screenWidth-1)))
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, fmtFunc(truncateRight("No quests yet. Talk to the locals.", screenWidth-1)))
+	}
+
+	row := y + 3
+	for _, line := range lines {
+		if row > screen.screenSize.Height-3 {
+			break
+		}
+
+		io.WriteString(screen.session, cursor.MoveTo(row, screenX)+line)
+		row++
+	}
+
+	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-3-row)
+}
+
+func (screen *sshScreen) renderCrafting() {
+	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
+	selectColor := screen.colorFunc(fmt.Sprintf("%v+b:255", bgcolor))
+	keyFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	unreadyFunc := screen.colorFunc(fmt.Sprintf("243:%v", bgcolor))
+
+	y := screen.screenSize.Height
+	if y < 20 {
+		y = 5
+	} else {
+		y = (y / 2) - 2
+	}
+
+	screenX := 2
+	screenWidth := screen.screenSize.Width/2 - 3
+
+	recipes := recipeList()
+	if screen.recipeIndex >= len(recipes) {
+		screen.recipeIndex = 0
+	} else if screen.recipeIndex < 0 {
+		screen.recipeIndex = len(recipes) - 1
+	}
+
+	lines := make([]string, 0)
+	selectedLine := 0
+	user := screen.user
+
+	for index, recipe := range recipes {
+		err := canCraft(user, &recipe)
+
+		status := ""
+		if err == nil {
+			status = "ready"
+		}
+		line := truncateRight(recipe.Name, screenWidth-1-utf8.RuneCountInString(status)) + status
+
+		if index != screen.recipeIndex {
+			if err == nil {
+				lines = append(lines, fmtFunc(line))
+			} else {
+				lines = append(lines, unreadyFunc(line))
+			}
+			continue
+		}
+
+		selectedLine = len(lines)
+		lines = append(lines, selectColor(line))
+
+		details := make([]string, 0)
+		if recipe.Description != "" {
+			details = append(details, wrapText(recipe.Description, screenWidth-2)...)
+		}
+		details = append(details, wrapText("Uses: "+itemList(recipe.Inputs), screenWidth-2)...)
+		details = append(details, wrapText("Makes: "+itemList(recipe.Outputs), screenWidth-2)...)
+		if err != nil {
+			details = append(details, wrapText(err.Error(), screenWidth-2)...)
+		}
+		for _, detail := range details {
+			lines = append(lines, fmtFunc(" "+truncateRight(detail, screenWidth-2)))
+		}
+
+		recipeID := recipe.ID
+		screen.keyCodeMap["}"] = func() {
+			if err := user.Craft(recipeID); err != nil {
+				user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, fmtFunc(truncateRight("Nothing to make.", screenWidth-1)))
+	}
+
+	row := y + 3
+	height := screen.screenSize.Height - 4 - row
+	offset := selectedLine - height/2
+	if offset > len(lines)-height {
+		offset = len(lines) - height
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	for _, line := range lines[offset:] {
+		if row > screen.screenSize.Height-4 {
+			break
+		}
+
+		io.WriteString(screen.session, cursor.MoveTo(row, screenX)+line)
+		row++
+	}
+
+	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-4-row)
+	io.WriteString(screen.session,
+		cursor.MoveTo(screen.screenSize.Height-3, screenX)+
+			keyFunc(
+				justifyRight(
+					"[: Prev ]: Next }: Craft",
+					screenWidth-1)))
+}
+
+// talentDetails describes what a talent costs, needs and grants, for the talent panel
+func talentDetails(talent *Talent) []string {
+	details := make([]string, 0)
+
+	if talent.Description != "" {
+		details = append(details, talent.Description)
+	}
+
+	cost := "Costs " + talentPointString(talent.Points())
+	if talent.Level > 1 {
+		cost += fmt.Sprintf(", from level %v", talent.Level)
+	}
+	details = append(details, cost)
+
+	if len(talent.Requires) > 0 {
+		names := make([]string, 0, len(talent.Requires))
+		for _, id := range talent.Requires {
+			if required, ok := talentByID(id); ok {
+				names = append(names, required.Name)
+			}
+		}
+		details = append(details, "Needs: "+strings.Join(names, ", "))
+	}
+
+	if talent.Attack != nil {
+		details = append(details, "Attack: "+talent.Attack.String())
+	}
+	if talent.Bonuses != "" {
+		details = append(details, "Bonus: "+talent.Bonuses)
+	}
+	if talent.Slot != nil {
+		details = append(details, fmt.Sprintf("Slot: %v (%v)", talent.Slot.Name, strings.Join(talent.Slot.SlotTypes, ", ")))
+	}
+	if talent.Passive != "" {
+		details = append(details, "Always: "+strings.Join(effectNames([]string{talent.Passive}), ", "))
+	}
+
+	return details
+}
+
+func (screen *sshScreen) renderTalents() {
+	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
+	selectColor := screen.colorFunc(fmt.Sprintf("%v+b:255", bgcolor))
+	keyFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	titleFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	unreadyFunc := screen.colorFunc(fmt.Sprintf("243:%v", bgcolor))
+
+	y := screen.screenSize.Height
+	if y < 20 {
+		y = 5
+	} else {
+		y = (y / 2) - 2
+	}
+
+	screenX := 2
+	screenWidth := screen.screenSize.Width/2 - 3
+
+	user := screen.user
+	tree, ok := talentTree(user)
+	talents := talentList(user, tree)
+	if screen.talentIndex >= len(talents) {
+		screen.talentIndex = 0
+	} else if screen.talentIndex < 0 {
+		screen.talentIndex = len(talents) - 1
+	}
+
+	lines := make([]string, 0)
+	if ok {
+		lines = append(lines,
+			titleFunc(truncateRight(fmt.Sprintf("%v: %v to spend", tree.Name, talentPointString(user.TalentPoints())), screenWidth-1)))
+		if len(user.Talents()) > 0 {
+			lines = append(lines,
+				fmtFunc(truncateRight(fmt.Sprintf("/talents respec to start over: %v", coinString(user.RespecPrice())), screenWidth-1)))
+		}
+	}
+	selectedLine := 0
+
+	for index, talent := range talents {
+		err := canLearnTalent(user, &talent)
+
+		status := ""
+		if knowsTalent(user.Talents(), talent.ID) {
+			status = "learned"
+		} else if err == nil {
+			status = "ready"
+		}
+		line := truncateRight(talent.Name, screenWidth-1-utf8.RuneCountInString(status)) + status
+
+		if index != screen.talentIndex {
+			if err == nil || status == "learned" {
+				lines = append(lines, fmtFunc(line))
+			} else {
+				lines = append(lines, unreadyFunc(line))
+			}
+			continue
+		}
+
+		selectedLine = len(lines)
+		lines = append(lines, selectColor(line))
+
+		details := make([]string, 0)
+		for _, detail := range talentDetails(&talent) {
+			details = append(details, wrapText(detail, screenWidth-2)...)
+		}
+		if err != nil && status != "learned" {
+			details = append(details, wrapText(err.Error(), screenWidth-2)...)
+		}
+		for _, detail := range details {
+			lines = append(lines, fmtFunc(" "+truncateRight(detail, screenWidth-2)))
+		}
+
+		talentID := talent.ID
+		screen.keyCodeMap["}"] = func() {
+			if err := user.LearnTalent(talentID); err != nil {
+				user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+			}
+		}
+	}
+
+	if len(talents) == 0 {
+		lines = append(lines, fmtFunc(truncateRight("No talents to learn.", screenWidth-1)))
+	}
+
+	row := y + 3
+	height := screen.screenSize.Height - 4 - row
+	offset := selectedLine - height/2
+	if offset > len(lines)-height {
+		offset = len(lines) - height
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	for _, line := range lines[offset:] {
+		if row > screen.screenSize.Height-4 {
+			break
+		}
+
+		io.WriteString(screen.session, cursor.MoveTo(row, screenX)+line)
+		row++
+	}
+
+	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-4-row)
+	io.WriteString(screen.session,
+		cursor.MoveTo(screen.screenSize.Height-3, screenX)+
+			keyFunc(
+				justifyRight(
+					"[: Prev ]: Next }: Learn",
+					screenWidth-1)))
+}
+
+// shopEntry is one line to pick in the shop panel, something to buy or something to sell
+type shopEntry struct {
+	label  string
+	price  string
+	ready  bool
+	action func() error
+}
+
+func (screen *sshScreen) openShop(vendorID string) {
+	screen.shopActive = true
+	screen.shoppingAt = vendorID
+	screen.shopIndex = 0
+	screen.tradeActive = false
+	screen.inventoryActive = false
+	screen.questsActive = false
+	screen.craftingActive = false
+	screen.talentsActive = false
+	screen.refreshed = false
+}
+
+func (screen *sshScreen) renderShop() {
+	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
+	selectColor := screen.colorFunc(fmt.Sprintf("%v+b:255", bgcolor))
+	keyFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	titleFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	unreadyFunc := screen.colorFunc(fmt.Sprintf("243:%v", bgcolor))
+
+	y := screen.screenSize.Height
+	if y < 20 {
+		y = 5
+	} else {
+		y = (y / 2) - 2
+	}
+
+	screenX := 2
+	screenWidth := screen.screenSize.Width/2 - 3
+
+	user := screen.user
+	vendorID := screen.shoppingAt
+	vendor, shop, err := vendorHere(user, vendorID)
+	if err != nil {
+		// The vendor left, or we did
+		screen.shopActive = false
+		screen.shoppingAt = ""
+		screen.drawFill(screenX, y+3, screenWidth-1, screen.screenSize.Height-3-(y+3))
+		screen.renderLog()
+		return
+	}
+
+	coins := user.Coins()
+	stock := screen.builder.World().ShopStock(vendor)
+
+	entries := make([]shopEntry, 0)
+	buying := 0
+	for _, selling := range shop.Sells {
+		itemName := selling.Item
+		label := itemName
+		if count, limited := stock[itemName]; limited {
+			label = fmt.Sprintf("%v (%v left)", itemName, count)
+		}
+
+		entries = append(entries, shopEntry{
+			label: label,
+			price: fmt.Sprintf("%v", selling.Price),
+			ready: coins >= selling.Price && (selling.Stock == 0 || stock[itemName] > 0),
+			action: func() error {
+				return user.Buy(vendorID, itemName)
+			}})
+		buying++
+	}
+
+	itemCount, itemID, keyList := groupInventory(user.InventoryItems())
+	for _, itemName := range keyList {
+		item := user.InventoryItem(itemID[itemName])
+		if item == nil {
+			continue
+		}
+
+		price := shop.BuyPrice(item)
+		itemIDToSell := item.ID
+		entries = append(entries, shopEntry{
+			label: fmt.Sprintf("%v x%v", itemName, itemCount[itemName]),
+			price: fmt.Sprintf("%v", price),
+			ready: price > 0,
+			action: func() error {
+				return user.Sell(vendorID, itemIDToSell)
+			}})
+	}
+
+	if screen.shopIndex >= len(entries) {
+		screen.shopIndex = 0
+	} else if screen.shopIndex < 0 {
+		screen.shopIndex = len(entries) - 1
+	}
+
+	lines := []string{titleFunc(truncateRight(fmt.Sprintf("%v  Coins: %v", shop.Name, coins), screenWidth-1))}
+	selectedLine := 0
+
+	for index, entry := range entries {
+		if index == 0 {
+			lines = append(lines, titleFunc(truncateRight("Buy", screenWidth-1)))
+		}
+		if index == buying {
+			lines = append(lines, titleFunc(truncateRight("Sell", screenWidth-1)))
+		}
+
+		line := truncateRight(" "+entry.label, screenWidth-1-utf8.RuneCountInString(entry.price)) + entry.price
+
+		if index == screen.shopIndex {
+			selectedLine = len(lines)
+			lines = append(lines, selectColor(line))
+
+			action := entry.action
+			screen.keyCodeMap["}"] = func() {
+				if err := action(); err != nil {
+					user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+				}
+			}
+		} else if entry.ready {
+			lines = append(lines, fmtFunc(line))
+		} else {
+			lines = append(lines, unreadyFunc(line))
+		}
+	}
+
+	if len(entries) == 0 {
+		lines = append(lines, fmtFunc(truncateRight("Nothing to trade.", screenWidth-1)))
+	}
+
+	row := y + 3
+	height := screen.screenSize.Height - 4 - row
+	offset := selectedLine - height/2
+	if offset > len(lines)-height {
+		offset = len(lines) - height
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	for _, line := range lines[offset:] {
+		if row > screen.screenSize.Height-4 {
+			break
+		}
+
+		io.WriteString(screen.session, cursor.MoveTo(row, screenX)+line)
+		row++
+	}
+
+	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-4-row)
+	io.WriteString(screen.session,
+		cursor.MoveTo(screen.screenSize.Height-3, screenX)+
+			keyFunc(
+				justifyRight(
+					"[: Prev ]: Next }: Buy/Sell",
+					screenWidth-1)))
+}
+
+func (screen *sshScreen) openTrade() {
+	screen.tradeActive = true
+	screen.tradeIndex = 0
+	screen.shopActive = false
+	screen.inventoryActive = false
+	screen.questsActive = false
+	screen.craftingActive = false
+	screen.talentsActive = false
+	screen.refreshed = false
+}
+
+func (screen *sshScreen) renderTrade() {
+	fmtFunc := screen.colorFunc(fmt.Sprintf("255:%v", bgcolor))
+	selectColor := screen.colorFunc(fmt.Sprintf("%v+b:255", bgcolor))
+	keyFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+	titleFunc := screen.colorFunc(fmt.Sprintf("255+b:%v", bgcolor))
+
+	y := screen.screenSize.Height
+	if y < 20 {
+		y = 5
+	} else {
+		y = (y / 2) - 2
+	}
+
+	screenX := 2
+	screenWidth := screen.screenSize.Width/2 - 3
+
+	user := screen.user
+	trade, ok := user.Trade()
+	if !ok {
+		// The trade went through, or was called off
+		screen.tradeActive = false
+		screen.drawFill(screenX, y+3, screenWidth-1, screen.screenSize.Height-3-(y+3))
+		screen.renderLog()
+		return
+	}
+
+	otherName := trade.Other(user.Username())
+	lines := []string{titleFunc(truncateRight("Trading with "+otherName, screenWidth-1))}
+	selectedLine := 0
+	footer := "}: Accept"
+
+	if !trade.Accepted && trade.Requester == user.Username() {
+		lines = append(lines, fmtFunc(truncateRight(fmt.Sprintf("Waiting for %v to agree...", otherName), screenWidth-1)))
+		footer = ""
+	} else if !trade.Accepted {
+		lines = append(lines, fmtFunc(truncateRight(fmt.Sprintf("%v wants to trade.", otherName), screenWidth-1)))
+		screen.keyCodeMap["}"] = func() {
+			if err := user.AcceptTrade(); err != nil {
+				user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+			}
+		}
+	} else {
+		other := screen.builder.GetUser(otherName)
+		mine, theirs := trade.Offer(user.Username()), trade.Offer(otherName)
+		footer = "[: Prev ]: Next }: Offer {: Confirm"
+
+		// Everything you can pick: what you've offered, to take back, then the rest of what
+		// you're carrying, to offer
+		offeredCount, offeredID, offeredNames := groupInventory(offeredItems(user, mine))
+		carried := make([]*InventoryItem, 0)
+		for _, item := range user.InventoryItems() {
+			if !mine.Offering(item.ID) {
+				carried = append(carried, item)
+			}
+		}
+		carriedCount, carriedID, carriedNames := groupInventory(carried)
+
+		choices := len(offeredNames) + len(carriedNames)
+		if screen.tradeIndex >= choices {
+			screen.tradeIndex = 0
+		} else if screen.tradeIndex < 0 {
+			screen.tradeIndex = choices - 1
+		}
+
+		choice := 0
+		addChoice := func(name string, count int, action func() error) {
+			countLine := fmt.Sprintf("x%v", count)
+			line := truncateRight(" "+name, screenWidth-1-utf8.RuneCountInString(countLine)) + countLine
+
+			if choice == screen.tradeIndex {
+				selectedLine = len(lines)
+				lines = append(lines, selectColor(line))
+				screen.keyCodeMap["}"] = func() {
+					if err := action(); err != nil {
+						user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+					}
+				}
+			} else {
+				lines = append(lines, fmtFunc(line))
+			}
+			choice++
+		}
+
+		confirmed := func(offer TradeOffer) string {
+			if offer.Confirmed {
+				return " (confirmed)"
+			}
+			return ""
+		}
+
+		lines = append(lines, titleFunc(truncateRight(fmt.Sprintf("Your offer%v: %v", confirmed(mine), coinString(mine.Coins)), screenWidth-1)))
+		for _, name := range offeredNames {
+			itemID := offeredID[name]
+			addChoice(name, offeredCount[name], func() error { return user.RemoveFromTrade(itemID) })
+		}
+
+		lines = append(lines, titleFunc(truncateRight(fmt.Sprintf("%v's offer%v: %v", otherName, confirmed(theirs), coinString(theirs.Coins)), screenWidth-1)))
+		theirCount, _, theirNames := groupInventory(offeredItems(other, theirs))
+		for _, name := range theirNames {
+			countLine := fmt.Sprintf("x%v", theirCount[name])
+			lines = append(lines, fmtFunc(truncateRight(" "+name, screenWidth-1-utf8.RuneCountInString(countLine))+countLine))
+		}
+
+		lines = append(lines, titleFunc(truncateRight("Your inventory", screenWidth-1)))
+		for _, name := range carriedNames {
+			itemID := carriedID[name]
+			addChoice(name, carriedCount[name], func() error { return user.AddToTrade(itemID) })
+		}
+
+		if err := canTrade(user, other); err != nil {
+			lines = append(lines, fmtFunc(truncateRight(err.Error(), screenWidth-1)))
+		}
+
+		screen.keyCodeMap["{"] = func() {
+			if err := user.ConfirmTrade(); err != nil {
+				user.Log(LogItem{MessageType: MESSAGEACTIVITY, Message: err.Error()})
+			}
+		}
+	}
+
+	row := y + 3
+	height := screen.screenSize.Height - 4 - row
+	offset := selectedLine - height/2
+	if offset > len(lines)-height {
+		offset = len(lines) - height
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	for _, line := range lines[offset:] {
+		if row > screen.screenSize.Height-4 {
+			break
+		}
+
+		io.WriteString(screen.session, cursor.MoveTo(row, screenX)+line)
+		row++
+	}
+
+	screen.drawFill(screenX, row, screenWidth-1, screen.screenSize.Height-4-row)
+	io.WriteString(screen.session,
+		cursor.MoveTo(screen.screenSize.Height-3, screenX)+
+			keyFunc(
+				justifyRight(
+					footer,
+					screenWidth-1)))
+}
+
 func (screen *sshScreen) ToggleInput() {
 	screen.inputActive = !screen.inputActive
 	screen.inputSticky = true
@@ -779,23 +1769,112 @@ func (screen *sshScreen) GetChat() string {
 	return ct
 }
 
+// ChatChannel is the channel chat typed into the input line goes to, falling back to
+// speech if the user has left it
+func (screen *sshScreen) ChatChannel() string {
+	channel := screen.chatChannel
+
+	if _, ok := ChatChannels[channel]; !ok || channel == CHANNELWHISPER {
+		channel = CHANNELGLOBAL
+	}
+
+	if !screen.user.InChannel(channel) {
+		channel = CHANNELSAY
+	}
+
+	return channel
+}
+
+func (screen *sshScreen) SetChatChannel(channel string) {
+	screen.chatChannel = channel
+}
+
+// RunCommand runs a line typed in command mode
+func (screen *sshScreen) RunCommand(line string) {
+	if len(strings.TrimSpace(line)) > 0 {
+		screen.user.Log(LogItem{Author: screen.user.Username(), Message: "/" + line, MessageType: MESSAGEACTION})
+		runCommand(&commandContext{builder: screen.builder, user: screen.user, screen: screen}, line)
+	}
+
+	screen.Render()
+}
+
+// CompleteCommand tab completes the command being typed, listing the choices if there's more than one
+func (screen *sshScreen) CompleteCommand() {
+	line, choices := completeCommand(&commandContext{builder: screen.builder, user: screen.user, screen: screen}, screen.inputText)
+	screen.inputText = line
+
+	if len(choices) > 0 {
+		screen.user.Log(LogItem{Message: strings.Join(choices, "  "), MessageType: MESSAGEACTION})
+	}
+
+	screen.Render()
+}
+
+// ToggleInventory cycles the lower left panel from the log to the inventory to the quest log
+// to crafting to talents. From a shop or a trade it goes back to the log.
 func (screen *sshScreen) ToggleInventory() {
-	screen.inventoryActive = !screen.inventoryActive
+	if screen.shopActive {
+		screen.shopActive = false
+		screen.shoppingAt = ""
+	} else if screen.tradeActive {
+		screen.tradeActive = false
+	} else if screen.inventoryActive {
+		screen.inventoryActive = false
+		screen.bankActive = false
+		screen.questsActive = true
+	} else if screen.questsActive {
+		screen.questsActive = false
+		screen.craftingActive = true
+	} else if screen.craftingActive {
+		screen.craftingActive = false
+		screen.talentsActive = true
+	} else if screen.talentsActive {
+		screen.talentsActive = false
+	} else {
+		screen.inventoryActive = true
+	}
 	screen.refreshed = false
 	screen.Render()
 }
 
+// InventoryActive checks whether the lower left panel is a list to pick from: the inventory,
+// crafting, talents, a shop or a trade
 func (screen *sshScreen) InventoryActive() bool {
-	return screen.inventoryActive
+	return screen.inventoryActive || screen.craftingActive || screen.talentsActive || screen.shopActive || screen.tradeActive
 }
 
 func (screen *sshScreen) PreviousInventoryItem() {
-	screen.inventoryIndex--
+	if screen.shopActive {
+		screen.shopIndex--
+	} else if screen.tradeActive {
+		screen.tradeIndex--
+	} else if screen.inventoryActive && screen.bankActive {
+		screen.bankIndex--
+	} else if screen.craftingActive {
+		screen.recipeIndex--
+	} else if screen.talentsActive {
+		screen.talentIndex--
+	} else {
+		screen.inventoryIndex--
+	}
 	screen.Render()
 }
 
 func (screen *sshScreen) NextInventoryItem() {
-	screen.inventoryIndex++
+	if screen.shopActive {
+		screen.shopIndex++
+	} else if screen.tradeActive {
+		screen.tradeIndex++
+	} else if screen.inventoryActive && screen.bankActive {
+		screen.bankIndex++
+	} else if screen.craftingActive {
+		screen.recipeIndex++
+	} else if screen.talentsActive {
+		screen.talentIndex++
+	} else {
+		screen.inventoryIndex++
+	}
 	screen.Render()
 }
 
@@ -828,8 +1907,21 @@ func (screen *sshScreen) Render() {
 
 	var slotKeys map[string]func()
 
-	if screen.inventoryActive {
+	if screen.shopActive {
+		screen.renderShop()
+	} else if screen.tradeActive {
+		screen.renderTrade()
+	} else if screen.inventoryActive && screen.bankActive && atBank(screen.user) == nil {
+		screen.renderBank()
+	} else if screen.inventoryActive {
+		screen.bankActive = false
 		slotKeys = screen.renderInventory()
+	} else if screen.questsActive {
+		screen.renderQuestLog()
+	} else if screen.craftingActive {
+		screen.renderCrafting()
+	} else if screen.talentsActive {
+		screen.renderTalents()
 	} else {
 		screen.renderLog()
 	}
@@ -839,6 +1931,12 @@ func (screen *sshScreen) Render() {
 	screen.renderCharacterSheet(slotKeys)
 }
 
+// Redraw clears the terminal and draws everything again, for after something else had the screen
+func (screen *sshScreen) Redraw() {
+	screen.refreshed = false
+	screen.Render()
+}
+
 func (screen *sshScreen) Reset() {
 	io.WriteString(screen.session, fmt.Sprintf("%s👋\n", resetScreen))
 }
@@ -0,0 +1,63 @@
+package mud
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ojrac/opensimplex-go"
+)
+
+// RNGREGIONSIZE is the width and height of the square regions random streams are grouped by
+const RNGREGIONSIZE = 64
+
+var noiseBySeed sync.Map
+
+// ServerConfig is the contents of config.json
+type ServerConfig struct {
+	Listen string   `json:""`
+	Seed   int64    `json:""`           // World seed used the first time world.db is created; 0 picks one at random
+	Admins []string `json:",omitempty"` // Usernames that can use admin chat
+
+	Respawn RespawnConfig `json:",omitempty"` // Cooldowns and caps for creatures and items coming back
+}
+
+// newWorldSeed picks a fresh seed for worlds that weren't given one
+func newWorldSeed() int64 {
+	seed := time.Now().UnixNano()
+	if seed == 0 {
+		seed = 1
+	}
+	return seed
+}
+
+// seededRand makes a random stream that depends only on the world seed, what it's for, and the keys given
+func seededRand(seed int64, purpose string, keys ...uint64) *rand.Rand {
+	hash := fnv.New64a()
+	binary.Write(hash, binary.BigEndian, seed)
+	hash.Write([]byte(purpose))
+	for _, key := range keys {
+		binary.Write(hash, binary.BigEndian, key)
+	}
+
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
+// regionRand makes the random stream for one purpose at one point, grouped under that point's region
+func regionRand(world World, purpose string, p Point) *rand.Rand {
+	return seededRand(world.Seed(), purpose, uint64(p.X/RNGREGIONSIZE), uint64(p.Y/RNGREGIONSIZE), uint64(p.X), uint64(p.Y))
+}
+
+// worldNoise returns the noise generator for a world's seed
+func worldNoise(world World) opensimplex.Noise {
+	seed := world.Seed()
+
+	if noise, ok := noiseBySeed.Load(seed); ok {
+		return noise.(opensimplex.Noise)
+	}
+
+	noise, _ := noiseBySeed.LoadOrStore(seed, opensimplex.New(seed))
+	return noise.(opensimplex.Noise)
+}
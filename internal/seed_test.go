@@ -0,0 +1,70 @@
+package mud
+
+import "testing"
+
+func TestSeededRand(t *testing.T) {
+	tests := []struct {
+		name  string
+		seed  int64
+		other int64
+		keys  []uint64
+		same  bool
+	}{
+		{"same seed and keys", 7, 7, []uint64{1, 2}, true},
+		{"different seed", 7, 8, []uint64{1, 2}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := seededRand(test.seed, "test", test.keys...).Int63()
+			b := seededRand(test.other, "test", test.keys...).Int63()
+			if (a == b) != test.same {
+				t.Fatalf("seededRand() gave %v and %v, want same = %v", a, b, test.same)
+			}
+		})
+	}
+
+	if seededRand(7, "a", 1).Int63() == seededRand(7, "b", 1).Int63() {
+		t.Fatal("different purposes gave the same stream")
+	}
+	if seededRand(7, "a", 1).Int63() == seededRand(7, "a", 2).Int63() {
+		t.Fatal("different keys gave the same stream")
+	}
+}
+
+// exploreNorth walks a fresh character a few steps north and reports the terrain and
+// region names they pass through
+func exploreNorth(t *testing.T, seed int64) ([]string, []string) {
+	world := NewMemoryWorld(seed).(*dbWorld)
+	t.Cleanup(world.Close)
+	user := newTestUser(t, world, "explorer")
+	spawn := *user.Location()
+
+	terrain, names := []string{}, []string{}
+	from := world.CellAtPoint(spawn)
+	for step := uint32(1); step <= 5; step++ {
+		to := world.Cell(spawn.X, spawn.Y-step)
+		PopulateCellFromAlgorithm(from, to, world)
+		to = world.Cell(spawn.X, spawn.Y-step)
+		terrain = append(terrain, to.CellInfo().TerrainID)
+		names = append(names, to.CellInfo().RegionName)
+		from = to
+	}
+
+	return terrain, names
+}
+
+func TestWorldGenerationIsDeterministic(t *testing.T) {
+	terrain, names := exploreNorth(t, 42)
+	againTerrain, againNames := exploreNorth(t, 42)
+
+	if terrain[0] == "" {
+		t.Fatal("exploring north generated nothing")
+	}
+
+	for i := range terrain {
+		if terrain[i] != againTerrain[i] || names[i] != againNames[i] {
+			t.Fatalf("step %v: got %v in %q then %v in %q from the same seed", i+1, terrain[i], names[i], againTerrain[i], againNames[i])
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// defaultRestockSeconds is how long a vendor takes to get one more of something back in stock,
+// when the shop doesn't say
+const defaultRestockSeconds = 300
+
+// ShopTypes is a mapping of string IDs to shops vendors can keep
+var ShopTypes map[string]Shop
+
+// SellPrices is what vendors pay for an item by default, by its subtype or failing that its type
+var SellPrices map[string]uint64
+
+// Shop is what a vendor NPC sells and buys
+type Shop struct {
+	ID    string            `json:"-"`
+	Name  string            `json:""`
+	Sells []ShopItem        `json:",omitempty"`
+	Buys  map[string]uint64 `json:",omitempty"` // Item name, subtype or type -> what the vendor pays instead of SellPrices; 0 refuses it
+}
+
+// ShopItem is something a vendor sells, and how many they keep in stock
+type ShopItem struct {
+	Item    string `json:""` // Name of item in items.json
+	Price   uint64 `json:""`
+	Stock   uint64 `json:",omitempty"` // Most the vendor has at once; 0 means they never run out
+	Restock int64  `json:",omitempty"` // Seconds to get one more back in stock after selling out
+}
+
+// stockLevel is how many of one thing a vendor has left, and since when they've been restocking
+type stockLevel struct {
+	Count uint64 `json:""`
+	Since int64  `json:""`
+}
+
+// ShopInfo handles a user's coin and their trading with vendors
+type ShopInfo interface {
+	Coins() uint64
+	AddCoins(uint64)
+	Buy(vendorID string, itemName string) error
+	Sell(vendorID string, itemID string) error
+}
+
+// Shop is the shop a creature keeps, if it's a vendor
+func (creature *Creature) Shop() (Shop, bool) {
+	if creature.CreatureTypeStruct.Hostile || creature.CreatureTypeStruct.Shop == "" {
+		return Shop{}, false
+	}
+
+	shop, ok := ShopTypes[creature.CreatureTypeStruct.Shop]
+	return shop, ok
+}
+
+// Selling finds something a shop sells by item name
+func (shop *Shop) Selling(itemName string) (ShopItem, bool) {
+	for _, item := range shop.Sells {
+		if item.Item == itemName {
+			return item, true
+		}
+	}
+
+	return ShopItem{}, false
+}
+
+// BuyPrice is what a shop pays for an item; 0 means it won't take it. The shop's own prices
+// go by name, then subtype, then type, before falling back to SellPrices.
+func (shop *Shop) BuyPrice(item *InventoryItem) uint64 {
+	for _, key := range []string{item.Name, item.Subtype, item.Type} {
+		if price, ok := shop.Buys[key]; ok && key != "" {
+			return price
+		}
+	}
+
+	return sellPrice(item)
+}
+
+// sellPrice is what an item goes for by default, going by its subtype and then its type
+func sellPrice(item *InventoryItem) uint64 {
+	if price, ok := SellPrices[item.Subtype]; ok && item.Subtype != "" {
+		return price
+	}
+
+	return SellPrices[item.Type]
+}
+
+// restock works out how much of something a vendor has now, given what they had and when
+func (item *ShopItem) restock(level stockLevel, now int64) stockLevel {
+	restock := item.Restock
+	if restock <= 0 {
+		restock = defaultRestockSeconds
+	}
+
+	if level.Count >= item.Stock {
+		return stockLevel{Count: item.Stock, Since: now}
+	}
+
+	gained := (now - level.Since) / restock
+	if gained <= 0 {
+		return level
+	}
+
+	level.Count += uint64(gained)
+	level.Since += gained * restock
+	if level.Count >= item.Stock {
+		return stockLevel{Count: item.Stock, Since: now}
+	}
+
+	return level
+}
+
+// stockOf looks up how much of each thing a vendor has, restocked up to now. Anything never
+// stocked before starts out full.
+func stockOf(shop Shop, stored map[string]stockLevel, now int64) map[string]stockLevel {
+	stock := make(map[string]stockLevel)
+
+	for _, item := range shop.Sells {
+		if item.Stock == 0 {
+			continue
+		}
+
+		level, ok := stored[item.Item]
+		if !ok {
+			level = stockLevel{Count: item.Stock, Since: now}
+		}
+		stock[item.Item] = item.restock(level, now)
+	}
+
+	return stock
+}
+
+// ShopStock is how many of each thing a vendor has left; things they never run out of are left out
+func (w *dbWorld) ShopStock(vendor *Creature) map[string]uint64 {
+	counts := make(map[string]uint64)
+
+	shop, ok := vendor.Shop()
+	if !ok {
+		return counts
+	}
+
+	w.store.View(func(tx Tx) error {
+		for name, level := range stockOf(shop, shopRepo(tx).Get(vendor.ID), time.Now().Unix()) {
+			counts[name] = level.Count
+		}
+
+		return nil
+	})
+
+	return counts
+}
+
+// coinString describes an amount of coin
+func coinString(coins uint64) string {
+	if coins == 1 {
+		return "1 coin"
+	}
+
+	return fmt.Sprintf("%v coins", coins)
+}
+
+// vendorHere finds a vendor in the user's cell by creature ID
+func vendorHere(user User, vendorID string) (*Creature, Shop, error) {
+	for _, creature := range user.Cell().GetCreatures() {
+		if creature.ID != vendorID || creature.HP == 0 {
+			continue
+		}
+
+		if shop, ok := creature.Shop(); ok {
+			return creature, shop, nil
+		}
+
+		return nil, Shop{}, fmt.Errorf("%v isn't selling anything", creature.CreatureTypeStruct.Name)
+	}
+
+	return nil, Shop{}, fmt.Errorf("There's nobody here to trade with")
+}
+
+// coinDrop rolls how many coins a dead creature leaves, between half its Coins and all of them
+func coinDrop(creatureType CreatureType, rng *rand.Rand) uint64 {
+	if creatureType.Coins == 0 {
+		return 0
+	}
+
+	least := creatureType.Coins / 2
+	return least + uint64(rng.Int63n(int64(creatureType.Coins-least)+1))
+}
+
+// splitCoins shares out the coins from a kill evenly between the killer's party members standing
+// there, the killer getting whatever doesn't divide evenly. Anyone not in a party keeps the lot.
+func splitCoins(coins uint64, killer string, party Party, partyOK bool, present []string) map[string]uint64 {
+	shares := make(map[string]uint64)
+	if coins == 0 || killer == "" {
+		return shares
+	}
+
+	sharers := []string{killer}
+	if partyOK {
+		for _, username := range present {
+			if username != killer && party.HasMember(username) {
+				sharers = append(sharers, username)
+			}
+		}
+	}
+
+	for _, username := range sharers {
+		shares[username] = coins / uint64(len(sharers))
+	}
+	shares[killer] += coins % uint64(len(sharers))
+
+	return shares
+}
+
+func loadShopTypes(shopInfoFile string) {
+	var shopData struct {
+		Prices map[string]uint64 `json:""`
+		Shops  map[string]Shop   `json:""`
+	}
+
+	data, err := ioutil.ReadFile(shopInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &shopData)
+	}
+
+	if shopData.Prices != nil {
+		SellPrices = shopData.Prices
+	}
+
+	for k, v := range shopData.Shops {
+		v.ID = k
+		ShopTypes[k] = v
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", shopInfoFile, err)
+	}
+}
+
+func init() {
+	ShopTypes = make(map[string]Shop)
+	SellPrices = make(map[string]uint64)
+}
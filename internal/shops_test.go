@@ -0,0 +1,178 @@
+package mud
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// newTestVendor puts a vendor in a user's cell, which has to happen before the user is marked active
+func newTestVendor(t *testing.T, world *dbWorld, username, vendorType string) (User, *Creature) {
+	t.Helper()
+
+	user := newTestCharacter(t, world, username)
+	cell := world.CellAtPoint(*user.Location())
+	cell.AddStockCreature(vendorType)
+	user.MarkActive()
+
+	for _, creature := range cell.GetCreatures() {
+		if creature.CreatureType == vendorType {
+			return user, creature
+		}
+	}
+
+	t.Fatalf("no %v turned up", vendorType)
+	return nil, nil
+}
+
+func TestBuy(t *testing.T) {
+	tests := []struct {
+		name       string
+		vendor     string
+		item       string
+		coins      uint64
+		times      int
+		wantErr    bool
+		wantCoins  uint64
+		wantBought int
+	}{
+		{"buys it", "pedlar", "Healing Potion", 20, 1, false, 5, 1},
+		{"can't afford it", "pedlar", "Healing Potion", 10, 1, true, 10, 0},
+		{"not for sale", "pedlar", "Iron Helm", 100, 1, true, 100, 0},
+		{"sold out", "smith", "Chain Shirt", 200, 2, true, 110, 1},
+		{"never runs out", "pedlar", "Whetstone", 24, 3, false, 0, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user, vendor := newTestVendor(t, world, "shopper", test.vendor)
+			user.AddCoins(test.coins)
+
+			var err error
+			for i := 0; i < test.times; i++ {
+				err = user.Buy(vendor.ID, test.item)
+			}
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Buy(%v) = %v, want error %v", test.item, err, test.wantErr)
+			}
+			if coins := user.Coins(); coins != test.wantCoins {
+				t.Fatalf("left with %v coins, want %v", coins, test.wantCoins)
+			}
+
+			if bought := carriedItems(user.InventoryItems())[test.item]; bought != test.wantBought {
+				t.Fatalf("carrying %v %v, want %v", bought, test.item, test.wantBought)
+			}
+		})
+	}
+}
+
+func TestSell(t *testing.T) {
+	tests := []struct {
+		name      string
+		vendor    string
+		item      string
+		wantErr   bool
+		wantCoins uint64
+	}{
+		{"shop's own price", "pedlar", "Skull", false, 4},
+		{"default price by subtype", "pedlar", "Iron Helm", false, 15},
+		{"refused", "smith", "Healing Potion", true, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user, vendor := newTestVendor(t, world, "seller", test.vendor)
+			item := giveTestItem(t, user, test.item)
+
+			err := user.Sell(vendor.ID, item.ID)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Sell(%v) = %v, want error %v", test.item, err, test.wantErr)
+			}
+			if coins := user.Coins(); coins != test.wantCoins {
+				t.Fatalf("got %v coins, want %v", coins, test.wantCoins)
+			}
+			if kept := user.InventoryItem(item.ID) != nil; kept != test.wantErr {
+				t.Fatalf("still carrying %v = %v", test.item, kept)
+			}
+		})
+	}
+
+	world := newTestWorld(t)
+	user, vendor := newTestVendor(t, world, "seller", "pedlar")
+	if err := user.Sell(vendor.ID, "no-such-item"); err == nil {
+		t.Fatal("sold something the user never had")
+	}
+}
+
+func TestShopItemRestock(t *testing.T) {
+	item := ShopItem{Item: "Healing Potion", Stock: 5, Restock: 100}
+
+	tests := []struct {
+		name  string
+		level stockLevel
+		now   int64
+		want  uint64
+	}{
+		{"not long enough", stockLevel{Count: 1, Since: 1000}, 1099, 1},
+		{"one back", stockLevel{Count: 1, Since: 1000}, 1100, 2},
+		{"several back", stockLevel{Count: 0, Since: 1000}, 1350, 3},
+		{"never over the stock", stockLevel{Count: 4, Since: 1000}, 9000, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := item.restock(test.level, test.now); got.Count != test.want {
+				t.Fatalf("restock() = %+v, want %v in stock", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCoinDrop(t *testing.T) {
+	if coinDrop(CreatureType{}, rand.New(rand.NewSource(1))) != 0 {
+		t.Fatal("a creature without coins dropped some")
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		coins := coinDrop(CreatureType{Coins: 10}, rand.New(rand.NewSource(seed)))
+		if coins < 5 || coins > 10 {
+			t.Fatalf("dropped %v coins, want 5 to 10", coins)
+		}
+		if again := coinDrop(CreatureType{Coins: 10}, rand.New(rand.NewSource(seed))); again != coins {
+			t.Fatalf("the same stream dropped %v then %v coins", coins, again)
+		}
+	}
+}
+
+func TestSplitCoins(t *testing.T) {
+	party := Party{ID: "p", Leader: "ann", Members: []string{"ann", "bob", "cat"}}
+
+	tests := []struct {
+		name    string
+		killer  string
+		inParty bool
+		present []string
+		want    map[string]uint64
+	}{
+		{"solo", "ann", false, []string{"ann", "bob"}, map[string]uint64{"ann": 10}},
+		{"party shares, killer gets the odd coin", "ann", true, []string{"ann", "bob", "cat"}, map[string]uint64{"ann": 4, "bob": 3, "cat": 3}},
+		{"only members standing there", "bob", true, []string{"bob", "cat", "dan"}, map[string]uint64{"bob": 5, "cat": 5}},
+		{"no killer", "", false, []string{"ann"}, map[string]uint64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitCoins(10, test.killer, party, test.inParty, test.present)
+			if len(got) != len(test.want) {
+				t.Fatalf("splitCoins() = %v, want %v", got, test.want)
+			}
+			for username, coins := range test.want {
+				if got[username] != coins {
+					t.Fatalf("splitCoins() = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
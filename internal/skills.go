@@ -0,0 +1,164 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// Skills a user gets better at by using them
+const (
+	SKILLCUNNING  = "Cunning"
+	SKILLORDERLY  = "Orderly"
+	SKILLCREATIVE = "Creative"
+)
+
+// SkillNames lists the skills in the order they're shown
+var SkillNames = []string{SKILLCUNNING, SKILLORDERLY, SKILLCREATIVE}
+
+// SkillTypes is a mapping of skill names to what they unlock
+var SkillTypes map[string]SkillType
+
+// maxSkillLevel is as good as a user can get at a skill
+const maxSkillLevel = 20
+
+// skillXPStep is the skill XP it takes to go from level 1 to 2; each level after takes that much more
+const skillXPStep = 20
+
+// SkillType describes a skill and what it unlocks as it levels
+type SkillType struct {
+	Description string        `json:",omitempty"`
+	Unlocks     []SkillUnlock `json:",omitempty"`
+}
+
+// SkillUnlock is an attack a user learns on reaching a skill level
+type SkillUnlock struct {
+	Level  uint64 `json:""`
+	Attack Attack `json:""`
+}
+
+// SkillInfo handles how good a user is at each skill
+type SkillInfo interface {
+	SkillLevel(string) uint64
+	SkillXP(string) uint64
+	TrainSkill(string, uint64)
+}
+
+// skillBits maps a skill to the primary and secondary skill bits that give it
+var skillBits = map[string][2]byte{
+	SKILLCUNNING:  {CUNNINGPRIMARY, CUNNINGSECONDARY},
+	SKILLORDERLY:  {ORDERLYPRIMARY, ORDERLYSECONDARY},
+	SKILLCREATIVE: {CREATIVEPRIMARY, CREATIVESECONDARY}}
+
+// hasSkill checks whether a user has a skill as either their primary or secondary skill
+func hasSkill(user User, skill string) bool {
+	bits, ok := skillBits[skill]
+	if !ok {
+		return skill == ""
+	}
+
+	primary, secondary := user.Skills()
+	return primary == bits[0] || secondary == bits[1]
+}
+
+// skillLevel is the level a given amount of skill XP is worth
+func skillLevel(xp uint64) uint64 {
+	level := uint64(1)
+
+	for need := uint64(skillXPStep); xp >= need && level < maxSkillLevel; need += skillXPStep {
+		xp -= need
+		level++
+	}
+
+	return level
+}
+
+// skillGain scales skill XP by how much the skill is the user's thing: triple for their primary
+// skill, double for their secondary
+func skillGain(user User, skill string, xp uint64) uint64 {
+	bits, ok := skillBits[skill]
+	if !ok {
+		return 0
+	}
+
+	primary, secondary := user.Skills()
+	if primary == bits[0] {
+		return xp * 3
+	} else if secondary == bits[1] {
+		return xp * 2
+	}
+
+	return xp
+}
+
+// skillUnlocks lists the attacks a skill has taught by a level
+func skillUnlocks(skill string, level uint64) []Attack {
+	attacks := make([]Attack, 0)
+
+	for _, unlock := range SkillTypes[skill].Unlocks {
+		if unlock.Level <= level {
+			attacks = append(attacks, unlock.Attack)
+		}
+	}
+
+	return attacks
+}
+
+// cunningAccuracy is how many points Cunning adds to the chance to hit
+func cunningAccuracy(user User) int {
+	return int(user.SkillLevel(SKILLCUNNING) - 1)
+}
+
+// critChance is the 0-1.0 chance a hit does double damage, going by Cunning
+func critChance(user User) float32 {
+	return 0.015 * float32(user.SkillLevel(SKILLCUNNING)-1)
+}
+
+// stealthRange is how much closer creatures have to be to notice a user, going by Cunning
+func stealthRange(user User) uint {
+	return uint((user.SkillLevel(SKILLCUNNING) - 1) / 4)
+}
+
+// orderlyDefense is how much Orderly adds to each of a user's defense stats
+func orderlyDefense(user User) uint64 {
+	return (user.SkillLevel(SKILLORDERLY) - 1) / 2
+}
+
+// orderlyYield is the 0-1.0 chance a recipe makes a second batch, going by Orderly
+func orderlyYield(user User) float32 {
+	return 0.025 * float32(user.SkillLevel(SKILLORDERLY)-1)
+}
+
+// creativeLuck scales up the chance of each drop from a user's kill, going by Creative
+func creativeLuck(user User) float32 {
+	return 1 + 0.03*float32(user.SkillLevel(SKILLCREATIVE)-1)
+}
+
+// skillSummary is a one line account of a user's skill levels, for the character sheet
+func skillSummary(user User) string {
+	parts := make([]string, 0, len(SkillNames))
+
+	for _, skill := range SkillNames {
+		parts = append(parts, fmt.Sprintf("%v %v", skill, user.SkillLevel(skill)))
+	}
+
+	return "Skills  " + strings.Join(parts, "  ")
+}
+
+func loadSkillTypes(skillInfoFile string) {
+	data, err := ioutil.ReadFile(skillInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &SkillTypes)
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", skillInfoFile, err)
+	}
+}
+
+func init() {
+	SkillTypes = make(map[string]SkillType)
+}
@@ -0,0 +1,124 @@
+package mud
+
+import "testing"
+
+func TestSkillLevel(t *testing.T) {
+	tests := []struct {
+		xp   uint64
+		want uint64
+	}{
+		{0, 1},
+		{19, 1},
+		{20, 2},
+		{59, 2},
+		{60, 3},
+		{120, 4},
+		{3799, 19},
+		{3800, maxSkillLevel},
+		{1000000, maxSkillLevel},
+	}
+
+	for _, test := range tests {
+		if level := skillLevel(test.xp); level != test.want {
+			t.Fatalf("skillLevel(%v) = %v, want %v", test.xp, level, test.want)
+		}
+	}
+}
+
+func TestSkillGain(t *testing.T) {
+	tests := []struct {
+		name  string
+		skill string
+		want  uint64
+	}{
+		{"primary", SKILLCREATIVE, 30},
+		{"secondary", SKILLCUNNING, 20},
+		{"neither", SKILLORDERLY, 10},
+		{"no such skill", "Juggling", 0},
+	}
+
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "learner")
+	user.SetSkills(CREATIVEPRIMARY, CUNNINGSECONDARY)
+
+	for _, test := range tests {
+		if gain := skillGain(user, test.skill, 10); gain != test.want {
+			t.Fatalf("%v: skillGain(%v, 10) = %v, want %v", test.name, test.skill, gain, test.want)
+		}
+	}
+}
+
+func TestTrainSkill(t *testing.T) {
+	tests := []struct {
+		name        string
+		primary     byte
+		times       int
+		wantLevel   uint64
+		wantLearned []string
+	}{
+		{"too little to learn anything", CUNNINGPRIMARY, 1, 2, nil},
+		{"primary learns faster", CREATIVEPRIMARY, 1, 3, []string{"Improvised Snare"}},
+		{"learns each attack once", CREATIVEPRIMARY, 5, 6, []string{"Improvised Snare", "Dazzling Flourish"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "learner")
+			user.SetSkills(test.primary, 0)
+
+			for i := 0; i < test.times; i++ {
+				user.TrainSkill(SKILLCREATIVE, 20)
+			}
+
+			// It all comes from the store, not just the copy in hand
+			user = world.GetUser("learner")
+			if level := user.SkillLevel(SKILLCREATIVE); level != test.wantLevel {
+				t.Fatalf("Creative is level %v, want %v", level, test.wantLevel)
+			}
+
+			learned := make(map[string]int)
+			for _, attack := range user.(*dbUser).UserData.Attacks {
+				learned[attack.Name]++
+			}
+			for _, name := range test.wantLearned {
+				if learned[name] != 1 {
+					t.Fatalf("knows %v %v times, want once", name, learned[name])
+				}
+			}
+			if learned["Masterwork Strike"] != 0 {
+				t.Fatal("learned Masterwork Strike early")
+			}
+		})
+	}
+}
+
+func TestAttackTrainsOrderly(t *testing.T) {
+	tests := []struct {
+		name   string
+		hp     uint64
+		wantXP bool
+	}{
+		{"alive", 1000, true},
+		{"already dead", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestUser(t, world, "target")
+			user.SetHP(test.hp)
+			user.Save()
+
+			world.Attack(nil, user, &Attack{Name: "Smite", Accuracy: 100, AP: 10})
+
+			user.Reload()
+			if trained := user.SkillXP(SKILLORDERLY) > 0; trained != test.wantXP {
+				t.Fatalf("trained Orderly: %v, want %v", trained, test.wantXP)
+			}
+			if test.hp == 0 && user.HP() != 0 {
+				t.Fatalf("dead user has %v HP", user.HP())
+			}
+		})
+	}
+}
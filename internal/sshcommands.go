@@ -0,0 +1,362 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// sshCommand is something that can be run without an interactive session, like
+// `ssh mud who`. Run returns a value for --json output along with the plain text version.
+type sshCommand struct {
+	Usage          string
+	Help           string
+	NeedsCharacter bool
+	Run            func(builder WorldBuilder, user User, args []string) (interface{}, string, error)
+}
+
+type sshStatus struct {
+	Username      string            `json:""`
+	Title         string            `json:""`
+	Level         uint64            `json:""`
+	HP            uint64            `json:""`
+	MaxHP         uint64            `json:""`
+	AP            uint64            `json:""`
+	MaxAP         uint64            `json:""`
+	RP            uint64            `json:""`
+	MaxRP         uint64            `json:""`
+	MP            uint64            `json:""`
+	MaxMP         uint64            `json:""`
+	XP            uint64            `json:""`
+	XPToNextLevel uint64            `json:""`
+	Location      Point             `json:""`
+	Region        string            `json:""`
+	Effects       []string          `json:""`
+	PvP           bool              `json:""`
+	PvPStats      PvPStats          `json:""`
+	Defense       StatPoints        `json:""`
+	Coins         uint64            `json:""`
+	Skills        map[string]uint64 `json:""`
+	Talents       []string          `json:""`
+	TalentPoints  uint64            `json:""`
+}
+
+type sshWho struct {
+	Username string `json:""`
+	Title    string `json:""`
+	Level    uint64 `json:""`
+	Region   string `json:""`
+}
+
+type sshSlot struct {
+	Slot string `json:""`
+	Item string `json:""`
+}
+
+type sshItemCount struct {
+	Name  string `json:""`
+	Count int    `json:""`
+}
+
+type sshInventory struct {
+	Equipped []sshSlot      `json:""`
+	Items    []sshItemCount `json:""`
+}
+
+var sshCommands map[string]sshCommand
+
+func sshStatusCommand(builder WorldBuilder, user User, args []string) (interface{}, string, error) {
+	now := time.Now().Unix()
+	effects := make([]string, 0)
+	for _, effect := range user.Effects() {
+		if effect.Remaining(now) > 0 {
+			effects = append(effects, effect.String(now))
+		}
+	}
+
+	status := sshStatus{
+		Username:      user.Username(),
+		Title:         user.Title(),
+		Level:         user.Level(),
+		HP:            user.HP(),
+		MaxHP:         user.MaxHP(),
+		AP:            user.AP(),
+		MaxAP:         user.MaxAP(),
+		RP:            user.RP(),
+		MaxRP:         user.MaxRP(),
+		MP:            user.MP(),
+		MaxMP:         user.MaxMP(),
+		XP:            user.XP(),
+		XPToNextLevel: user.XPToNextLevel(),
+		Location:      *user.Location(),
+		Region:        user.LocationName(),
+		Effects:       effects,
+		PvP:           user.PvP(),
+		PvPStats:      user.PvPStats(),
+		Defense:       GetDefensePoints(user),
+		Coins:         user.Coins(),
+		Skills:        make(map[string]uint64),
+		Talents:       make([]string, 0),
+		TalentPoints:  user.TalentPoints()}
+
+	for _, skill := range SkillNames {
+		status.Skills[skill] = user.SkillLevel(skill)
+	}
+
+	for _, id := range user.Talents() {
+		if talent, ok := talentByID(id); ok {
+			status.Talents = append(status.Talents, talent.Name)
+		}
+	}
+
+	text := fmt.Sprintf("%v, Level %v %v\n", status.Username, status.Level, status.Title) +
+		fmt.Sprintf("HP %v/%v  AP %v/%v  RP %v/%v  MP %v/%v  XP %v/%v\n",
+			status.HP, status.MaxHP, status.AP, status.MaxAP, status.RP, status.MaxRP, status.MP, status.MaxMP, status.XP, status.XPToNextLevel) +
+		fmt.Sprintf("Defense AP %v  RP %v  MP %v\n", status.Defense.AP, status.Defense.RP, status.Defense.MP) +
+		fmt.Sprintf("Coins %v\n", status.Coins) +
+		skillSummary(user) + "\n" +
+		fmt.Sprintf("In %v (%v, %v)\n", status.Region, status.Location.X, status.Location.Y) +
+		pvpSummary(user) + "\n"
+
+	if len(status.Talents) > 0 {
+		text += fmt.Sprintf("Talents: %v\n", strings.Join(status.Talents, ", "))
+	}
+	if status.TalentPoints > 0 {
+		text += fmt.Sprintf("Talent points to spend: %v\n", status.TalentPoints)
+	}
+
+	if len(effects) > 0 {
+		text += fmt.Sprintf("Effects: %v\n", strings.Join(effects, ", "))
+	}
+
+	return status, text, nil
+}
+
+func sshWhoCommand(builder WorldBuilder, user User, args []string) (interface{}, string, error) {
+	who := make([]sshWho, 0)
+
+	for _, online := range builder.World().OnlineUsers() {
+		who = append(who, sshWho{Username: online.Username(), Title: online.Title(), Level: online.Level(), Region: online.LocationName()})
+	}
+
+	sort.Slice(who, func(i, j int) bool { return who[i].Username < who[j].Username })
+
+	text := fmt.Sprintf("%v online\n", len(who))
+	for _, online := range who {
+		text += fmt.Sprintf("%v, Level %v %v, in %v\n", online.Username, online.Level, online.Title, online.Region)
+	}
+
+	return who, text, nil
+}
+
+func sshInventoryCommand(builder WorldBuilder, user User, args []string) (interface{}, string, error) {
+	inventory := sshInventory{Equipped: make([]sshSlot, 0), Items: make([]sshItemCount, 0)}
+
+	text := "Equipped:\n"
+	for _, slot := range user.Equipped() {
+		itemName := ""
+		if slot.Item != nil {
+			itemName = slot.Item.Name
+		}
+
+		inventory.Equipped = append(inventory.Equipped, sshSlot{Slot: slot.Name, Item: itemName})
+		if itemName == "" {
+			itemName = "(empty)"
+		}
+		text += fmt.Sprintf("  %v: %v\n", slot.Name, itemName)
+	}
+
+	itemCount, _, keyList := groupInventory(user.InventoryItems())
+
+	text += "Carrying:\n"
+	for _, itemName := range keyList {
+		inventory.Items = append(inventory.Items, sshItemCount{Name: itemName, Count: itemCount[itemName]})
+		text += fmt.Sprintf("  %v x%v\n", itemName, itemCount[itemName])
+	}
+
+	if len(keyList) == 0 {
+		text += "  Nothing\n"
+	}
+
+	return inventory, text, nil
+}
+
+// parseSince reads a --since value, either a duration back from now or an RFC 3339 time
+func parseSince(value string) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return since, fmt.Errorf("Can't understand --since %v; use a duration like 10m or a time like 2006-01-02T15:04:05Z", value)
+	}
+
+	return since, nil
+}
+
+func sshLogCommand(builder WorldBuilder, user User, args []string) (interface{}, string, error) {
+	var logMessages []LogItem
+
+	if len(args) > 0 {
+		value := ""
+		if strings.HasPrefix(args[0], "--since=") {
+			value = strings.TrimPrefix(args[0], "--since=")
+		} else if args[0] == "--since" && len(args) > 1 {
+			value = args[1]
+		} else {
+			return nil, "", fmt.Errorf("Usage: %v", sshCommands["log"].Usage)
+		}
+
+		since, err := parseSince(value)
+		if err != nil {
+			return nil, "", err
+		}
+
+		logMessages = user.GetLogSince(since)
+	} else {
+		logMessages = user.GetLog()
+	}
+
+	// Stored newest first, but reads better oldest first
+	for i, j := 0, len(logMessages)-1; i < j; i, j = i+1, j-1 {
+		logMessages[i], logMessages[j] = logMessages[j], logMessages[i]
+	}
+
+	text := ""
+	for _, message := range logMessages {
+		stamp := ""
+		if !message.Timestamp.IsZero() {
+			stamp = message.Timestamp.UTC().Format(time.RFC3339) + " "
+		}
+
+		if message.Author != "" {
+			text += fmt.Sprintf("%v%v: %v\n", stamp, message.ChatAuthor(), message.Message)
+		} else {
+			text += fmt.Sprintf("%v%v\n", stamp, message.Message)
+		}
+	}
+
+	return logMessages, text, nil
+}
+
+func sshSayCommand(builder WorldBuilder, user User, args []string) (interface{}, string, error) {
+	message := strings.TrimSpace(strings.Join(args, " "))
+
+	if message == "" {
+		return nil, "", fmt.Errorf("Usage: %v", sshCommands["say"].Usage)
+	}
+
+	chatItem, err := sendChat(builder, user, CHANNELGLOBAL, "", message)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return chatItem, fmt.Sprintf("%v: %v\n", chatItem.ChatAuthor(), chatItem.Message), nil
+}
+
+func sshHelpCommand(builder WorldBuilder, user User, args []string) (interface{}, string, error) {
+	names := make([]string, 0, len(sshCommands))
+	for name := range sshCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	help := make(map[string]string)
+	text := "Commands (add --json for JSON output):\n"
+	for _, name := range names {
+		command := sshCommands[name]
+		help[command.Usage] = command.Help
+		text += fmt.Sprintf("  %-22v %v\n", command.Usage, command.Help)
+	}
+
+	return help, text, nil
+}
+
+// splitJSONFlag pulls --json out of a command line wherever it appears
+func splitJSONFlag(command []string) ([]string, bool) {
+	args := make([]string, 0, len(command))
+	jsonOutput := false
+
+	for _, arg := range command {
+		if arg == "--json" || arg == "-json" {
+			jsonOutput = true
+		} else {
+			args = append(args, arg)
+		}
+	}
+
+	return args, jsonOutput
+}
+
+// runSSHCommand runs the command a session was opened with and returns the exit status
+func runSSHCommand(builder WorldBuilder, user User, session ssh.Session) int {
+	args, jsonOutput := splitJSONFlag(session.Command())
+
+	if len(args) == 0 {
+		args = []string{"help"}
+	}
+
+	command, ok := sshCommands[strings.ToLower(args[0])]
+	if !ok {
+		fmt.Fprintf(session.Stderr(), "Unknown command %v. Try help.\n", args[0])
+		return 1
+	} else if command.NeedsCharacter && !user.IsInitialized() {
+		fmt.Fprintf(session.Stderr(), "%v hasn't been set up yet. Log in without a command first.\n", user.Username())
+		return 1
+	}
+
+	result, text, err := command.Run(builder, user, args[1:])
+	if err != nil {
+		fmt.Fprintln(session.Stderr(), err.Error())
+		return 1
+	}
+
+	if jsonOutput {
+		if err := json.NewEncoder(session).Encode(result); err != nil {
+			fmt.Fprintln(session.Stderr(), err.Error())
+			return 1
+		}
+	} else {
+		io.WriteString(session, text)
+	}
+
+	return 0
+}
+
+func init() {
+	sshCommands = map[string]sshCommand{
+		"status": sshCommand{
+			Usage:          "status",
+			Help:           "Your stats, level and whereabouts",
+			NeedsCharacter: true,
+			Run:            sshStatusCommand},
+		"who": sshCommand{
+			Usage: "who",
+			Help:  "Who's online",
+			Run:   sshWhoCommand},
+		"inventory": sshCommand{
+			Usage:          "inventory",
+			Help:           "What you have equipped and what you're carrying",
+			NeedsCharacter: true,
+			Run:            sshInventoryCommand},
+		"log": sshCommand{
+			Usage: "log [--since 10m]",
+			Help:  "Your message log, optionally only since a duration ago or an RFC 3339 time",
+			Run:   sshLogCommand},
+		"say": sshCommand{
+			Usage:          "say MESSAGE",
+			Help:           "Send a message on the global chat channel",
+			NeedsCharacter: true,
+			Run:            sshSayCommand},
+		"help": sshCommand{
+			Usage: "help",
+			Help:  "This list",
+			Run:   sshHelpCommand},
+	}
+}
@@ -0,0 +1,88 @@
+package mud
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitJSONFlag(t *testing.T) {
+	tests := []struct {
+		command  []string
+		wantArgs int
+		wantJSON bool
+	}{
+		{[]string{"status"}, 1, false},
+		{[]string{"status", "--json"}, 1, true},
+		{[]string{"-json", "log", "--since", "10m"}, 3, true},
+		{nil, 0, false},
+	}
+
+	for _, test := range tests {
+		args, jsonOutput := splitJSONFlag(test.command)
+		if len(args) != test.wantArgs || jsonOutput != test.wantJSON {
+			t.Fatalf("splitJSONFlag(%v) = %v, %v", test.command, args, jsonOutput)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"10m", now.Add(-10 * time.Minute), false},
+		{"2006-01-02T15:04:05Z", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseSince(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseSince(%v) = %v", test.value, err)
+			}
+			if diff := got.Sub(test.want); !test.wantErr && (diff > time.Second || diff < -time.Second) {
+				t.Fatalf("parseSince(%v) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSSHCommands(t *testing.T) {
+	world := newTestWorld(t)
+	builder := NewWorldBuilder(world)
+	user := newTestUser(t, world, "scripter")
+
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"status"}, false},
+		{[]string{"who"}, false},
+		{[]string{"inventory"}, false},
+		{[]string{"say", "hello"}, false},
+		{[]string{"say"}, true},
+		{[]string{"log", "--since", "1h"}, false},
+		{[]string{"log", "--until"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.args[0], func(t *testing.T) {
+			command, ok := sshCommands[test.args[0]]
+			if !ok {
+				t.Fatalf("no ssh command %v", test.args[0])
+			}
+
+			_, text, err := command.Run(builder, user, test.args[1:])
+			if (err != nil) != test.wantErr {
+				t.Fatalf("%v = %v, want error %v", test.args, err, test.wantErr)
+			}
+			if !test.wantErr && text == "" {
+				t.Fatalf("%v printed nothing", test.args)
+			}
+		})
+	}
+}
@@ -15,38 +15,48 @@ import (
 
 const mudPubkey = "MUD-pubkey"
 
-func handleConnection(builder WorldBuilder, session ssh.Session) {
-	user := builder.GetUser(session.User())
-	screen := NewSSHScreen(session, builder, user)
+func handleConnection(builder WorldBuilder, session ssh.Session, admins []string) {
+	world := builder.World()
 	pubKey, _ := session.Context().Value(mudPubkey).(string)
-	userSSH, ok := user.(UserSSHAuthentication)
 
-	builder.Chat(LogItem{Message: fmt.Sprintf("User %s has logged in", user.Username()), MessageType: MESSAGESYSTEM})
-	user.MarkActive()
-	user.Act()
+	account, err := world.SignIn(session.User(), pubKey)
+	if err != nil {
+		session.Write([]byte(err.Error() + "\n"))
+		log.Printf("Account %s can't sign in: %v", session.User(), err)
+		session.Exit(1)
+		return
+	}
 
-	if len(session.Command()) > 0 {
-		session.Write([]byte("Commands are not supported.\n"))
-		session.Close()
+	// Admins are named by account, never by character: anyone can give a character any free name
+	admin := false
+	for _, name := range admins {
+		if name == account.Name {
+			admin = true
+		}
 	}
 
-	if ok {
-		if userSSH.SSHKeysEmpty() {
-			userSSH.AddSSHKey(pubKey)
-			log.Printf("Saving SSH key for %s", user.Username())
-		} else if !userSSH.ValidateSSHKey(pubKey) {
-			session.Write([]byte("This is not the SSH key verified for this user. Try another username.\n"))
-			log.Printf("User %s doesn't use this key.", user.Username())
+	if len(session.Command()) > 0 {
+		if account.LastPlayed == "" {
+			fmt.Fprintf(session.Stderr(), "Log in without a command first to pick a character.\n")
+			session.Exit(1)
 			return
 		}
+
+		user, err := world.PlayCharacter(account.Name, account.LastPlayed)
+		if err != nil {
+			fmt.Fprintf(session.Stderr(), "%v\n", err)
+			session.Exit(1)
+			return
+		}
+		user.SetAdmin(admin)
+
+		log.Printf("Running %v for %v@%v", session.Command(), user.Username(), session.RemoteAddr())
+		session.Exit(runSSHCommand(builder, user, session))
+		return
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	logMessage := fmt.Sprintf("Logged in as %s via %s at %s", user.Username(), session.RemoteAddr(), time.Now().UTC().Format(time.RFC3339))
-	log.Println(logMessage)
-	user.Log(LogItem{Message: logMessage, MessageType: MESSAGESYSTEM})
-
 	done := session.Context().Done()
 	tick := time.Tick(500 * time.Millisecond)
 	tickForOnline := time.Tick(2 * time.Second)
@@ -55,8 +65,24 @@ func handleConnection(builder WorldBuilder, session ssh.Session) {
 
 	go handleKeys(reader, stringInput, cancel)
 
+	user := selectCharacter(ctx, cancel, done, session, world, account.Name, stringInput)
+	if user == nil {
+		return
+	}
+	user.SetAdmin(admin)
+
+	screen := NewSSHScreen(session, builder, user)
+
+	builder.Chat(LogItem{Message: fmt.Sprintf("User %s has logged in", user.Username()), MessageType: MESSAGESYSTEM})
+	user.MarkActive()
+	user.Act()
+
+	logMessage := fmt.Sprintf("Logged in as %s via %s at %s", user.Username(), session.RemoteAddr(), time.Now().UTC().Format(time.RFC3339))
+	log.Println(logMessage)
+	user.Log(LogItem{Message: logMessage, MessageType: MESSAGESYSTEM})
+
 	if !user.IsInitialized() {
-		setupSSHUser(ctx, cancel, done, session, user, stringInput)
+		setupSSHUser(ctx, cancel, done, session, user, stringInput, false)
 	}
 
 	for {
@@ -81,7 +107,11 @@ func handleConnection(builder WorldBuilder, session ssh.Session) {
 				builder.MoveUserEast(user)
 				screen.Render()
 			case "TAB":
-				screen.ToggleInventory()
+				if screen.InputActive() && screen.InCommandMode() {
+					screen.CompleteCommand()
+				} else {
+					screen.ToggleInventory()
+				}
 			case "ESCAPE":
 				screen.ToggleInput()
 			case "[":
@@ -105,22 +135,13 @@ func handleConnection(builder WorldBuilder, session ssh.Session) {
 			case "ENTER":
 				if screen.InputActive() {
 					chat := screen.GetChat()
-					var chatItem LogItem
 					if screen.InCommandMode() {
-						chatItem = LogItem{
-							Author:      user.Username(),
-							Message:     chat,
-							MessageType: MESSAGEACTION}
-						if len(chat) > 0 {
-							user.Log(chatItem)
-						}
+						screen.RunCommand(chat)
 					} else {
-						chatItem = LogItem{
-							Author:      user.Username(),
-							Message:     chat,
-							MessageType: MESSAGECHAT}
 						if len(chat) > 0 {
-							builder.Chat(chatItem)
+							if _, err := sendChat(builder, user, screen.ChatChannel(), "", chat); err != nil {
+								user.Log(LogItem{Message: err.Error(), MessageType: MESSAGEACTION})
+							}
 						}
 					}
 
@@ -135,6 +156,11 @@ func handleConnection(builder WorldBuilder, session ssh.Session) {
 			user.MarkActive()
 		case <-tick:
 			user.Reload()
+			if user.Respeccing() {
+				setupSSHUser(ctx, cancel, done, session, user, stringInput, true)
+				screen.Redraw()
+				continue
+			}
 			screen.Render()
 			continue
 		case <-done:
@@ -149,11 +175,13 @@ func handleConnection(builder WorldBuilder, session ssh.Session) {
 }
 
 // ServeSSH runs the main SSH server loop.
-func ServeSSH(listen string) {
-	rand.Seed(time.Now().Unix())
+func ServeSSH(config ServerConfig) {
+	rand.Seed(time.Now().UnixNano())
+	listen := config.Listen
 
-	world := LoadWorldFromDB("./world.db")
+	world := LoadWorldFromDB("./world.db", config.Seed)
 	defer world.Close()
+	world.ConfigureRespawns(config.Respawn)
 	builder := NewWorldBuilder(world)
 
 	privateKey := makeKeyFiles()
@@ -166,6 +194,6 @@ func ServeSSH(listen string) {
 
 	log.Printf("Starting SSH server on %v", listen)
 	log.Fatal(ssh.ListenAndServe(listen, func(s ssh.Session) {
-		handleConnection(builder, s)
+		handleConnection(builder, s, config.Admins)
 	}, publicKeyOption, ssh.HostKeyFile(privateKey)))
 }
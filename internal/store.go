@@ -0,0 +1,80 @@
+package mud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// Store is an embedded, transactional key/value database the world is persisted to
+type Store interface {
+	View(func(Tx) error) error
+	Update(func(Tx) error) error
+	Close() error
+}
+
+// Tx is a single read-only or read-write transaction against a Store
+type Tx interface {
+	Bucket(name string) Bucket
+	CreateBucketIfNotExists(name string) (Bucket, error)
+}
+
+// Bucket is a named collection of keys kept in byte order
+type Bucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	ForEach(func(k, v []byte) error) error
+	// Range visits every key between min and max inclusive, stopping early if the visitor errors
+	Range(min, max []byte, visitor func(k, v []byte) error) error
+	NextSequence() (uint64, error)
+}
+
+var (
+	errReadOnlyTx = errors.New("write attempted in a read-only transaction")
+	errStopRange  = errors.New("stop iterating")
+)
+
+// storeBuckets lists every bucket a world needs before it can be used
+var storeBuckets = []string{"users", "userinventory", "userequipment", "userlog", "onlineusers", "lastuseraction", "terrain", "placenames", "placeitems", "creaturelist", "creatures", "settings", "userquests", "parties", "userparties", "duels", "respawns", "respawncells", "regioncreatures", "shopstock", "trades", "userbank", "accounts"}
+
+// prefixedKey builds an owner + \0 + suffix key, the layout every per-owner bucket uses
+func prefixedKey(prefix []byte, suffix []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, prefix)
+	binary.Write(buf, binary.BigEndian, byte(0))
+	binary.Write(buf, binary.BigEndian, suffix)
+
+	return buf.Bytes()
+}
+
+// prefixRange returns the min and max keys for a Range scan over everything an owner has
+func prefixRange(prefix []byte) ([]byte, []byte) {
+	minBuf := new(bytes.Buffer)
+	maxBuf := new(bytes.Buffer)
+	binary.Write(minBuf, binary.BigEndian, prefix)
+	binary.Write(minBuf, binary.BigEndian, byte(0))
+	binary.Write(maxBuf, binary.BigEndian, prefix)
+	binary.Write(maxBuf, binary.BigEndian, byte(1))
+
+	return minBuf.Bytes(), maxBuf.Bytes()
+}
+
+// deleteOwned removes everything an owner has in a per-owner bucket
+func deleteOwned(bucket Bucket, owner []byte) error {
+	keys := make([][]byte, 0)
+
+	min, max := prefixRange(owner)
+	bucket.Range(min, max, func(k, v []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
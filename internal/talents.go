@@ -0,0 +1,314 @@
+package mud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+)
+
+// talentPointsPerLevel is how many talent points each level past the first brings
+const talentPointsPerLevel = 1
+
+// talentRespecCost is what forgetting talents costs for each talent point spent on them
+const talentRespecCost = 25
+
+// TalentTrees is a mapping of primary strengths (Melee, Range, Magic) to the talents open to them
+var TalentTrees map[string]TalentTree
+
+// treeNames maps a primary strength to the talent tree that goes with it
+var treeNames = map[byte]string{
+	MELEEPRIMARY: "Melee",
+	RANGEPRIMARY: "Range",
+	MAGICPRIMARY: "Magic"}
+
+// TalentTree is the set of talents a class can spend talent points on
+type TalentTree struct {
+	ID      string            `json:"-"`
+	Name    string            `json:""`
+	Talents map[string]Talent `json:""` // IDs have to be unique across every tree
+}
+
+// Talent is something a user learns by spending talent points, granting any of an attack, a
+// bonus string on all their attacks, an extra equipment slot and a passive effect
+type Talent struct {
+	ID          string             `json:"-"`
+	Name        string             `json:""`
+	Description string             `json:",omitempty"`
+	Class       string             `json:",omitempty"` // Only for characters of this class, like Paladin; empty means the whole tree
+	Level       uint64             `json:",omitempty"` // Character level needed to learn it
+	Cost        uint64             `json:",omitempty"` // Talent points; 0 means 1
+	Requires    []string           `json:",omitempty"` // IDs of talents that have to be learned first
+	Attack      *Attack            `json:",omitempty"`
+	Bonuses     string             `json:",omitempty"` // Bonus string applied to every attack the user makes
+	Slot        *EquipmentSlotInfo `json:",omitempty"`
+	Passive     string             `json:",omitempty"` // ID of an effect in effects.json kept running for as long as the talent is known
+}
+
+// TalentInfo handles the talents a user has learned and the points they have to spend
+type TalentInfo interface {
+	Talents() []string
+	TalentPoints() uint64
+	LearnTalent(string) error
+	RespecPrice() uint64
+	RespecTalents() error
+}
+
+// Points is how many talent points a talent costs
+func (talent *Talent) Points() uint64 {
+	if talent.Cost == 0 {
+		return 1
+	}
+
+	return talent.Cost
+}
+
+// talentPointString describes a number of talent points
+func talentPointString(points uint64) string {
+	if points == 1 {
+		return "1 talent point"
+	}
+
+	return fmt.Sprintf("%v talent points", points)
+}
+
+// talentTree is the tree a user's primary strength gives them
+func talentTree(user User) (TalentTree, bool) {
+	primary, _ := user.Strengths()
+
+	tree, ok := TalentTrees[treeNames[primary]]
+	return tree, ok
+}
+
+// talentByID finds a talent in any tree
+func talentByID(id string) (Talent, bool) {
+	for _, tree := range TalentTrees {
+		if talent, ok := tree.Talents[id]; ok {
+			return talent, true
+		}
+	}
+
+	return Talent{}, false
+}
+
+// strengthClass is the class a user's strengths make them, like Warrior or Paladin
+func strengthClass(user User) string {
+	primary, secondary := user.Strengths()
+	skill1, skill2 := user.Skills()
+	class, _ := GetSubTitles(primary, secondary, skill1, skill2)
+
+	return class
+}
+
+// talentList is every talent in a tree a user's class can learn, by level and then name
+func talentList(user User, tree TalentTree) []Talent {
+	class := strengthClass(user)
+
+	talents := make([]Talent, 0, len(tree.Talents))
+	for _, talent := range tree.Talents {
+		if talent.Class == "" || talent.Class == class {
+			talents = append(talents, talent)
+		}
+	}
+	sort.Slice(talents, func(i, j int) bool {
+		if talents[i].Level != talents[j].Level {
+			return talents[i].Level < talents[j].Level
+		}
+		return talents[i].Name < talents[j].Name
+	})
+
+	return talents
+}
+
+// talentPointsEarned is how many talent points a character has been given by a level
+func talentPointsEarned(level uint64) uint64 {
+	if level <= 1 {
+		return 0
+	}
+
+	return (level - 1) * talentPointsPerLevel
+}
+
+// talentPointsSpent adds up what a set of learned talents cost
+func talentPointsSpent(ids []string) uint64 {
+	spent := uint64(0)
+
+	for _, id := range ids {
+		if talent, ok := talentByID(id); ok {
+			spent += talent.Points()
+		}
+	}
+
+	return spent
+}
+
+// knowsTalent checks whether a talent is among those learned
+func knowsTalent(ids []string, id string) bool {
+	for _, known := range ids {
+		if known == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canLearnTalent says why a user can't learn a talent, if they can't
+func canLearnTalent(user User, talent *Talent) error {
+	known := user.Talents()
+
+	if knowsTalent(known, talent.ID) {
+		return fmt.Errorf("You already know %v", talent.Name)
+	} else if talent.Class != "" && talent.Class != strengthClass(user) {
+		return fmt.Errorf("%v is only for a %v", talent.Name, talent.Class)
+	} else if user.Level() < talent.Level {
+		return fmt.Errorf("%v takes level %v", talent.Name, talent.Level)
+	}
+
+	for _, id := range talent.Requires {
+		if !knowsTalent(known, id) {
+			required, _ := talentByID(id)
+			return fmt.Errorf("%v needs %v first", talent.Name, required.Name)
+		}
+	}
+
+	if points := user.TalentPoints(); points < talent.Points() {
+		return fmt.Errorf("%v costs %v; you have %v", talent.Name, talentPointString(talent.Points()), points)
+	}
+
+	return nil
+}
+
+// talentBonuses applies the bonus strings of every learned talent to an attack
+func talentBonuses(ids []string, attack Attack) Attack {
+	for _, id := range ids {
+		talent, ok := talentByID(id)
+		if !ok || talent.Bonuses == "" {
+			continue
+		}
+
+		atkSP := attack.FullStatPoints()
+		boosted := ApplyBonuses(&atkSP, nil, talent.Bonuses)
+		attack.AP, attack.RP, attack.MP, attack.Trample = boosted.AP, boosted.RP, boosted.MP, boosted.Trample
+	}
+
+	return attack
+}
+
+// talentPassives is the set of effect IDs a user's talents keep running
+func talentPassives(ids []string) map[string]bool {
+	passives := make(map[string]bool)
+
+	for _, id := range ids {
+		if talent, ok := talentByID(id); ok && talent.Passive != "" {
+			passives[talent.Passive] = true
+		}
+	}
+
+	return passives
+}
+
+// keepPassives starts any passive effect that isn't running, whether it wore off or was never started
+func keepPassives(effects []ActiveEffect, passives map[string]bool, now int64) []ActiveEffect {
+	for id := range passives {
+		running := false
+		for _, effect := range effects {
+			if effect.ID == id && effect.Expires > now {
+				running = true
+				break
+			}
+		}
+
+		if !running {
+			effects = addEffect(effects, id, now)
+		}
+	}
+
+	return effects
+}
+
+// forgetTalents takes back everything a user's talents gave them, returning the names of the
+// equipment slots that went with them
+func forgetTalents(userData *UserData) []string {
+	attacks, slots, passives := make(map[string]bool), make(map[string]bool), talentPassives(userData.Talents)
+
+	for _, id := range userData.Talents {
+		talent, ok := talentByID(id)
+		if !ok {
+			continue
+		}
+
+		if talent.Attack != nil {
+			attacks[talent.Attack.Name] = true
+		}
+		if talent.Slot != nil {
+			slots[talent.Slot.Name] = true
+		}
+	}
+
+	keptAttacks := make([]*Attack, 0, len(userData.Attacks))
+	for _, attack := range userData.Attacks {
+		if !attacks[attack.Name] {
+			keptAttacks = append(keptAttacks, attack)
+		}
+	}
+
+	keptSlots := make([]*EquipmentSlotInfo, 0, len(userData.Slots))
+	removed := make([]string, 0)
+	for _, slot := range userData.Slots {
+		if slots[slot.Name] {
+			removed = append(removed, slot.Name)
+		} else {
+			keptSlots = append(keptSlots, slot)
+		}
+	}
+
+	keptEffects := make([]ActiveEffect, 0, len(userData.Effects))
+	for _, effect := range userData.Effects {
+		if !passives[effect.ID] {
+			keptEffects = append(keptEffects, effect)
+		}
+	}
+
+	userData.Attacks, userData.Slots, userData.Effects = keptAttacks, keptSlots, keptEffects
+	userData.Talents = nil
+
+	return removed
+}
+
+// passiveNamed checks whether an effect name belongs to one of a set of passive effect IDs
+func passiveNamed(passives map[string]bool, name string) bool {
+	for id := range passives {
+		if effectType, ok := EffectTypes[id]; ok && effectType.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func loadTalentTrees(talentInfoFile string) {
+	data, err := ioutil.ReadFile(talentInfoFile)
+
+	if err == nil {
+		err = json.Unmarshal(data, &TalentTrees)
+	}
+
+	for k, tree := range TalentTrees {
+		tree.ID = k
+		for id, talent := range tree.Talents {
+			talent.ID = id
+			tree.Talents[id] = talent
+		}
+		TalentTrees[k] = tree
+	}
+
+	if err != nil {
+		log.Printf("Error parsing %s: %v", talentInfoFile, err)
+	}
+}
+
+func init() {
+	TalentTrees = make(map[string]TalentTree)
+}
@@ -0,0 +1,168 @@
+package mud
+
+import "testing"
+
+// newTestTalentedUser makes a level 6 melee user who has learned every talent that comes
+// with something to take back: a bonus, an attack, a passive effect and an equipment slot
+func newTestTalentedUser(t *testing.T, world *dbWorld, username string) User {
+	t.Helper()
+
+	user := newTestUser(t, world, username)
+	user.SetStrengths(MELEEPRIMARY, RANGESECONDARY)
+	user.Initialize(true)
+
+	user.Reload()
+	user.(*dbUser).UserData.Level = 6
+	user.Save()
+
+	for _, id := range []string{"iron-grip", "cleave", "steeled", "second-hand"} {
+		if err := user.LearnTalent(id); err != nil {
+			t.Fatalf("LearnTalent(%v) = %v", id, err)
+		}
+	}
+
+	return user
+}
+
+func TestRespecTalents(t *testing.T) {
+	tests := []struct {
+		name      string
+		coins     uint64
+		equip     bool
+		wantErr   bool
+		wantCoins uint64
+	}{
+		{"nothing in the talent slot", 125, false, false, 0},
+		{"sword in the talent slot", 150, true, false, 25},
+		{"can't afford it", 124, true, true, 124},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			user := newTestTalentedUser(t, world, "forgetful")
+			user.AddCoins(test.coins)
+
+			if price := user.RespecPrice(); price != 125 {
+				t.Fatalf("RespecPrice() = %v, want 125", price)
+			}
+
+			if test.equip {
+				sword := giveTestItem(t, user, "Simple Sword")
+				user.PullInventoryItem(sword.ID)
+				if _, err := user.Equip("Second Hand", sword); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := user.RespecTalents()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RespecTalents() = %v, want error %v", err, test.wantErr)
+			}
+			if coins := user.Coins(); coins != test.wantCoins {
+				t.Fatalf("left with %v coins, want %v", coins, test.wantCoins)
+			}
+
+			// Everything the talents gave is gone for good, unless the respec failed
+			user = world.GetUser("forgetful")
+			userData := user.(*dbUser).UserData
+			kept := test.wantErr
+
+			if knows := len(userData.Talents) > 0; knows != kept {
+				t.Fatalf("knows talents %v", userData.Talents)
+			}
+			if hasSlot := knowsSlot(user, "Second Hand"); hasSlot != kept {
+				t.Fatalf("has the Second Hand slot: %v", hasSlot)
+			}
+			if hasAttack := knowsAttack(userData.Attacks, "Cleave"); hasAttack != kept {
+				t.Fatalf("knows Cleave: %v", hasAttack)
+			}
+			if hasPassive := hasEffect(userData.Effects, "steeled"); hasPassive != kept {
+				t.Fatalf("steeled: %v", hasPassive)
+			}
+
+			wantPoints := uint64(5)
+			if kept {
+				wantPoints = 0
+			}
+			if points := user.TalentPoints(); points != wantPoints {
+				t.Fatalf("%v talent points to spend, want %v", points, wantPoints)
+			}
+
+			// A sword in a slot that's gone goes back in the pack rather than vanishing
+			wantCarried := 0
+			if test.equip && !kept {
+				wantCarried = 1
+			}
+			if carried := carriedItems(user.InventoryItems())["Simple Sword"]; carried != wantCarried {
+				t.Fatalf("carrying %v swords, want %v", carried, wantCarried)
+			}
+			if test.equip && !kept && user.EquipmentSlotItem("Second Hand") != nil {
+				t.Fatal("the sword is still in the forgotten slot")
+			}
+		})
+	}
+}
+
+func TestRespecTalentsWithoutTalents(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "untalented")
+	user.AddCoins(100)
+
+	if err := user.RespecTalents(); err == nil {
+		t.Fatal("RespecTalents() worked with no talents")
+	}
+	if coins := user.Coins(); coins != 100 {
+		t.Fatalf("left with %v coins, want 100", coins)
+	}
+}
+
+func TestTickAfterRespecTalents(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestTalentedUser(t, world, "forgetful")
+	user.AddCoins(125)
+
+	// The ticker's copy was loaded while the talents were still known
+	ticker := world.GetUser("forgetful")
+	if err := user.RespecTalents(); err != nil {
+		t.Fatal(err)
+	}
+
+	ticker.TickEffects()
+	ticker.ChargePoints()
+
+	userData := world.GetUser("forgetful").(*dbUser).UserData
+	if len(userData.Talents) > 0 || hasEffect(userData.Effects, "steeled") {
+		t.Fatalf("talents %v and effects %v came back after a tick", userData.Talents, userData.Effects)
+	}
+}
+
+func knowsSlot(user User, name string) bool {
+	for _, slot := range user.EquipSlots() {
+		if slot == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func knowsAttack(attacks []*Attack, name string) bool {
+	for _, attack := range attacks {
+		if attack.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasEffect(effects []ActiveEffect, id string) bool {
+	for _, effect := range effects {
+		if effect.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
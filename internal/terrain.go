@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"math/rand"
 )
 
 // DefaultBiomeType yes
@@ -12,11 +13,12 @@ const DefaultBiomeType = "open-grass"
 // BiomeData contains information about biome types
 type BiomeData struct {
 	ID                  string
-	Name                string            `json:""`
-	Algorithm           string            `json:""` // Need strategies to make land
-	AlgorithmParameters map[string]string `json:""` // Helpers for terrain generator algorithm
-	Transitions         []string          `json:""` // Other biome types this can transition into when generating
-	GetRandomTransition func() string     // What to transition to
+	Name                string                  `json:""`
+	Algorithm           string                  `json:""`           // Need strategies to make land
+	AlgorithmParameters map[string]string       `json:""`           // Helpers for terrain generator algorithm
+	Transitions         []string                `json:""`           // Other biome types this can transition into when generating
+	PvP                 bool                    `json:",omitempty"` // Players can fight each other here without agreeing to
+	GetRandomTransition func(*rand.Rand) string // What to transition to
 }
 
 // DefaultCellType is the seed land type when spawning a character.
@@ -33,6 +35,8 @@ type CellTerrain struct {
 	AlgorithmParameters map[string]string `json:""`           // Helpers for terrain generator algorithm
 	CreatureSpawns      []CreatureSpawn   `json:""`           // List of monster types and probabilities of them appearing in each terrain type
 	ItemDrops           []ItemDrop        `json:""`           // List of items and probabilities of them appearing in each terrain type
+	PvP                 bool              `json:",omitempty"` // Players can fight each other here without agreeing to
+	Bank                bool              `json:",omitempty"` // Users can get to their bank here
 	FGcolor             byte              `json:""`           // SSH-display specific: the 256 color xterm color for FG
 	BGcolor             byte              `json:""`           // SSH-display specific: the 256 color xterm color for BG
 	Bold                bool              `json:""`           // SSH-display specific: bold the cell FG?
@@ -6,17 +6,12 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
-	"time"
-
-	"github.com/ojrac/opensimplex-go"
 )
 
-type tileFunc func(Cell, Cell, BiomeData, World) bool
+type tileFunc func(Cell, Cell, BiomeData, World, *rand.Rand) bool
 
 var tileGenerationAlgorithms map[string]tileFunc
 
-var seed opensimplex.Noise
-
 func getIntSetting(settings map[string]string, settingName string, defaultValue int) int {
 	if settings != nil {
 		value, ok := settings[settingName]
@@ -63,12 +58,12 @@ func getBoolSetting(settings map[string]string, settingName string, defaultValue
 	return defaultValue
 }
 
-func visitOnce(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitOnce(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	cell := world.Cell(x2, y2)
 	cell.SetCellInfo(&CellInfo{TerrainID: cellTerrain.ID, RegionNameID: regionID})
 }
 
-func tendril(x, y uint32, count uint64, world World, regionID uint64, cellTerrain *CellTerrain) {
+func tendril(x, y uint32, count uint64, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	if count <= 0 {
 		return
 	}
@@ -92,23 +87,23 @@ func tendril(x, y uint32, count uint64, world World, regionID uint64, cellTerrai
 	width, height := world.GetDimensions()
 	if x > 1 && y > 1 && x < width-2 && y < height-2 {
 		nx, ny := x, y
-		num := rand.Int() % 4
+		num := rng.Int() % 4
 		if num%2 == 0 {
 			nx += uint32(num - 1)
 		} else {
 			ny += uint32(num - 2)
 		}
-		tendril(nx, ny, count, world, regionID, cellTerrain)
+		tendril(nx, ny, count, world, regionID, cellTerrain, rng)
 	}
 }
 
-func visitTendril(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitTendril(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	radius := getIntSetting(cellTerrain.AlgorithmParameters, "radius", 4)
 
 	tendrilcount := getIntSetting(cellTerrain.AlgorithmParameters, "tendrilcount", radius)
 
 	for i := 0; i < tendrilcount; i++ {
-		tendril(x2, y2, uint64(radius), world, regionID, cellTerrain)
+		tendril(x2, y2, uint64(radius), world, regionID, cellTerrain, rng)
 	}
 
 	newCell := world.Cell(x2, y2)
@@ -121,7 +116,7 @@ func visitTendril(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerra
 	}
 }
 
-func visitSpread(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitSpread(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	blocked := false
 
 	newCell := world.Cell(x2, y2)
@@ -156,11 +151,11 @@ func visitSpread(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrai
 	}
 
 	if blocked {
-		visitTendril(x1, y1, x2, y2, world, regionID, cellTerrain)
+		visitTendril(x1, y1, x2, y2, world, regionID, cellTerrain, rng)
 	}
 }
 
-func visitPath(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitPath(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	xd := int(x2) - int(x1)
 	yd := int(y2) - int(y1)
 	nx, ny := (int(x2)), (int(y2))
@@ -182,7 +177,7 @@ func visitPath(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain
 		}
 	}
 
-	length := int(radius/2) + rand.Int()%int(radius/2)
+	length := int(radius/2) + rng.Int()%int(radius/2)
 	broken := false
 
 	for i := 0; i < length; i++ {
@@ -213,8 +208,8 @@ func visitPath(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain
 		}
 
 		// Make trails jitter a little
-		if rand.Int()%3 == 0 {
-			if rand.Int()%2 == 0 {
+		if rng.Int()%3 == 0 {
+			if rng.Int()%2 == 0 {
 				nx -= yd
 				ny -= xd
 			} else {
@@ -233,11 +228,11 @@ func visitPath(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain
 		if newCell.IsEmpty() {
 			newCell.SetCellInfo(&CellInfo{TerrainID: endcap, RegionNameID: regionID})
 
-			if rand.Int()%3 > 0 {
-				visitPath(uint32(nx), uint32(ny), uint32(nx+1), uint32(ny), world, regionID, cellTerrain)
-				visitPath(uint32(nx), uint32(ny), uint32(nx-1), uint32(ny), world, regionID, cellTerrain)
-				visitPath(uint32(nx), uint32(ny+1), uint32(nx), uint32(ny), world, regionID, cellTerrain)
-				visitPath(uint32(nx), uint32(ny-1), uint32(nx), uint32(ny), world, regionID, cellTerrain)
+			if rng.Int()%3 > 0 {
+				visitPath(uint32(nx), uint32(ny), uint32(nx+1), uint32(ny), world, regionID, cellTerrain, rng)
+				visitPath(uint32(nx), uint32(ny), uint32(nx-1), uint32(ny), world, regionID, cellTerrain, rng)
+				visitPath(uint32(nx), uint32(ny+1), uint32(nx), uint32(ny), world, regionID, cellTerrain, rng)
+				visitPath(uint32(nx), uint32(ny-1), uint32(nx), uint32(ny), world, regionID, cellTerrain, rng)
 			}
 		}
 	}
@@ -341,7 +336,7 @@ EmptyCheck:
 	return x, y, x + uint32(tileSize-1), y + uint32(tileSize-1), empty
 }
 
-func visitDungeonRoom(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitDungeonRoom(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	minRadius := getIntSetting(cellTerrain.AlgorithmParameters, "minradius", 5)
 	maxRadius := getIntSetting(cellTerrain.AlgorithmParameters, "maxradius", 5)
 	wall := getStringSetting(cellTerrain.AlgorithmParameters, "wall", cellTerrain.ID)
@@ -350,7 +345,7 @@ func visitDungeonRoom(x1, y1, x2, y2 uint32, world World, regionID uint64, cellT
 
 	radius := minRadius
 	if (maxRadius - minRadius) > 0 {
-		radius += rand.Int() % (maxRadius - minRadius)
+		radius += rng.Int() % (maxRadius - minRadius)
 	}
 
 	lx, ly, ux, uy, xd, yd, free := getAvailableBox(x1, y1, x2, y2, world, radius*2, radius*2)
@@ -401,7 +396,7 @@ func visitDungeonRoom(x1, y1, x2, y2 uint32, world World, regionID uint64, cellT
 	}
 }
 
-func visitGreatWall(castleWall Box, world World, regionID uint64, biome BiomeData) {
+func visitGreatWall(castleWall Box, world World, regionID uint64, biome BiomeData, rng *rand.Rand) {
 	settings := biome.AlgorithmParameters
 
 	seedExit := getStringSetting(settings, "seed-exit", "clearing-grass")
@@ -432,7 +427,7 @@ func visitGreatWall(castleWall Box, world World, regionID uint64, biome BiomeDat
 
 	// Outline
 	for x := uint32(0); x < (ux - lx); x++ {
-		if rand.Int()%2 == 0 {
+		if rng.Int()%2 == 0 {
 			world.Cell(uint32(lx+x), uint32(uy)).SetCellInfo(&wallTextureInfo)
 			world.Cell(uint32(ux-x), uint32(ly)).SetCellInfo(&wallTextureInfo)
 			world.Cell(uint32(ux-x), uint32(uy-wallThickness)).SetCellInfo(&wallTextureInfo)
@@ -443,7 +438,7 @@ func visitGreatWall(castleWall Box, world World, regionID uint64, biome BiomeDat
 		}
 	}
 	for y := uint32(0); y < (uy - ly); y++ {
-		if rand.Int()%2 == 0 {
+		if rng.Int()%2 == 0 {
 			world.Cell(uint32(lx), uint32(uy-y)).SetCellInfo(&wallTextureInfo)
 			world.Cell(uint32(ux), uint32(ly+y)).SetCellInfo(&wallTextureInfo)
 			world.Cell(uint32(lx+wallThickness), uint32(ly+y)).SetCellInfo(&wallTextureInfo)
@@ -481,7 +476,7 @@ func visitGreatWall(castleWall Box, world World, regionID uint64, biome BiomeDat
 	}
 }
 
-func visitCircle(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitCircle(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	settings := cellTerrain.AlgorithmParameters
 
 	radius := getIntSetting(settings, "radius", 50)
@@ -541,11 +536,11 @@ func visitCircle(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrai
 		} else {
 			*cellTerrain = CellTypes[seedExit]
 		}
-		visitSpread(x1, y1, x2, y2, world, regionID, &cellInfo.TerrainData)
+		visitSpread(x1, y1, x2, y2, world, regionID, &cellInfo.TerrainData, rng)
 	}
 }
 
-func visitChangeOfScenery(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain) {
+func visitChangeOfScenery(x1, y1, x2, y2 uint32, world World, regionID uint64, cellTerrain *CellTerrain, rng *rand.Rand) {
 	settings := cellTerrain.AlgorithmParameters
 
 	length := 100
@@ -619,13 +614,13 @@ func visitChangeOfScenery(x1, y1, x2, y2 uint32, world World, regionID uint64, c
 					dividerCenterCell.TerrainID = dividerEdge
 				} else if localthick < dividerThickness {
 					dividerCenterCell.TerrainID = dividerCenter
-				} else if localthick < dividerThickness+2+rand.Int()%2 {
+				} else if localthick < dividerThickness+2+rng.Int()%2 {
 					dividerCenterCell.TerrainID = leftInfo
 				} else {
 					continue
 				}
 				world.Cell(uint32(xc+(yp*thick)), uint32(yc+(xp*thick))).SetCellInfo(&dividerCenterCell)
-				jitter += (rand.Int() % 3) - 1
+				jitter += (rng.Int() % 3) - 1
 				if jitter < 0 {
 					jitter = 0
 				} else if jitter > 2 {
@@ -656,7 +651,7 @@ func visitChangeOfScenery(x1, y1, x2, y2 uint32, world World, regionID uint64, c
 		} else {
 			*cellTerrain = CellTypes[seedExit]
 		}
-		visitSpread(x1, y1, x2, y2, world, regionID, &cellInfo.TerrainData)
+		visitSpread(x1, y1, x2, y2, world, regionID, &cellInfo.TerrainData, rng)
 	}
 }
 
@@ -672,7 +667,7 @@ func isBoxEmpty(box Box, world World) bool {
 	return true
 }
 
-func fuzzBordersWithNeighbors(x1, y1, x2, y2 uint32, biome BiomeData, world World) {
+func fuzzBordersWithNeighbors(x1, y1, x2, y2 uint32, biome BiomeData, world World, rng *rand.Rand) {
 	top, bottom := Point{X: x1, Y: y1}, Point{X: x1, Y: y2}
 
 	width, height := int(x2-x1)/2, int(y2-y1)/2
@@ -687,7 +682,7 @@ func fuzzBordersWithNeighbors(x1, y1, x2, y2 uint32, biome BiomeData, world Worl
 				topInfo.BiomeID = biome.ID
 				pt := top
 
-				widthFill := rand.Int() % height
+				widthFill := rng.Int() % height
 
 				for i := 0; i < widthFill; i++ {
 					cell := world.CellAtPoint(pt)
@@ -705,7 +700,7 @@ func fuzzBordersWithNeighbors(x1, y1, x2, y2 uint32, biome BiomeData, world Worl
 				bottomInfo.BiomeID = biome.ID
 				pt := bottom
 
-				widthFill := rand.Int() % height
+				widthFill := rng.Int() % height
 
 				for i := 0; i < widthFill; i++ {
 					cell := world.CellAtPoint(pt)
@@ -732,7 +727,7 @@ func fuzzBordersWithNeighbors(x1, y1, x2, y2 uint32, biome BiomeData, world Worl
 				leftInfo.BiomeID = biome.ID
 				pt := left
 
-				heightFill := rand.Int() % width
+				heightFill := rng.Int() % width
 
 				for i := 0; i < heightFill; i++ {
 					cell := world.CellAtPoint(pt)
@@ -750,7 +745,7 @@ func fuzzBordersWithNeighbors(x1, y1, x2, y2 uint32, biome BiomeData, world Worl
 				rightInfo.BiomeID = biome.ID
 				pt := right
 
-				heightFill := rand.Int() % width
+				heightFill := rng.Int() % width
 
 				for i := 0; i < heightFill; i++ {
 					cell := world.CellAtPoint(pt)
@@ -789,6 +784,8 @@ func fillBox(b Box, world World, terrain *CellInfo) {
 }
 
 func fillWithNoise(x1, y1, x2, y2 uint32, biome BiomeData, terrainFunction func(float64) string, regionID uint64, world World) {
+	noise := worldNoise(world)
+
 	for xc := x1; xc <= x2; xc++ {
 		for yc := y1; yc <= y2; yc++ {
 			cell := world.Cell(xc, yc)
@@ -796,7 +793,7 @@ func fillWithNoise(x1, y1, x2, y2 uint32, biome BiomeData, terrainFunction func(
 				cell.SetCellInfo(&CellInfo{
 					TerrainID: terrainFunction(
 						math.Abs(
-							seed.Eval2(
+							noise.Eval2(
 								float64(xc)/10.0,
 								float64(yc)/10.0))),
 					BiomeID:      biome.ID,
@@ -806,7 +803,7 @@ func fillWithNoise(x1, y1, x2, y2 uint32, biome BiomeData, terrainFunction func(
 	}
 }
 
-func tilePerlin(fromCell, toCell Cell, biome BiomeData, world World) bool {
+func tilePerlin(fromCell, toCell Cell, biome BiomeData, world World, rng *rand.Rand) bool {
 	cellSize := getIntSetting(biome.AlgorithmParameters, "cell-size", 8)
 
 	newLoc := toCell.Location()
@@ -820,7 +817,7 @@ func tilePerlin(fromCell, toCell Cell, biome BiomeData, world World) bool {
 	terrains := strings.Split(biome.AlgorithmParameters["terrains"], ";")
 	terrainFunction := MakeGradientTransitionFunction(terrains)
 
-	fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world)
+	fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world, rng)
 	fillWithNoise(x1, y1, x2, y2, biome, terrainFunction, fromCell.CellInfo().RegionNameID, world)
 
 	spreadIfNew := getBoolSetting(biome.AlgorithmParameters, "spread-if-new", false)
@@ -844,7 +841,7 @@ func tilePerlin(fromCell, toCell Cell, biome BiomeData, world World) bool {
 				if isBoxEmpty(item, world) {
 					fillWithNoise(item.TopLeft.X, item.TopLeft.Y, item.BottomRight.X, item.BottomRight.Y, biome, terrainFunction, fromCell.CellInfo().RegionNameID, world)
 
-					for _, neighboritem := range rand.Perm(len(directions)) {
+					for _, neighboritem := range rng.Perm(len(directions)) {
 						newBox := item.Neighbor(directions[neighboritem])
 
 						if isBoxEmpty(newBox, world) {
@@ -870,7 +867,7 @@ func tilePerlin(fromCell, toCell Cell, biome BiomeData, world World) bool {
 	return true
 }
 
-func fillyReachy(fromCell, toCell Cell, biome BiomeData, world World) (Box, bool) {
+func fillyReachy(fromCell, toCell Cell, biome BiomeData, world World, rng *rand.Rand) (Box, bool) {
 	cellSize := getIntSetting(biome.AlgorithmParameters, "cell-size", 64)
 	terrains := strings.Split(biome.AlgorithmParameters["terrains"], ";")
 
@@ -886,7 +883,7 @@ func fillyReachy(fromCell, toCell Cell, biome BiomeData, world World) (Box, bool
 	// Can't fill block? Fizzle out some grass.
 	if !ok {
 		x1, y1, x2, y2, _ = getTile(newLoc.X, newLoc.Y, 8, world)
-		fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world)
+		fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world, rng)
 		fillWithNoise(x1, y1, x2, y2, biome, terrainFunction, fromCell.CellInfo().RegionNameID, world)
 
 		containerBox = BoxFromCoords(x1, y1, x2, y2)
@@ -903,8 +900,8 @@ func fillyReachy(fromCell, toCell Cell, biome BiomeData, world World) (Box, bool
 	return containerBox, false
 }
 
-func tileRuin(fromCell, toCell Cell, biome BiomeData, world World) bool {
-	containerBox, handled := fillyReachy(fromCell, toCell, biome, world)
+func tileRuin(fromCell, toCell Cell, biome BiomeData, world World, rng *rand.Rand) bool {
+	containerBox, handled := fillyReachy(fromCell, toCell, biome, world, rng)
 
 	if handled {
 		return true
@@ -959,7 +956,7 @@ func tileRuin(fromCell, toCell Cell, biome BiomeData, world World) bool {
 					}
 				}
 
-				for _, neighboritem := range rand.Perm(len(directions))[0 : 1+rand.Int()%len(directions)] {
+				for _, neighboritem := range rng.Perm(len(directions))[0 : 1+rng.Int()%len(directions)] {
 					newBox := item.Neighbor(directions[neighboritem])
 					door := item.Door(directions[neighboritem])
 
@@ -982,14 +979,14 @@ func tileRuin(fromCell, toCell Cell, biome BiomeData, world World) bool {
 		}
 	}
 
-	fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world)
+	fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world, rng)
 	fillWithNoise(x1, y1, x2, y2, biome, terrainFunction, regionName, world)
 
 	return true
 }
 
-func tileCastle(fromCell, toCell Cell, biome BiomeData, world World) bool {
-	containerBox, handled := fillyReachy(fromCell, toCell, biome, world)
+func tileCastle(fromCell, toCell Cell, biome BiomeData, world World, rng *rand.Rand) bool {
+	containerBox, handled := fillyReachy(fromCell, toCell, biome, world, rng)
 
 	if handled {
 		return true
@@ -1008,11 +1005,17 @@ func tileCastle(fromCell, toCell Cell, biome BiomeData, world World) bool {
 
 	castleWall := BoxFromCenteraAndWidthAndHeight(&c, uint32(castleSize), uint32(castleSize))
 
-	visitGreatWall(castleWall, world, regionName, biome)
+	visitGreatWall(castleWall, world, regionName, biome, rng)
 
-	fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world)
+	fuzzBordersWithNeighbors(x1, y1, x2, y2, biome, world, rng)
 	fillWithNoise(x1, y1, x2, y2, biome, terrainFunction, regionName, world)
 
+	// The keep in the middle is the lair of the biome's boss, if it has one
+	keep := world.CellAtPoint(c)
+	if boss := lairBoss(keep.CellInfo()); boss != "" {
+		keep.AddStockCreature(boss)
+	}
+
 	return true
 }
 
@@ -1028,9 +1031,11 @@ func PopulateCellFromAlgorithm(oldPos, newPos Cell, world World) bool {
 
 	fixed := false
 
+	rng := regionRand(world, "terrain", newPos.Location())
+
 AlgoLoop:
 	for i := 0; i < 25 && fixed == false; i++ {
-		newBiome := oldPos.CellInfo().BiomeData.GetRandomTransition()
+		newBiome := oldPos.CellInfo().BiomeData.GetRandomTransition(rng)
 		biome, ok := BiomeTypes[newBiome]
 		if !ok {
 			biome, ok = BiomeTypes[oldPos.CellInfo().BiomeID]
@@ -1046,7 +1051,7 @@ AlgoLoop:
 		}
 
 		if algo != nil {
-			fixed = algo(oldPos, newPos, biome, world)
+			fixed = algo(oldPos, newPos, biome, world, rng)
 			if fixed {
 				break AlgoLoop
 			}
@@ -1059,8 +1064,6 @@ AlgoLoop:
 }
 
 func init() {
-	seed = opensimplex.New(time.Now().UnixMicro())
-
 	tileGenerationAlgorithms = make(map[string]tileFunc)
 	tileGenerationAlgorithms["noise"] = tilePerlin
 	tileGenerationAlgorithms["ruin"] = tileRuin
@@ -0,0 +1,38 @@
+package mud
+
+import "testing"
+
+func TestPopulateCellFromAlgorithm(t *testing.T) {
+	world := newTestWorld(t)
+	user := newTestUser(t, world, "explorer")
+	spawn := *user.Location()
+
+	// Spawn is laid down with the user, but a few steps out is still wild
+	home := world.CellAtPoint(spawn)
+	wild := world.Cell(spawn.X, spawn.Y-3)
+	nowhere := world.Cell(spawn.X+500, spawn.Y+500)
+
+	tests := []struct {
+		name     string
+		from, to Cell
+		want     bool
+	}{
+		{"from empty", nowhere, wild, false},
+		{"into empty", home, wild, true},
+		{"into filled", wild, home, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := PopulateCellFromAlgorithm(test.from, test.to, world); got != test.want {
+				t.Fatalf("PopulateCellFromAlgorithm() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if wild.IsEmpty() {
+		t.Fatal("populated cell has no terrain")
+	} else if wild.CellInfo().RegionNameID == 0 {
+		t.Fatal("populated cell has no region")
+	}
+}
@@ -0,0 +1,140 @@
+package mud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tradeRequestSeconds is how long a trade request stands before it lapses
+const tradeRequestSeconds = 60
+
+// tradeSeconds is how long a trade can sit open without either side changing or confirming it
+const tradeSeconds = 300
+
+// TradeOffer is what one side of a trade puts up
+type TradeOffer struct {
+	Items     []string `json:",omitempty"` // IDs of items in the user's inventory
+	Coins     uint64   `json:",omitempty"`
+	Confirmed bool     `json:",omitempty"`
+}
+
+// Trade is an exchange of items and coin between two users standing together. Nothing changes
+// hands until both confirm, and changing either offer takes back both confirmations.
+type Trade struct {
+	Requester string                `json:""`
+	Partner   string                `json:""`
+	Accepted  bool                  `json:",omitempty"`
+	Updated   int64                 `json:""` // Unix time of the request, or of the last change to either offer
+	Offers    map[string]TradeOffer `json:",omitempty"`
+}
+
+// TradeInfo handles trading items and coin with other users
+type TradeInfo interface {
+	Trade() (Trade, bool)
+	RequestTrade(string) error
+	AcceptTrade() error
+	AddToTrade(itemID string) error
+	RemoveFromTrade(itemID string) error
+	OfferCoins(uint64) error
+	ConfirmTrade() error
+	CancelTrade() (Trade, error)
+}
+
+// Other is whoever a user is trading with
+func (trade *Trade) Other(username string) string {
+	if trade.Requester == username {
+		return trade.Partner
+	}
+
+	return trade.Requester
+}
+
+// Expired checks whether a request went unanswered or a trade sat idle too long
+func (trade *Trade) Expired(now int64) bool {
+	if trade.Accepted {
+		return now-trade.Updated > tradeSeconds
+	}
+
+	return now-trade.Updated > tradeRequestSeconds
+}
+
+// Offer is what a user has put up in the trade
+func (trade *Trade) Offer(username string) TradeOffer {
+	return trade.Offers[username]
+}
+
+// setOffer changes what a user has put up, taking back both sides' confirmations
+func (trade *Trade) setOffer(username string, offer TradeOffer, now int64) {
+	if trade.Offers == nil {
+		trade.Offers = make(map[string]TradeOffer)
+	}
+
+	for name, other := range trade.Offers {
+		other.Confirmed = false
+		trade.Offers[name] = other
+	}
+
+	offer.Confirmed = false
+	trade.Offers[username] = offer
+	trade.Updated = now
+}
+
+// Offering checks whether an item is in a user's offer
+func (offer *TradeOffer) Offering(itemID string) bool {
+	for _, id := range offer.Items {
+		if id == itemID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describeOffer lists what's in an offer by name, going by the items the user carries
+func describeOffer(offer TradeOffer, carried []*InventoryItem) string {
+	names := make([]string, 0, len(offer.Items))
+
+	for _, item := range carried {
+		if offer.Offering(item.ID) {
+			names = append(names, item.Name)
+		}
+	}
+
+	parts := make([]string, 0, 2)
+	if len(names) > 0 {
+		parts = append(parts, itemList(names))
+	}
+	if offer.Coins > 0 {
+		parts = append(parts, coinString(offer.Coins))
+	}
+
+	if len(parts) == 0 {
+		return "nothing"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// canTrade says why two users can't trade, if they can't
+func canTrade(user, other User) error {
+	if user.Username() == other.Username() {
+		return fmt.Errorf("You can't trade with yourself")
+	} else if *user.Location() != *other.Location() {
+		return fmt.Errorf("%v isn't here", other.Username())
+	}
+
+	return nil
+}
+
+// openTrade asks another user to trade, or agrees to if they asked first
+func openTrade(user User, username string) error {
+	if trade, ok := user.Trade(); ok && trade.Other(user.Username()) == username {
+		if !trade.Accepted && trade.Partner == user.Username() {
+			return user.AcceptTrade()
+		}
+
+		return nil
+	}
+
+	return user.RequestTrade(username)
+}
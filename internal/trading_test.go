@@ -0,0 +1,103 @@
+package mud
+
+import "testing"
+
+// spendCoins takes coins straight out of the store, like a sale going through elsewhere
+func spendCoins(t *testing.T, world *dbWorld, username string, coins uint64) {
+	t.Helper()
+
+	err := world.store.Update(func(tx Tx) error {
+		users := userRepo(tx)
+		userData, _ := users.Get(username)
+		userData.Coins -= coins
+
+		return users.Put(&userData)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfirmTrade(t *testing.T) {
+	tests := []struct {
+		name     string
+		meddle   func(t *testing.T, world *dbWorld, ann, bob User, item *InventoryItem)
+		wantErr  bool
+		wantSwap bool
+	}{
+		{"swaps", func(t *testing.T, world *dbWorld, ann, bob User, item *InventoryItem) {}, false, true},
+		{"item gone", func(t *testing.T, world *dbWorld, ann, bob User, item *InventoryItem) {
+			ann.PullInventoryItem(item.ID)
+		}, true, false},
+		{"coin gone", func(t *testing.T, world *dbWorld, ann, bob User, item *InventoryItem) {
+			spendCoins(t, world, "bob", 5)
+		}, true, false},
+		{"walked apart", func(t *testing.T, world *dbWorld, ann, bob User, item *InventoryItem) {
+			bob.Reload()
+			bob.(*dbUser).X++
+			bob.Save()
+		}, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			ann := newTestUser(t, world, "ann")
+			bob := newTestUser(t, world, "bob")
+			item := giveTestItem(t, ann, "Iron Helm")
+			bob.AddCoins(10)
+
+			if err := ann.RequestTrade("bob"); err != nil {
+				t.Fatal(err)
+			}
+			if err := bob.AcceptTrade(); err != nil {
+				t.Fatal(err)
+			}
+			if err := ann.AddToTrade(item.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := bob.OfferCoins(10); err != nil {
+				t.Fatal(err)
+			}
+			if err := ann.ConfirmTrade(); err != nil {
+				t.Fatal(err)
+			}
+
+			test.meddle(t, world, ann, bob, item)
+			if err := bob.ConfirmTrade(); (err != nil) != test.wantErr {
+				t.Fatalf("ConfirmTrade() = %v, want error %v", err, test.wantErr)
+			}
+
+			annHas, bobHas := ann.InventoryItem(item.ID) != nil, bob.InventoryItem(item.ID) != nil
+			if bobHas != test.wantSwap || (test.wantSwap && annHas) {
+				t.Fatalf("ann has the helm %v, bob has it %v", annHas, bobHas)
+			}
+
+			wantAnn := uint64(0)
+			if test.wantSwap {
+				wantAnn = 10
+			}
+			if coins := ann.Coins(); coins != wantAnn {
+				t.Fatalf("ann has %v coins, want %v", coins, wantAnn)
+			}
+		})
+	}
+}
+
+func TestTickersKeepStoredCoins(t *testing.T) {
+	world := newTestWorld(t)
+	ann := newTestUser(t, world, "ann")
+	ann.AddEffect("poison")
+	ann.AddCoins(25)
+
+	// The ticker's copy of ann was loaded before the trade that took some of ann's coins
+	ticker := world.GetUser("ann")
+	spendCoins(t, world, "ann", 5)
+
+	ticker.TickEffects()
+	ticker.ChargePoints()
+
+	if coins := ann.Coins(); coins != 20 {
+		t.Fatalf("ann has %v coins after a tick, want 20", coins)
+	}
+}
@@ -1,5 +1,7 @@
 package mud
 
+import "time"
+
 // SlottedInventoryItem describe the slots and items in the slots
 type SlottedInventoryItem struct {
 	Name string
@@ -32,6 +34,18 @@ type User interface {
 	ChargeInfo
 	InventoryInfo
 	EquipUserInfo
+	EffectInfo
+	QuestInfo
+	ChannelInfo
+	PartyInfo
+	PvPInfo
+	CraftingInfo
+	ShopInfo
+	TradeInfo
+	BankInfo
+	SkillInfo
+	TalentInfo
+	RespecInfo
 
 	Username() string
 	Title() string
@@ -47,6 +61,7 @@ type User interface {
 
 	Log(message LogItem)
 	GetLog() []LogItem
+	GetLogSince(time.Time) []LogItem
 
 	MarkActive()
 	Cell() Cell
@@ -70,10 +85,3 @@ type ChargeInfo interface {
 	MusterAttack(string) *Attack
 	MusterCounterAttack() *Attack
 }
-
-// UserSSHAuthentication for storing SSH auth.
-type UserSSHAuthentication interface {
-	SSHKeysEmpty() bool
-	ValidateSSHKey(string) bool
-	AddSSHKey(string)
-}
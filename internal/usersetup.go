@@ -93,7 +93,8 @@ func renderChoices(selected byte, items []setMapThing) string {
 	return retstring + ansi.ColorCode("reset")
 }
 
-func renderSetup(session ssh.Session, user User) {
+// renderSetup draws the character setup screen, for a new character or one picking a new class
+func renderSetup(session ssh.Session, user User, respec bool) {
 	primarystrength, secondarystrength := user.Strengths()
 	primaryskill, secondaryskill := user.Skills()
 
@@ -103,7 +104,11 @@ func renderSetup(session ssh.Session, user User) {
 	title := ansi.ColorFunc("250+b:black")
 
 	io.WriteString(session, cursor.ClearEntireScreen()+cursor.MoveUpperLeft(1))
-	io.WriteString(session, fmt.Sprintf("Please set up your character, %v.\n\n", user.Username()))
+	if respec {
+		io.WriteString(session, fmt.Sprintf("Pick your new class, %v.\n\n", user.Username()))
+	} else {
+		io.WriteString(session, fmt.Sprintf("Please set up your character, %v.\n\n", user.Username()))
+	}
 
 	io.WriteString(session, header("Strength:                                                 "))
 	io.WriteString(session, "\n")
@@ -128,10 +133,18 @@ func renderSetup(session ssh.Session, user User) {
 	io.WriteString(session, "\n")
 
 	io.WriteString(session, "\n\n")
-	io.WriteString(session, "Press enter when you are finished.")
+	if !respec {
+		io.WriteString(session, "Press enter when you are finished.")
+	} else if price := user.ClassRespecPrice(); price > 0 {
+		io.WriteString(session, fmt.Sprintf("Press enter to change class for %v, or escape to keep the one you have.", coinString(price)))
+	} else {
+		io.WriteString(session, "Press enter to change class, or escape to keep the one you have.")
+	}
 }
 
-func setupSSHUser(ctx context.Context, cancel context.CancelFunc, done <-chan struct{}, session ssh.Session, user User, stringInput chan inputEvent) {
+// setupSSHUser runs the character setup screen. For a respec it starts from the user's class,
+// escape keeps that class, and enter changes to the new one instead of starting a new character.
+func setupSSHUser(ctx context.Context, cancel context.CancelFunc, done <-chan struct{}, session ssh.Session, user User, stringInput chan inputEvent, respec bool) {
 	tick := time.Tick(500 * time.Millisecond)
 
 	strengthPrimary := []byte{MELEEPRIMARY, RANGEPRIMARY, MAGICPRIMARY}
@@ -139,13 +152,15 @@ func setupSSHUser(ctx context.Context, cancel context.CancelFunc, done <-chan st
 	skillPrimary := []byte{CUNNINGPRIMARY, ORDERLYPRIMARY, CREATIVEPRIMARY}
 	skillSecondary := []byte{CUNNINGSECONDARY, ORDERLYSECONDARY, CREATIVESECONDARY}
 
-	user.SetClassInfo(
-		strengthPrimary[rand.Int()%len(strengthPrimary)] |
-			strengthSecondary[rand.Int()%len(strengthSecondary)] |
-			skillPrimary[rand.Int()%len(skillPrimary)] |
-			skillSecondary[rand.Int()%len(skillSecondary)])
+	if !respec {
+		user.SetClassInfo(
+			strengthPrimary[rand.Int()%len(strengthPrimary)] |
+				strengthSecondary[rand.Int()%len(strengthSecondary)] |
+				skillPrimary[rand.Int()%len(skillPrimary)] |
+				skillSecondary[rand.Int()%len(skillSecondary)])
+	}
 
-	renderSetup(session, user)
+	renderSetup(session, user, respec)
 
 	for {
 		select {
@@ -205,9 +220,20 @@ func setupSSHUser(ctx context.Context, cancel context.CancelFunc, done <-chan st
 				secondaryskill = CREATIVESECONDARY
 
 			case "ESCAPE":
+				if respec {
+					user.CancelRespec()
+					return
+				}
 				session.Close()
 
 			case "ENTER":
+				if respec {
+					if err := user.FinishRespec(); err != nil {
+						user.CancelRespec()
+						user.Log(LogItem{Message: err.Error(), MessageType: MESSAGEACTIVITY})
+					}
+					return
+				}
 				greet(user)
 				user.Initialize(true)
 				return
@@ -215,7 +241,7 @@ func setupSSHUser(ctx context.Context, cancel context.CancelFunc, done <-chan st
 
 			user.SetStrengths(primarystrength, secondarystrength)
 			user.SetSkills(primaryskill, secondaryskill)
-			renderSetup(session, user)
+			renderSetup(session, user, respec)
 
 		case <-ctx.Done():
 			cancel()
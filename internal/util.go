@@ -31,6 +31,8 @@ type LogItem struct {
 	Timestamp   time.Time   `json:""`
 	MessageType MessageType `json:""`
 	Location    *Point      `json:",omit"`
+	Channel     string      `json:",omitempty"` // Chat channel, empty for messages that aren't chat
+	To          string      `json:",omitempty"` // Who a whisper is for
 }
 
 // Point represents an (X,Y) pair in the world
@@ -93,8 +95,8 @@ func (p *Point) Bresenham(v Point, visitor func(Point) error) {
 		return
 	}
 
-	deltax := x1 - x0
-	deltay := y1 - y0
+	deltax := int64(x1) - int64(x0)
+	deltay := int64(y1) - int64(y0)
 	deltaerr := math.Abs(float64(deltay) / float64(deltax))
 	err := float64(0.0)
 	y := y0
@@ -295,6 +297,25 @@ func ToTheLeft(d Direction) Direction {
 	return DIRECTIONNORTH
 }
 
+// Opposite gives the direction facing the other way
+func Opposite(d Direction) Direction {
+	switch d {
+	case DIRECTIONNORTH:
+		return DIRECTIONSOUTH
+	case DIRECTIONEAST:
+		return DIRECTIONWEST
+	case DIRECTIONSOUTH:
+		return DIRECTIONNORTH
+	case DIRECTIONWEST:
+		return DIRECTIONEAST
+	}
+
+	return DIRECTIONNORTH
+}
+
+// BitForDirection maps directions to their bit in CellInfo.ExitBlocks
+var BitForDirection map[Direction]byte
+
 // VectorForDirection maps directions to a distance vector
 var VectorForDirection map[Direction]Vector
 
@@ -306,6 +327,13 @@ func LoadResources() {
 	loadCreatureTypes("./bestiary.json")
 	loadItemTypes("./items.json")
 	loadTerrainTypes("./terrain.json")
+	loadEffectTypes("./effects.json")
+	loadDialogueTrees("./dialogue.json")
+	loadQuestTypes("./quests.json")
+	loadRecipeTypes("./recipes.json")
+	loadShopTypes("./shops.json")
+	loadSkillTypes("./skills.json")
+	loadTalentTrees("./talents.json")
 }
 
 type transitionName struct {
@@ -362,14 +390,14 @@ func MakeGradientTransitionFunction(transitionList []string) func(float64) strin
 }
 
 // MakeTransitionFunction helps build Markov chains.
-func MakeTransitionFunction(name string, transitionList []string) (func() string, []string) {
+func MakeTransitionFunction(name string, transitionList []string) (func(*rand.Rand) string, []string) {
 
 	transitionInternalList, total, returnTransitionList := makeTransitionGradient(transitionList)
 
-	return func() string {
+	return func(rng *rand.Rand) string {
 		if transitionInternalList != nil && len(transitionInternalList) != 0 {
 			weight := 0
-			countTo := rand.Int() % total
+			countTo := rng.Int() % total
 
 			for _, item := range transitionInternalList {
 				weight += item.weight
@@ -411,6 +439,11 @@ func init() {
 		DIRECTIONEAST:  Vector{X: 1, Y: 0},
 		DIRECTIONSOUTH: Vector{X: 0, Y: 1},
 		DIRECTIONWEST:  Vector{X: -1, Y: 0}}
+	BitForDirection = map[Direction]byte{
+		DIRECTIONNORTH: NORTHBIT,
+		DIRECTIONEAST:  EASTBIT,
+		DIRECTIONSOUTH: SOUTHBIT,
+		DIRECTIONWEST:  WESTBIT}
 	DirectionForVector = make(map[Vector]Direction)
 	for k, v := range VectorForDirection {
 		DirectionForVector[v] = k
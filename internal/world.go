@@ -32,7 +32,54 @@ type World interface {
 	Attack(interface{}, interface{}, *Attack)
 
 	NewPlaceID() uint64
+	Seed() int64
 	OnlineUsers() []User
 	Chat(LogItem)
+	ConfigureRespawns(RespawnConfig)
+	ShopStock(*Creature) map[string]uint64
+	AccountInfo
 	Close()
 }
+
+func newUserData(username string, world World) UserData {
+	width, height := world.GetDimensions()
+	return UserData{
+		Username: username,
+		X:        width / 2,
+		Y:        height / 2,
+		SpawnX:   width / 2,
+		SpawnY:   height / 2,
+		HP:       baseHP,
+		MaxHP:    baseHP,
+		AP:       baseStatPoints,
+		MaxAP:    baseStatPoints,
+		MP:       baseStatPoints,
+		MaxMP:    baseStatPoints,
+		RP:       baseStatPoints,
+		MaxRP:    baseStatPoints,
+		Level:    1}
+}
+
+// seedSpawnArea lays down the starting clearing around a spawn point if nothing is there yet
+func seedSpawnArea(world World, x, y uint32) {
+	cellData := world.Cell(x, y).CellInfo()
+
+	if cellData == nil {
+		cellData = &CellInfo{
+			TerrainID:    DefaultCellType,
+			RegionNameID: world.NewPlaceID(),
+			BiomeID:      DefaultBiomeType}
+
+		for xd := -1; xd <= 1; xd++ {
+			for yd := -1; yd <= 1; yd++ {
+				cell := world.Cell(uint32(int(x)+xd), uint32(int(y)+yd))
+				if xd == 0 && yd == 0 {
+					cellData.TerrainID = DefaultCellType
+				} else {
+					cellData.TerrainID = "grass"
+				}
+				cell.SetCellInfo(cellData)
+			}
+		}
+	}
+}
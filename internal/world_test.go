@@ -0,0 +1,74 @@
+package mud
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) {
+	// The data files live at the top of the repository, next to where the server runs
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	LoadResources()
+
+	os.Exit(m.Run())
+}
+
+// newTestWorld sets up an in-memory world that goes away when the test is done
+func newTestWorld(t *testing.T) *dbWorld {
+	t.Helper()
+
+	world := NewMemoryWorld(1).(*dbWorld)
+	t.Cleanup(world.Close)
+
+	return world
+}
+
+// newTestCharacter signs in an account with the same name and makes it a character, standing at
+// the middle of the world's spawn area
+func newTestCharacter(t *testing.T, world *dbWorld, username string) User {
+	t.Helper()
+
+	if _, err := world.SignIn(username, "key-"+username); err != nil {
+		t.Fatal(err)
+	}
+	if err := world.CreateCharacter(username, username); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := world.PlayCharacter(username, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// newTestUser makes a character and puts them online
+func newTestUser(t *testing.T, world *dbWorld, username string) User {
+	t.Helper()
+
+	user := newTestCharacter(t, world, username)
+	user.MarkActive()
+
+	return user
+}
+
+// giveTestItem puts a new item from items.json in a user's inventory
+func giveTestItem(t *testing.T, user User, name string) *InventoryItem {
+	t.Helper()
+
+	item, ok := ItemTypes[name]
+	if !ok {
+		t.Fatalf("no item called %v", name)
+	}
+	item.ID = uuid.New().String()
+	if !user.AddInventoryItem(&item) {
+		t.Fatalf("couldn't give %v to %v", name, user.Username())
+	}
+
+	return &item
+}
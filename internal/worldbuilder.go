@@ -1,7 +1,6 @@
 package mud
 
 import (
-	"math/rand"
 	"time"
 )
 
@@ -35,7 +34,7 @@ type CellRenderInfo struct {
 
 // SSHInterfaceTools has miscellaneous helpers for
 type SSHInterfaceTools interface {
-	GetTerrainMap(uint32, uint32, uint32, uint32) [][]CellRenderInfo
+	GetTerrainMap(User, uint32, uint32, uint32, uint32) [][]CellRenderInfo
 }
 
 type worldBuilder struct {
@@ -46,9 +45,11 @@ func (builder *worldBuilder) populateAround(x, y uint32, xdelta, ydelta int) {
 	wwidth, wheight := builder.world.GetDimensions()
 
 	if x > 100 && x < wwidth-100 && y > 100 && y < wheight-100 {
+		rng := regionRand(builder.world, "populate", Point{X: x, Y: y})
+
 		for i := 1; i < 25; i++ {
-			xd := uint32(int(x) + (rand.Int()%i - (i / 2)))
-			yd := uint32(int(y) + (rand.Int()%i - (i / 2)))
+			xd := uint32(int(x) + (rng.Int()%i - (i / 2)))
+			yd := uint32(int(y) + (rng.Int()%i - (i / 2)))
 
 			if builder.world.Cell(xd, yd).CellInfo() != nil {
 				type diff struct {
@@ -56,7 +57,7 @@ func (builder *worldBuilder) populateAround(x, y uint32, xdelta, ydelta int) {
 				}
 
 				directions := []diff{diff{x: -1, y: 0}, diff{x: 1, y: 0}, diff{x: 0, y: -1}, diff{x: 0, y: 1}}
-				movement := directions[rand.Int()%len(directions)]
+				movement := directions[rng.Int()%len(directions)]
 
 				if builder.world.Cell(uint32(int(xd)+movement.x), uint32(int(yd)+movement.y)).CellInfo() == nil {
 					builder.StepInto(xd, yd, uint32(int(xd)+xdelta), uint32(int(yd)+ydelta))
@@ -209,7 +210,8 @@ func (builder *worldBuilder) MoveUserWest(user User) {
 	}
 }
 
-func (builder *worldBuilder) GetTerrainMap(cx, cy, width, height uint32) [][]CellRenderInfo {
+// GetTerrainMap renders the map around a point for a viewer, who sees other members of their party picked out
+func (builder *worldBuilder) GetTerrainMap(viewer User, cx, cy, width, height uint32) [][]CellRenderInfo {
 	terrainMap := make([][]CellRenderInfo, height)
 	for i := range terrainMap {
 		terrainMap[i] = make([]CellRenderInfo, width)
@@ -272,15 +274,27 @@ func (builder *worldBuilder) GetTerrainMap(cx, cy, width, height uint32) [][]Cel
 		}
 	}
 
+	var party Party
+	inParty := false
+	if viewer != nil {
+		party, inParty = viewer.Party()
+	}
+
 	for _, player := range builder.world.OnlineUsers() {
 		location := player.Location()
 		if location.X >= startx && location.X < startx+width && location.Y >= starty && location.Y < starty+height {
 			ix := location.X - startx
 			iy := location.Y - starty
 
-			terrainMap[iy][ix].FGColor = 160
+			if inParty && player.Username() != viewer.Username() && party.HasMember(player.Username()) {
+				terrainMap[iy][ix].FGColor = byte(ChatChannels[CHANNELPARTY].Color)
+				terrainMap[iy][ix].Bold = true
+				terrainMap[iy][ix].Glyph = rune('@')
+				continue
+			}
+
 			switch terrainMap[iy][ix].Glyph {
-			case rune('⁂'):
+			case rune('@'), rune('⁂'):
 				continue
 			case rune('⁑'):
 				terrainMap[iy][ix].Glyph = rune('⁂')
@@ -289,6 +303,7 @@ func (builder *worldBuilder) GetTerrainMap(cx, cy, width, height uint32) [][]Cel
 			default:
 				terrainMap[iy][ix].Glyph = rune('*')
 			}
+			terrainMap[iy][ix].FGColor = 160
 		}
 	}
 
@@ -0,0 +1,48 @@
+package mud
+
+import "testing"
+
+func TestMoveUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		move   func(WorldBuilder, User)
+		blocks byte
+		want   Vector
+	}{
+		{"north", WorldBuilder.MoveUserNorth, 0, Vector{X: 0, Y: -1}},
+		{"south", WorldBuilder.MoveUserSouth, 0, Vector{X: 0, Y: 1}},
+		{"east", WorldBuilder.MoveUserEast, 0, Vector{X: 1, Y: 0}},
+		{"west", WorldBuilder.MoveUserWest, 0, Vector{X: -1, Y: 0}},
+		{"north blocked", WorldBuilder.MoveUserNorth, NORTHBIT, Vector{}},
+		{"west blocked", WorldBuilder.MoveUserWest, WESTBIT, Vector{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			builder := NewWorldBuilder(world)
+			user := newTestUser(t, world, "walker")
+			start := *user.Location()
+			home := world.CellAtPoint(start)
+
+			// Open ground all around, so only the exits can stop the user
+			for _, vector := range VectorForDirection {
+				grass := *home.CellInfo()
+				grass.TerrainID = "clearing-grass"
+				world.CellAtPoint(start.Add(vector)).SetCellInfo(&grass)
+			}
+
+			if test.blocks != 0 {
+				cellInfo := home.CellInfo()
+				cellInfo.ExitBlocks = test.blocks
+				home.SetCellInfo(cellInfo)
+			}
+
+			test.move(builder, user)
+
+			if got := start.Vector(*user.Location()); got != test.want {
+				t.Fatalf("moved %v, want %v", got, test.want)
+			}
+		})
+	}
+}